@@ -2,14 +2,23 @@ function ShowResult(dataRes) {
     if (dataRes === undefined) {
         return
     }
+    // 切换 key 时恢复默认查看方式
+    if ($("#key_key").val() !== dataRes.keys) {
+        $("#key_hint").val("");
+    }
     $("#key_key").val(dataRes.keys);
     $("#key_type").val(dataRes.type);
     $("#key_ttl").val(dataRes.ttl);
     $("#key_value").val("");
-    $("#key_page").val(0);
+    $("#key_page").val(dataRes.page || 0);
 
     $("#TableResultHtml").hide();
     $("#aloneKeyShow").show();
+    WatchKey(dataRes);
+    if (dataRes.type !== "msg" && dataRes.data) {
+        // 重命名/复制/过期时间等操作返回刷新后的数据和提示
+        layer.msg(dataRes.data)
+    }
     if (dataRes.type === "msg"){
         layer.msg(dataRes.data)
     }else if (dataRes.type === "string") {
@@ -19,15 +28,17 @@ function ShowResult(dataRes) {
         let str = '';
         str += '<tr>';
         str += '<td>新增数据</td><td colspan="2" ><textarea  class="form-control"  id="new_list_value" placeholder="输入值"></textarea></td>';
-        str += '<td><a onclick="AddList(' + dataRes.keys + ')">新增</a></td>';
+        str += '<td><a onclick="AddList(\'LPUSH\')">头部新增</a> <a onclick="AddList(\'RPUSH\')">尾部新增</a></td>';
         str += '</tr><tr class="success" ><td>队列序号</td><td colspan="3">数据值</td></tr>';
 
+        listValues = {};
         for (var i in dataRes.list) {
             let cc = dataRes.list[i];
+            listValues[cc.index] = cc.value;
             str += '<tr>';
-            str += '<td style="width: 50px;">' + i + '</td><td><input class="form-control" id="list_index_' + cc.index + '" value="' + cc.value + '"></td>';
-            str += '<td><a onclick="UpdateList(' + dataRes.keys + ',' + cc.index + ')">修改</a></td>';
-            str += '<td><a onclick="DelList(' + dataRes.keys + ',' + cc.index + ')">删除</a></td>';
+            str += '<td style="width: 50px;">' + cc.index + '</td><td><input class="form-control" id="list_index_' + cc.index + '" value="' + cc.value + '"></td>';
+            str += '<td><a onclick="UpdateList(' + cc.index + ')">修改</a></td>';
+            str += '<td><a onclick="DelList(' + cc.index + ')">删除</a></td>';
             str += '</tr>'
         }
         $("#TabResult").html(str);
@@ -101,16 +112,49 @@ function ShowResult(dataRes) {
     }
 }
 
-function AddList(keys) {
+// 页面上 list 各下标的原值, 修改时用于检查并发修改
+var listValues = {};
 
+function HandleList(data) {
+    data["client"] = $("#SelectDB").val();
+    data["db"] = $("#SelectDBIndex").val();
+    data["key"] = $("#key_key").val();
+    data["page"] = $("#key_page").val();
+    data["token"] = GetLocalToken();
+    $.ajax({
+        type: "POST",
+        url: '/redis/handle',
+        data: data,
+        success: function (response) {
+            if (response.code === -126) {
+                NeedLogin();
+                return
+            }
+            // 列表已被修改, 使用返回的最新数据刷新
+            if (response.code === 10409) {
+                ShowResult(response.data);
+                layer.msg(response.message);
+                return
+            }
+            if (response.code !== 0) {
+                layer.msg(response.message);
+                return
+            }
+            ShowResult(response.data)
+        }
+    });
 }
 
-function DelList(keys, index) {
-
+function AddList(type) {
+    HandleList({"type": type, "value": $("#new_list_value").val()});
 }
 
-function UpdateList(keys, index) {
+function DelList(index) {
+    HandleList({"type": "LDEL", "index": index, "old": listValues[index]});
+}
 
+function UpdateList(index) {
+    HandleList({"type": "LSET", "index": index, "old": listValues[index], "value": $("#list_index_" + index).val()});
 }
 
 function AddHash(keys) {
@@ -143,6 +187,7 @@ function switchPage() {
         "key": $("#key_key").val(),
         "page": $("#key_page").val(),
         "type": $("#key_type").val(),
+        "hint": $("#key_hint").val(),
         "token": GetLocalToken(),
     };
     $.ajax({
@@ -166,4 +211,46 @@ function switchPage() {
             }
         }
     });
-}
\ No newline at end of file
+}
+
+// 已开启 keyspace 通知时订阅当前 key, 变化后自动刷新
+var keyWatcher = null;
+var keyWatchTimer = null;
+function WatchKey(dataRes) {
+    let key = dataRes.keys;
+    if (keyWatcher != null) {
+        if (keyWatcher.key === key && dataRes.notify) {
+            return
+        }
+        keyWatcher.close();
+        keyWatcher = null;
+    }
+    if (!dataRes.notify || key === undefined || key === "") {
+        return
+    }
+    let url = '/redis/keyspace?' + $.param({
+        "client": $("#SelectDB").val(),
+        "db": $("#SelectDBIndex").val(),
+        "key": key,
+        "token": GetLocalToken(),
+    });
+    keyWatcher = new EventSource(url);
+    keyWatcher.key = key;
+    keyWatcher.addEventListener("key", function (e) {
+        let ev = JSON.parse(e.data);
+        if (ev.key !== $("#key_key").val()) {
+            return
+        }
+        if (ev.event === "del" || ev.event === "expired" || ev.event === "evicted") {
+            layer.msg("key 已" + (ev.event === "del" ? "删除" : "过期"));
+            return
+        }
+        // 合并短时间内的多次变化
+        clearTimeout(keyWatchTimer);
+        keyWatchTimer = setTimeout(switchPage, 300);
+    });
+    keyWatcher.addEventListener("closed", function () {
+        keyWatcher.close();
+        keyWatcher = null;
+    });
+}
@@ -28,6 +28,12 @@ type Config struct {
 	LoginUser   []LoginUser              `mapstructure:"login_user"`
 	LocalConfig LocalConfig              `mapstructure:"config"`
 	AmapServer  AmapServer               `mapstructure:"amap_server"`
+	Profile     Profile                  `mapstructure:"profile"`
+	Trash       Trash                    `mapstructure:"trash"`
+	Baseline    Baseline                 `mapstructure:"baseline"`
+	Migrate     Migrate                  `mapstructure:"migrate"`
+	Monitor     Monitor                  `mapstructure:"monitor"`
+	Console     Console                  `mapstructure:"console"`
 }
 
 type HttpServer struct {
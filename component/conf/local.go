@@ -5,6 +5,54 @@ type LocalConfig struct {
 	ServiceName string `mapstructure:"service_name"`
 }
 
+// Profile 页面添加的连接持久化配置, file_path 为空时不保存
+type Profile struct {
+	FilePath string `mapstructure:"file_path"`
+	Secret   string `mapstructure:"secret"` // 密码加密密钥
+}
+
+// Baseline 保存的 CONFIG 基线, file_path 为空时只保存在内存
+type Baseline struct {
+	FilePath string `mapstructure:"file_path"`
+}
+
+// Migrate 迁移任务断点保存目录, 为空时只保存在内存
+type Migrate struct {
+	CheckpointDir string `mapstructure:"checkpoint_dir"`
+}
+
+// Trash 删除/覆盖前的 DUMP 快照回收站
+type Trash struct {
+	MaxEntries int     `mapstructure:"max_entries"` // 最多保留条数
+	MaxSize    int64   `mapstructure:"max_size"`    // 最多占用字节数
+	Retention  float64 `mapstructure:"retention"`   // 保留时长, 秒
+}
+
+// Monitor 定时采集 INFO
+type Monitor struct {
+	Interval float64 `mapstructure:"interval"` // 采集间隔, 秒
+	History  int     `mapstructure:"history"`  // 每个节点保留采样数
+
+	Exporter      bool    `mapstructure:"exporter"`       // inner /metrics 输出所有连接的指标
+	ScrapeTimeout float64 `mapstructure:"scrape_timeout"` // exporter 每个实例的超时, 秒
+}
+
+// Console 默认禁用的命令, 可带子命令如 "CONFIG SET"
+type Console struct {
+	Deny []string `mapstructure:"deny"`
+
+	MonitorDuration float64 `mapstructure:"monitor_duration"` // MONITOR 会话最长时间, 秒
+	MonitorSessions int     `mapstructure:"monitor_sessions"` // 同时进行的 MONITOR 会话数
+	MonitorRate     int     `mapstructure:"monitor_rate"`     // 每秒最多推送的行数, 超出丢弃
+
+	KeyspaceDuration float64 `mapstructure:"keyspace_duration"` // keyspace 事件订阅最长时间, 秒
+	KeyspaceSessions int     `mapstructure:"keyspace_sessions"` // 同时进行的 keyspace 订阅数
+	UserStreams      int     `mapstructure:"user_streams"`      // 每个用户同时进行的 keyspace/pubsub 订阅数
+	PubsubDuration   float64 `mapstructure:"pubsub_duration"`   // websocket 订阅最长时间, 秒
+	PubsubBuffer     int     `mapstructure:"pubsub_buffer"`     // 订阅消息缓冲条数, 满时丢弃最旧的
+	PubsubSessions   int     `mapstructure:"pubsub_sessions"`   // 同时进行的 websocket 订阅数
+}
+
 type AmapServer struct {
 	Key string `mapstructure:"key"`
 }
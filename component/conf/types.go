@@ -39,6 +39,11 @@ type Log struct {
 	Access struct {
 		FilePath string `mapstructure:"file_path"`
 	} `mapstructure:"access"`
+
+	// Audit 数据修改审计日志, file_path 为空时不记录
+	Audit struct {
+		FilePath string `mapstructure:"file_path"`
+	} `mapstructure:"audit"`
 }
 
 type Trace struct {
@@ -48,16 +53,21 @@ type Trace struct {
 }
 
 type Redis struct {
-	Name         string  `mapstructure:"name"`
-	Addr         string  `mapstructure:"addr"`
-	Pwd          string  `mapstructure:"pwd"`
-	Db           float64 `mapstructure:"db"`
-	DialTimeout  float64 `mapstructure:"dial_timeout"`
-	ReadTimeout  float64 `mapstructure:"read_timeout"`
-	WriteTimeout float64 `mapstructure:"write_timeout"`
-	PoolSize     float64 `mapstructure:"pool_size"`
-	MinIdleConns float64 `mapstructure:"min_idle_conns"`
-	MaxRetries   float64 `mapstructure:"max_retries"`
+	Name          string   `mapstructure:"name"`
+	Mode          string   `mapstructure:"mode"` // single(默认) / cluster / sentinel
+	Addr          string   `mapstructure:"addr"`
+	Addrs         []string `mapstructure:"addrs"`          // cluster 种子节点, 为空时使用 addr
+	MasterName    string   `mapstructure:"master_name"`    // sentinel 监控的 master 名称
+	SentinelAddrs []string `mapstructure:"sentinel_addrs"` // sentinel 节点地址
+	SentinelPwd   string   `mapstructure:"sentinel_pwd"`   // sentinel 密码
+	Pwd           string   `mapstructure:"pwd"`
+	Db            float64  `mapstructure:"db"`
+	DialTimeout   float64  `mapstructure:"dial_timeout"`
+	ReadTimeout   float64  `mapstructure:"read_timeout"`
+	WriteTimeout  float64  `mapstructure:"write_timeout"`
+	PoolSize      float64  `mapstructure:"pool_size"`
+	MinIdleConns  float64  `mapstructure:"min_idle_conns"`
+	MaxRetries    float64  `mapstructure:"max_retries"`
 }
 
 type Mysql struct {
@@ -121,6 +131,20 @@ type OSS struct {
 }
 
 type LoginUser struct {
-	UserName string `mapstructure:"user_name"`
-	UserPwd  string `mapstructure:"user_pwd"`
+	UserName string       `mapstructure:"user_name"`
+	UserPwd  string       `mapstructure:"user_pwd"`
+	Role     string       `mapstructure:"role"`  // viewer(默认) / editor / admin
+	Allow    []AccessRule `mapstructure:"allow"` // 为空表示允许全部实例
+	Deny     []AccessRule `mapstructure:"deny"`  // 优先于 allow
+
+	AllowCommands []string `mapstructure:"allow_commands"` // console 可执行的命令, 为空时使用默认禁用列表
+	DenyCommands  []string `mapstructure:"deny_commands"`  // console 禁止执行的命令, 优先于 allow_commands
+}
+
+// AccessRule 实例/db/key 访问规则, 字段为空或 "*" 表示全部
+type AccessRule struct {
+	Instance string `mapstructure:"instance"`
+	Db       string `mapstructure:"db"`
+	Key      string `mapstructure:"key"`       // key 通配符, 支持 * ?
+	ReadOnly bool   `mapstructure:"read_only"` // 仅 allow 有效, 匹配时不允许写
 }
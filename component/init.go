@@ -1,9 +1,12 @@
 package component
 
 import (
+	"fmt"
+
 	"github.com/fighthorse/redisAdmin/component/conf"
 	"github.com/fighthorse/redisAdmin/component/httpclient"
 	"github.com/fighthorse/redisAdmin/component/log"
+	"github.com/fighthorse/redisAdmin/component/profile"
 	"github.com/fighthorse/redisAdmin/component/thirdpart/trace_redis"
 	"github.com/fighthorse/redisAdmin/component/trace"
 )
@@ -11,6 +14,15 @@ import (
 func InitComponent() {
 	// redis cfg
 	trace_redis.InitCfg(conf.GConfig.Redis)
+	// 页面添加的连接
+	profile.Init(conf.GConfig.Profile)
+	list, err := profile.List()
+	if err != nil {
+		panic(fmt.Errorf("load redis profile error: %s", err))
+	}
+	for _, v := range list {
+		trace_redis.AddCfg(v)
+	}
 	// http cfg
 	httpclient.Init(conf.GConfig.HttpServer)
 	httpclient.InitCircuitBreaker(conf.GConfig.HttpBreaker)
@@ -22,12 +22,15 @@ type (
 		appLogger zerolog.Logger
 		//AccessLogger 访问日志句柄（非实时， 100ms刷新一次）
 		accessLogger zerolog.Logger
+		//AuditLogger 审计日志句柄（非实时， 100ms刷新一次）
+		auditLogger zerolog.Logger
 	}
 )
 
 var (
 	SrvLogger = &srvlogger{
-		appLogger: zerolog.New(os.Stdout).With().Timestamp().Logger(),
+		appLogger:   zerolog.New(os.Stdout).With().Timestamp().Logger(),
+		auditLogger: zerolog.Nop(),
 	}
 )
 
@@ -74,6 +77,23 @@ func Init() {
 		SrvLogger.accessLogger = zerolog.New(w).With().Timestamp().Logger()
 
 	}
+
+	if fileName := conf.GConfig.Log.Audit.FilePath; fileName != "" {
+		// 初始化审计日志
+		fmt.Println("AppAuditLogPath:" + fileName)
+		err := os.MkdirAll(path.Dir(fileName), 0777)
+		if err != nil {
+			panic(fmt.Errorf("creat log dir error: %s", err))
+		}
+		f, err := os.OpenFile(fileName, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+		if err != nil {
+			panic(fmt.Errorf("open log file error: %s", err))
+		}
+		w := diode.NewWriter(f, 1000000, 100*time.Millisecond, func(missed int) {
+			SrvLogger.appLogger.Log().Int("count", missed).Msg("audit_log_miss")
+		})
+		SrvLogger.auditLogger = zerolog.New(w).With().Timestamp().Logger()
+	}
 }
 
 type Fields map[string]interface{}
@@ -160,6 +180,12 @@ func AccessLog(ctx context.Context, fields Fields) {
 	//l2.Log().Msg("")
 }
 
+// AuditLog 审计日志, 字段平铺便于查询
+func AuditLog(ctx context.Context, fields Fields) {
+	traceId := TraceIdFromCtx(ctx)
+	SrvLogger.auditLogger.Log().Str("trace_id", traceId).Fields(map[string]interface{}(fields)).Msg("audit")
+}
+
 func AppLog(ctx context.Context) *zerolog.Logger {
 	traceId := TraceIdFromCtx(ctx)
 	l := SrvLogger.appLogger.With().Str("trace_id", traceId).Logger()
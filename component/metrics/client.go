@@ -5,4 +5,5 @@ import "github.com/prometheus/client_golang/prometheus"
 func init() {
 	//---float---//
 	prometheus.MustRegister(apiCount)
+	prometheus.MustRegister(redisInfo, redisKeyspace, redisCommand)
 }
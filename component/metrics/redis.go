@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// INFO 采集的指标, 按 instance/node 区分
+var (
+	redisInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "redis_info", Help: "redis INFO numeric fields"},
+		[]string{"instance", "node", "section", "field"})
+	redisKeyspace = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "redis_info_keyspace", Help: "redis INFO keyspace"},
+		[]string{"instance", "node", "db", "field"})
+	redisCommand = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "redis_info_command", Help: "redis INFO commandstats and latencystats"},
+		[]string{"instance", "node", "cmd", "field"})
+
+	redisVecs = map[string]*prometheus.GaugeVec{
+		"info":     redisInfo,
+		"keyspace": redisKeyspace,
+		"command":  redisCommand,
+	}
+
+	seriesMux sync.Mutex
+	series    = map[string]map[string][]redisSeries{}
+)
+
+// A RedisGauge is a numeric field of INFO, Metric is one of info/keyspace/command
+// and Key is the section, db or command name accordingly.
+type RedisGauge struct {
+	Metric string
+	Key    string
+	Field  string
+	Value  float64
+}
+
+type redisSeries struct {
+	metric string
+	labels []string
+}
+
+// SetRedisInfo 更新节点指标, 删除本次没有采集到的旧指标
+func SetRedisInfo(instance, node string, gauges []RedisGauge) {
+	seriesMux.Lock()
+	defer seriesMux.Unlock()
+	current := make([]redisSeries, 0, len(gauges))
+	seen := make(map[string]bool, len(gauges))
+	for _, g := range gauges {
+		vec, ok := redisVecs[g.Metric]
+		if !ok {
+			continue
+		}
+		labels := []string{instance, node, g.Key, g.Field}
+		vec.WithLabelValues(labels...).Set(g.Value)
+		current = append(current, redisSeries{metric: g.Metric, labels: labels})
+		seen[g.Metric+"\x00"+g.Key+"\x00"+g.Field] = true
+	}
+	if series[instance] == nil {
+		series[instance] = map[string][]redisSeries{}
+	}
+	for _, s := range series[instance][node] {
+		if !seen[s.metric+"\x00"+s.labels[2]+"\x00"+s.labels[3]] {
+			redisVecs[s.metric].DeleteLabelValues(s.labels...)
+		}
+	}
+	series[instance][node] = current
+}
+
+// DelRedisInfo 删除实例的所有指标, 连接删除时使用
+func DelRedisInfo(instance string) {
+	seriesMux.Lock()
+	defer seriesMux.Unlock()
+	for _, list := range series[instance] {
+		for _, s := range list {
+			redisVecs[s.metric].DeleteLabelValues(s.labels...)
+		}
+	}
+	delete(series, instance)
+}
+
+// RedisInfoInstances 已采集指标的实例
+func RedisInfoInstances() []string {
+	seriesMux.Lock()
+	defer seriesMux.Unlock()
+	out := make([]string, 0, len(series))
+	for name := range series {
+		out = append(out, name)
+	}
+	return out
+}
@@ -1,10 +1,25 @@
 package middleware
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/fighthorse/redisAdmin/component/self_errors"
 	"github.com/fighthorse/redisAdmin/internal/service/login"
+	"github.com/fighthorse/redisAdmin/protos"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 )
 
+// adminPaths 仅 admin 角色可访问的接口, 在 TokenRequired 中校验
+var adminPaths = map[string]bool{
+	"/redis/clients/kill": true,
+	"/redis/monitor":      true,
+}
+
 func AuthRequired(c *gin.Context) {
 
 }
@@ -33,5 +48,67 @@ func TokenRequired(c *gin.Context) {
 		c.Abort()
 		return
 	}
+	if adminPaths[c.FullPath()] && !login.HasRole(data.Name, login.RoleAdmin) {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.PermissionErr, nil, ""))
+		c.Abort()
+		return
+	}
+	// 实例/db/key 读权限
+	p := bindParams(c)
+	if instance := p["client"]; instance != "" {
+		// 通配符 key 由 work 中对 scan 结果逐个校验
+		key := p["key"]
+		if strings.Contains(key, "*") {
+			key = ""
+		}
+		if err := login.CheckAccess(data.Name, instance, p["db"], key, false); err != nil {
+			c.JSON(200, self_errors.ErrExport(err))
+			c.Abort()
+			return
+		}
+	}
 	c.Set("user_info", data)
 }
+
+// AdminRequired 仅 admin 角色可访问, 需在 TokenRequired 之后使用
+func AdminRequired(c *gin.Context) {
+	data, ok := c.Get("user_info")
+	person, _ := data.(*protos.Person)
+	if !ok || person == nil || !login.HasRole(person.Name, login.RoleAdmin) {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.PermissionErr, nil, ""))
+		c.Abort()
+		return
+	}
+}
+
+// bindParams 按 ShouldBind 的取值规则读取 client/db/key, 保证校验的就是 handler 使用的值
+// json 请求读取 body 后还原; 表单请求 body 优先于 query
+func bindParams(c *gin.Context) map[string]string {
+	out := make(map[string]string, 3)
+	names := []string{"client", "db", "key"}
+	if c.Request.Method != "GET" && c.ContentType() == binding.MIMEJSON && c.Request.Body != nil {
+		body, err := ioutil.ReadAll(c.Request.Body)
+		c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return out
+		}
+		m := make(map[string]interface{})
+		if json.Unmarshal(body, &m) != nil {
+			return out
+		}
+		for _, name := range names {
+			if v, ok := m[name]; ok && v != nil {
+				out[name] = fmt.Sprint(v)
+			}
+		}
+		return out
+	}
+	for _, name := range names {
+		if v, ok := c.GetPostForm(name); ok {
+			out[name] = v
+		} else {
+			out[name] = c.Query(name)
+		}
+	}
+	return out
+}
@@ -0,0 +1,254 @@
+package profile
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fighthorse/redisAdmin/component/conf"
+)
+
+// 页面添加的redis连接, 密码加密后保存在本地文件
+
+var (
+	ErrNotFound = errors.New("连接不存在")
+
+	mux      sync.RWMutex
+	filePath string
+	aead     cipher.AEAD
+	profiles = map[string]*Profile{}
+)
+
+// A Profile is a redis connection added through the api
+type Profile struct {
+	Name          string   `json:"name"`
+	Mode          string   `json:"mode"`
+	Addr          string   `json:"addr"`
+	Addrs         []string `json:"addrs"`
+	Pwd           string   `json:"pwd"` // 加密
+	Db            int      `json:"db"`
+	MasterName    string   `json:"master_name"`
+	SentinelAddrs []string `json:"sentinel_addrs"`
+	SentinelPwd   string   `json:"sentinel_pwd"` // 加密
+	UpdatedBy     string   `json:"updated_by"`
+	UpdatedAt     string   `json:"updated_at"`
+}
+
+// Init loads profiles from file, profiles are kept in memory only when file_path is empty.
+func Init(cfg conf.Profile) {
+	if cfg.FilePath == "" {
+		return
+	}
+	if cfg.Secret == "" {
+		panic(fmt.Errorf("profile secret can not be empty"))
+	}
+	key := sha256.Sum256([]byte(cfg.Secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		panic(fmt.Errorf("profile cipher error: %s", err))
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(fmt.Errorf("profile cipher error: %s", err))
+	}
+
+	mux.Lock()
+	defer mux.Unlock()
+	filePath = cfg.FilePath
+	aead = gcm
+	if err := os.MkdirAll(path.Dir(filePath), 0755); err != nil {
+		panic(fmt.Errorf("creat profile dir error: %s", err))
+	}
+	b, err := ioutil.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		panic(fmt.Errorf("read profile file error: %s", err))
+	}
+	var list []*Profile
+	if err := json.Unmarshal(b, &list); err != nil {
+		panic(fmt.Errorf("parse profile file error: %s", err))
+	}
+	for _, v := range list {
+		profiles[v.Name] = v
+	}
+}
+
+// Get returns a stored profile with decrypted passwords
+func Get(name string) (conf.Redis, error) {
+	mux.RLock()
+	defer mux.RUnlock()
+	p, ok := profiles[name]
+	if !ok {
+		return conf.Redis{}, ErrNotFound
+	}
+	return toRedis(p)
+}
+
+// Has returns true if the connection is added through the api
+func Has(name string) bool {
+	mux.RLock()
+	defer mux.RUnlock()
+	_, ok := profiles[name]
+	return ok
+}
+
+// List returns all stored profiles with decrypted passwords
+func List() ([]conf.Redis, error) {
+	mux.RLock()
+	defer mux.RUnlock()
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out := make([]conf.Redis, 0, len(names))
+	for _, name := range names {
+		v, err := toRedis(profiles[name])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err)
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// Save adds or replaces a profile and writes the file
+func Save(v conf.Redis, user string) error {
+	mux.Lock()
+	defer mux.Unlock()
+	pwd, err := encrypt(v.Pwd)
+	if err != nil {
+		return err
+	}
+	sentinelPwd, err := encrypt(v.SentinelPwd)
+	if err != nil {
+		return err
+	}
+	old := profiles[v.Name]
+	profiles[v.Name] = &Profile{
+		Name:          v.Name,
+		Mode:          v.Mode,
+		Addr:          v.Addr,
+		Addrs:         v.Addrs,
+		Pwd:           pwd,
+		Db:            int(v.Db),
+		MasterName:    v.MasterName,
+		SentinelAddrs: v.SentinelAddrs,
+		SentinelPwd:   sentinelPwd,
+		UpdatedBy:     user,
+		UpdatedAt:     time.Now().Format("2006-01-02 15:04:05"),
+	}
+	if err := flush(); err != nil {
+		if old == nil {
+			delete(profiles, v.Name)
+		} else {
+			profiles[v.Name] = old
+		}
+		return err
+	}
+	return nil
+}
+
+// Del removes a profile and writes the file
+func Del(name string) error {
+	mux.Lock()
+	defer mux.Unlock()
+	old, ok := profiles[name]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(profiles, name)
+	if err := flush(); err != nil {
+		profiles[name] = old
+		return err
+	}
+	return nil
+}
+
+// flush writes all profiles to a temp file then renames it
+func flush() error {
+	if filePath == "" {
+		return nil
+	}
+	list := make([]*Profile, 0, len(profiles))
+	for _, v := range profiles {
+		list = append(list, v)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	b, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filePath + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filePath)
+}
+
+func toRedis(p *Profile) (conf.Redis, error) {
+	pwd, err := decrypt(p.Pwd)
+	if err != nil {
+		return conf.Redis{}, err
+	}
+	sentinelPwd, err := decrypt(p.SentinelPwd)
+	if err != nil {
+		return conf.Redis{}, err
+	}
+	return conf.Redis{
+		Name:          p.Name,
+		Mode:          p.Mode,
+		Addr:          p.Addr,
+		Addrs:         p.Addrs,
+		Pwd:           pwd,
+		Db:            float64(p.Db),
+		MasterName:    p.MasterName,
+		SentinelAddrs: p.SentinelAddrs,
+		SentinelPwd:   sentinelPwd,
+	}, nil
+}
+
+// encrypt 未开启持久化时只保存在内存, 不加密
+func encrypt(s string) (string, error) {
+	if s == "" || aead == nil {
+		return s, nil
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	b := aead.Seal(nonce, nonce, []byte(s), nil)
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+func decrypt(s string) (string, error) {
+	if s == "" || aead == nil {
+		return s, nil
+	}
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+	if len(b) < aead.NonceSize() {
+		return "", errors.New("invalid encrypted password")
+	}
+	plain, err := aead.Open(nil, b[:aead.NonceSize()], b[aead.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("decrypt password failed, secret changed?")
+	}
+	return string(plain), nil
+}
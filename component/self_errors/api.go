@@ -1,6 +1,7 @@
 package self_errors
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/gin-gonic/gin"
@@ -15,8 +16,31 @@ var (
 	JsonErr = ErrCode{Code: 10000, Msg: "参数解析失败"}
 
 	ParamsErr = ErrCode{Code: 10000, Msg: "参数校验失败"}
+
+	PermissionErr = ErrCode{Code: 10403, Msg: "没有权限"}
+
+	ConflictErr = ErrCode{Code: 10409, Msg: "数据已被修改"}
 )
 
+// PermissionError 权限校验失败
+type PermissionError struct {
+	Reason string
+}
+
+func (e *PermissionError) Error() string {
+	return e.Reason
+}
+
+// ConflictError 数据已被并发修改, Data 为最新数据供页面刷新
+type ConflictError struct {
+	Reason string
+	Data   interface{}
+}
+
+func (e *ConflictError) Error() string {
+	return e.Reason
+}
+
 func JsonErrExport(data ErrCode, err error, userMsg string) map[string]interface{} {
 	errMsg := ""
 	if err != nil {
@@ -28,3 +52,16 @@ func JsonErrExport(data ErrCode, err error, userMsg string) map[string]interface
 	return gin.H{"code": data.Code, "message": fmt.Sprintf("%s%s", data.Msg, errMsg), "data": map[string]interface{}{}}
 
 }
+
+// ErrExport 业务错误输出, 权限错误返回 PermissionErr 错误码
+func ErrExport(err error) map[string]interface{} {
+	var pe *PermissionError
+	if errors.As(err, &pe) {
+		return JsonErrExport(PermissionErr, nil, pe.Reason)
+	}
+	var ce *ConflictError
+	if errors.As(err, &ce) {
+		return gin.H{"code": ConflictErr.Code, "message": ce.Reason, "data": ce.Data}
+	}
+	return gin.H{"code": -1, "message": "" + err.Error(), "data": map[string]interface{}{}}
+}
@@ -14,9 +14,11 @@ import (
 type Client struct {
 	*goredis.Client
 
-	mux    sync.RWMutex
-	log    log.Logger
-	config *Config
+	mux     sync.RWMutex
+	log     log.Logger
+	config  *Config
+	cluster *goredis.ClusterClient
+	stop    func() // stops background goroutines, e.g. the sentinel watcher
 }
 
 // New creates a new redis client with config given and a dummy logger.
@@ -28,6 +30,13 @@ func New(config *Config) (*Client, error) {
 func NewWithLogger(config *Config, log log.Logger) (*Client, error) {
 	config.FillWithDefaults()
 
+	switch config.Mode {
+	case ModeCluster:
+		return newClusterWithLogger(config, log)
+	case ModeSentinel:
+		return newSentinelWithLogger(config, log)
+	}
+
 	client := goredis.NewClient(&goredis.Options{
 		Network:      config.Network,
 		Addr:         config.Addr,
@@ -49,10 +58,87 @@ func NewWithLogger(config *Config, log log.Logger) (*Client, error) {
 	}, nil
 }
 
+// newClusterWithLogger creates a cluster aware client. The embedded client never
+// dials by itself, all commands and pipelines are routed by the cluster client,
+// so every wrapper of RedisClient works for cluster as well.
+func newClusterWithLogger(config *Config, log log.Logger) (*Client, error) {
+	if len(config.Addrs) == 0 {
+		return nil, ErrClusterAddrs
+	}
+	cluster := goredis.NewClusterClient(&goredis.ClusterOptions{
+		Addrs:        config.Addrs,
+		Password:     config.Passwd,
+		DialTimeout:  time.Duration(config.DialTimeout) * time.Millisecond,
+		ReadTimeout:  time.Duration(config.ReadTimeout) * time.Millisecond,
+		WriteTimeout: time.Duration(config.WriteTimeout) * time.Millisecond,
+		PoolSize:     config.PoolSize,
+		PoolTimeout:  time.Duration(config.PoolTimeout) * time.Second,
+		MinIdleConns: config.MinIdleConns,
+		MaxRetries:   config.MaxRetries,
+	})
+
+	client := goredis.NewClient(&goredis.Options{
+		Addr:     config.Addrs[0],
+		Password: config.Passwd,
+	})
+	client.WrapProcess(func(func(cmd goredis.Cmder) error) func(cmd goredis.Cmder) error {
+		return cluster.Process
+	})
+	client.WrapProcessPipeline(func(func([]goredis.Cmder) error) func([]goredis.Cmder) error {
+		return func(cmds []goredis.Cmder) error {
+			pipe := cluster.Pipeline()
+			for _, cmd := range cmds {
+				_ = pipe.Process(cmd)
+			}
+			_, err := pipe.Exec()
+			return err
+		}
+	})
+
+	return &Client{
+		Client:  client,
+		config:  config,
+		log:     log,
+		cluster: cluster,
+	}, nil
+}
+
+// IsCluster returns true if the client is connected to a redis cluster
+func (c *Client) IsCluster() bool {
+	return c.cluster != nil
+}
+
+// ForEachMaster calls fn for each master node concurrently, a single node
+// client is treated as its own master.
+func (c *Client) ForEachMaster(fn func(client *goredis.Client) error) error {
+	if c.cluster != nil {
+		return c.cluster.ForEachMaster(fn)
+	}
+	return fn(c.Client)
+}
+
+// Close closes the client and the cluster client if there is one.
+func (c *Client) Close() error {
+	if c.stop != nil {
+		c.stop()
+	}
+	if c.cluster != nil {
+		_ = c.cluster.Close()
+	}
+	return c.Client.Close()
+}
+
 // Select changes db by coping out a new client.
 //
 // NOTE: There maybe a deadlock if internal invocations panic!!!
 func (c *Client) Select(db int) (*Client, error) {
+	if c.cluster != nil {
+		if db == 0 {
+			return c, nil
+		}
+		return nil, ErrClusterSelect
+	}
+
 	c.mux.RLock()
 
 	opts := c.Options()
@@ -68,25 +154,33 @@ func (c *Client) Select(db int) (*Client, error) {
 	c.mux.Lock()
 	defer c.mux.Unlock()
 
-	config := &Config{
-		Network:      opts.Network,
-		Addr:         opts.Addr,
-		Passwd:       opts.Password,
-		DB:           db,
-		DialTimeout:  int(opts.DialTimeout / time.Millisecond),
-		ReadTimeout:  int(opts.ReadTimeout / time.Millisecond),
-		WriteTimeout: int(opts.WriteTimeout / time.Millisecond),
-		PoolSize:     opts.PoolSize,
-		PoolTimeout:  int(opts.PoolTimeout / time.Second),
-		MinIdleConns: opts.MinIdleConns,
-		MaxRetries:   opts.MaxRetries,
+	var config *Config
+	if c.config.Mode == ModeSentinel {
+		// sentinel client has no fixed addr, copy the whole config
+		cfg := *c.config
+		cfg.DB = db
+		config = &cfg
+	} else {
+		config = &Config{
+			Network:      opts.Network,
+			Addr:         opts.Addr,
+			Passwd:       opts.Password,
+			DB:           db,
+			DialTimeout:  int(opts.DialTimeout / time.Millisecond),
+			ReadTimeout:  int(opts.ReadTimeout / time.Millisecond),
+			WriteTimeout: int(opts.WriteTimeout / time.Millisecond),
+			PoolSize:     opts.PoolSize,
+			PoolTimeout:  int(opts.PoolTimeout / time.Second),
+			MinIdleConns: opts.MinIdleConns,
+			MaxRetries:   opts.MaxRetries,
+		}
 	}
 
 	name := config.Name()
 
 	// first, try loading a client from default manager
 	client, err := DefaultMgr.NewClientWithLogger(name, c.log)
-	if err == nil {
+	if err == nil && client.config.Passwd == config.Passwd {
 		return client, nil
 	}
 	c.log.Warnf("DefaultMgr.NewClient(%s): %v", name, err)
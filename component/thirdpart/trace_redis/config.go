@@ -3,14 +3,18 @@ package trace_redis
 import (
 	"fmt"
 	"runtime"
+	"strings"
+	"sync"
 
 	"github.com/fighthorse/redisAdmin/component/conf"
+	goredis "github.com/go-redis/redis"
 )
 
-// Global redis manager
+// Global redis manager, 页面增删连接时会修改, 读写需持有 cfgMux
 var (
 	RedisMgr  *Manager
 	marooning = ManagerConfig{}
+	cfgMux    sync.RWMutex
 )
 
 const (
@@ -23,24 +27,39 @@ const (
 	MaxRetries      = 1
 )
 
+// connection modes of a config
+const (
+	ModeSingle   = "single"
+	ModeCluster  = "cluster"
+	ModeSentinel = "sentinel"
+)
+
 // A config of go redis
 type Config struct {
-	Network              string `yaml:"network"`
-	Addr                 string `yaml:"addr"`
-	Passwd               string `yaml:"password"`
-	DB                   int    `yaml:"database"`
-	DialTimeout          int    `yaml:"dial_timeout"`
-	ReadTimeout          int    `yaml:"read_timeout"`
-	WriteTimeout         int    `yaml:"write_timeout"`
-	PoolSize             int    `yaml:"pool_size"`
-	PoolTimeout          int    `yaml:"pool_timeout"`
-	MinIdleConns         int    `yaml:"min_idle_conns"`
-	MaxRetries           int    `yaml:"max_retries"`
-	TraceIncludeNotFound bool   `yaml:"trace_include_not_found"`
+	Mode                 string   `yaml:"mode"`
+	Network              string   `yaml:"network"`
+	Addr                 string   `yaml:"addr"`
+	Addrs                []string `yaml:"addrs"`
+	MasterName           string   `yaml:"master_name"`
+	SentinelAddrs        []string `yaml:"sentinel_addrs"`
+	SentinelPasswd       string   `yaml:"sentinel_password"`
+	Passwd               string   `yaml:"password"`
+	DB                   int      `yaml:"database"`
+	DialTimeout          int      `yaml:"dial_timeout"`
+	ReadTimeout          int      `yaml:"read_timeout"`
+	WriteTimeout         int      `yaml:"write_timeout"`
+	PoolSize             int      `yaml:"pool_size"`
+	PoolTimeout          int      `yaml:"pool_timeout"`
+	MinIdleConns         int      `yaml:"min_idle_conns"`
+	MaxRetries           int      `yaml:"max_retries"`
+	TraceIncludeNotFound bool     `yaml:"trace_include_not_found"`
 }
 
 // Name returns client name of the config
 func (c *Config) Name() string {
+	if c.Mode == ModeSentinel {
+		return fmt.Sprintf("sentinel(%s@%s/%d)", c.MasterName, strings.Join(c.SentinelAddrs, ","), c.DB)
+	}
 	return fmt.Sprintf("%s(%s/%d)", c.Network, c.Addr, c.DB)
 }
 
@@ -48,6 +67,25 @@ func (c *Config) Name() string {
 func (c *Config) FillWithDefaults() {
 	maxCPU := runtime.NumCPU()
 
+	if c.Mode == "" {
+		c.Mode = ModeSingle
+	}
+
+	if c.Mode == ModeCluster {
+		if len(c.Addrs) == 0 && c.Addr != "" {
+			c.Addrs = strings.Split(c.Addr, ",")
+		}
+		if c.Addr == "" {
+			c.Addr = strings.Join(c.Addrs, ",")
+		}
+		// cluster only has db 0
+		c.DB = 0
+	}
+
+	if c.Mode == ModeSentinel && c.Addr == "" {
+		c.Addr = c.MasterName
+	}
+
 	if c.DialTimeout <= 0 || c.DialTimeout > MaxDialTimeout*maxCPU {
 		c.DialTimeout = MaxDialTimeout
 	}
@@ -85,7 +123,12 @@ func convertToConfig(c conf.Redis) *Config {
 	fn := func(s float64) int {
 		return int(s * 1000)
 	}
+	ret.Mode = c.Mode
 	ret.Addr = c.Addr
+	ret.Addrs = c.Addrs
+	ret.MasterName = c.MasterName
+	ret.SentinelAddrs = c.SentinelAddrs
+	ret.SentinelPasswd = c.SentinelPwd
 	ret.Passwd = c.Pwd
 	ret.DB = int(c.Db)
 	ret.DialTimeout = fn(c.DialTimeout)
@@ -101,6 +144,8 @@ func InitCfg(cfg []conf.Redis) {
 	if cfg == nil || len(cfg) <= 0 {
 		return
 	}
+	cfgMux.Lock()
+	defer cfgMux.Unlock()
 	for _, v := range cfg {
 		c := convertToConfig(v)
 		marooning[v.Name] = c
@@ -110,17 +155,91 @@ func InitCfg(cfg []conf.Redis) {
 
 func AddCfg(v conf.Redis) {
 	c := convertToConfig(v)
+	cfgMux.Lock()
+	defer cfgMux.Unlock()
 	marooning[v.Name] = c
-	RedisMgr = NewManager(&marooning)
+	if RedisMgr == nil {
+		RedisMgr = NewManager(&marooning)
+		return
+	}
+	RedisMgr.Add(v.Name, c)
+}
+
+func DelCfg(name string) {
+	cfgMux.Lock()
+	defer cfgMux.Unlock()
+	delete(marooning, name)
+	if RedisMgr != nil {
+		RedisMgr.Del(name)
+	}
+}
+
+func HasCfg(name string) bool {
+	cfgMux.RLock()
+	defer cfgMux.RUnlock()
+	_, ok := marooning[name]
+	return ok
+}
+
+// Mgr 当前的 RedisMgr, 未初始化时为 nil
+func Mgr() *Manager {
+	cfgMux.RLock()
+	defer cfgMux.RUnlock()
+	return RedisMgr
+}
+
+// TestCfg connects to redis with config given and PINGs it.
+func TestCfg(v conf.Redis) error {
+	client, err := New(convertToConfig(v))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if client.IsCluster() {
+		return client.ForEachMaster(func(node *goredis.Client) error {
+			return node.Ping().Err()
+		})
+	}
+	return client.Ping().Err()
+}
+
+// CfgNames 所有已配置的连接名
+func CfgNames() []string {
+	mgr := Mgr()
+	if mgr == nil {
+		return nil
+	}
+	return mgr.Names()
 }
 
 func ListCfg() map[string]interface{} {
+	cfgMux.RLock()
+	defer cfgMux.RUnlock()
+	if RedisMgr == nil {
+		return map[string]interface{}{}
+	}
 	out := RedisMgr.List(&marooning)
 	return out
 }
 
+// cfgAddr 连接配置中的地址和 db
+func cfgAddr(schema string) (string, int) {
+	cfgMux.RLock()
+	defer cfgMux.RUnlock()
+	c, ok := marooning[schema]
+	if !ok {
+		return "", 0
+	}
+	return c.Addr, c.DB
+}
+
 func NewClient(schema string) *RedisClient {
-	client, err := RedisMgr.NewClient(schema)
+	mgr := Mgr()
+	if mgr == nil {
+		panic(fmt.Errorf("redis no such config section: %s", schema))
+	}
+	client, err := mgr.NewClient(schema)
 	if err != nil {
 		if err == ErrNotFoundConfig {
 			panic(fmt.Errorf("redis no such config section: %s", schema))
@@ -128,13 +247,16 @@ func NewClient(schema string) *RedisClient {
 		panic(fmt.Errorf("new redis client error: %s", err.Error()))
 	}
 
-	addr := marooning[schema].Addr
-	db := marooning[schema].DB
+	addr, db := cfgAddr(schema)
 	return &RedisClient{client, schema, addr, db}
 }
 
 func NewClientDb(schema string, db int) *RedisClient {
-	client, err := RedisMgr.NewClient(schema)
+	mgr := Mgr()
+	if mgr == nil {
+		panic(fmt.Errorf("redis no such config section: %s", schema))
+	}
+	client, err := mgr.NewClient(schema)
 	if err != nil {
 		if err == ErrNotFoundConfig {
 			panic(fmt.Errorf("redis no such config section: %s", schema))
@@ -142,6 +264,44 @@ func NewClientDb(schema string, db int) *RedisClient {
 		panic(fmt.Errorf("new redis client error: %s", err.Error()))
 	}
 	clientNew, err := client.Select(db)
-	addr := marooning[schema].Addr
+	if err != nil {
+		panic(fmt.Errorf("redis select db error: %s", err.Error()))
+	}
+	addr, _ := cfgAddr(schema)
 	return &RedisClient{clientNew, schema, addr, db}
 }
+
+// CloseClient 关闭 NewClient/NewClientDb 返回的连接, 其他 db 的连接缓存在 DefaultMgr 中, 先从中移除
+func CloseClient(c *RedisClient) {
+	if c == nil || c.Client == nil {
+		return
+	}
+	name := c.config.Name()
+	if old, ok := DefaultMgr.clients.Load(name); ok && old == c.Client {
+		DefaultMgr.clients.Delete(name)
+	}
+	_ = c.Close()
+}
+
+// MgrClient 从 RedisMgr 获取指定 db 的连接, 出错时返回 error 而不是 panic, 供后台任务使用
+func MgrClient(schema string, db int) (*RedisClient, error) {
+	mgr := Mgr()
+	if mgr == nil {
+		return nil, ErrNotFoundConfig
+	}
+	client, err := mgr.NewClient(schema)
+	if err != nil {
+		return nil, err
+	}
+	if db != 0 {
+		client, err = client.Select(db)
+		if err != nil {
+			return nil, err
+		}
+	}
+	cfg, err := mgr.Config(schema)
+	if err != nil {
+		return nil, err
+	}
+	return &RedisClient{client, schema, cfg.Addr, db}, nil
+}
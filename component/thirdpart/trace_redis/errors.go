@@ -6,4 +6,6 @@ import "errors"
 var (
 	ErrNotFoundConfig = errors.New("no config found")
 	ErrInvalidConfig  = errors.New("named config is not a valid *Config type")
+	ErrClusterSelect  = errors.New("redis cluster only supports db 0")
+	ErrClusterAddrs   = errors.New("redis cluster requires at least one addr")
 )
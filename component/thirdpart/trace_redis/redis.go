@@ -1,6 +1,7 @@
 package trace_redis
 
 import (
+	"sort"
 	"sync"
 
 	"github.com/fighthorse/redisAdmin/component/log"
@@ -26,6 +27,9 @@ func (mgr *Manager) NewClient(name string) (*Client, error) {
 
 // NewClientWithLogger finds or creates a redis client registered with the name and logger given
 func (mgr *Manager) NewClientWithLogger(name string, log log.Logger) (*Client, error) {
+	if mgr == nil {
+		return nil, ErrNotFoundConfig
+	}
 	// first, try clients store
 	mgrclient, ok := mgr.clients.Load(name)
 	if ok {
@@ -57,6 +61,9 @@ func (mgr *Manager) NewClientWithLogger(name string, log log.Logger) (*Client, e
 
 // Config returns a config registered with the name given
 func (mgr *Manager) Config(name string) (config *Config, err error) {
+	if mgr == nil {
+		return nil, ErrNotFoundConfig
+	}
 	marooning, ok := mgr.configs.Load(name)
 	if !ok {
 		err = ErrNotFoundConfig
@@ -73,7 +80,7 @@ func (mgr *Manager) Config(name string) (config *Config, err error) {
 
 // Add registers a new config of redis with the name given.
 //
-// NOTE: It will remove client related to the name if existed.
+// NOTE: It will remove and close client related to the name if existed.
 func (mgr *Manager) Add(name string, config *Config) {
 	config.FillWithDefaults()
 
@@ -81,13 +88,25 @@ func (mgr *Manager) Add(name string, config *Config) {
 	mgr.configs.Store(name, config)
 
 	// remove old client
-	mgr.clients.Delete(name)
+	mgr.closeClient(name)
 }
 
 // Del removes both client and config of redis registered with the name given.
 func (mgr *Manager) Del(name string) {
 	mgr.configs.Delete(name)
-	mgr.clients.Delete(name)
+	mgr.closeClient(name)
+}
+
+// closeClient removes the client registered with the name and closes it,
+// which also stops its background goroutines, e.g. the sentinel watcher.
+func (mgr *Manager) closeClient(name string) {
+	old, ok := mgr.clients.LoadAndDelete(name)
+	if !ok {
+		return
+	}
+	if client, ok := old.(*Client); ok {
+		_ = client.Close()
+	}
 }
 
 // Load registers all configs with its name defined by ManagerConfig
@@ -100,6 +119,19 @@ func (mgr *Manager) Load(configs *ManagerConfig) {
 	}
 }
 
+// Names returns names of all registered configs
+func (mgr *Manager) Names() []string {
+	var out []string
+	mgr.configs.Range(func(key, value interface{}) bool {
+		if name, ok := key.(string); ok {
+			out = append(out, name)
+		}
+		return true
+	})
+	sort.Strings(out)
+	return out
+}
+
 func (mgr *Manager) List(configs *ManagerConfig) map[string]interface{} {
 	if configs == nil {
 		return nil
@@ -109,6 +141,7 @@ func (mgr *Manager) List(configs *ManagerConfig) map[string]interface{} {
 		out[name] = map[string]interface{}{
 			"addr": config.Addr,
 			"db":   config.DB,
+			"mode": config.Mode,
 		}
 	}
 	return out
@@ -390,3 +390,310 @@ func (c *RedisClient) Pipeline(ctx context.Context) (iface goredis.Pipeliner) {
 	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
 	return tc.Pipeline()
 }
+
+func (c *RedisClient) XLen(ctx context.Context, key string) (int64, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.XLen(key)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) XRangeN(ctx context.Context, key, start, stop string, count int64) ([]goredis.XMessage, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.XRangeN(key, start, stop, count)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) XRevRangeN(ctx context.Context, key, start, stop string, count int64) ([]goredis.XMessage, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.XRevRangeN(key, start, stop, count)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) XAdd(ctx context.Context, args *goredis.XAddArgs) (string, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.XAdd(args)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) XDel(ctx context.Context, key string, ids ...string) (int64, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.XDel(key, ids...)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) XTrim(ctx context.Context, key string, maxLen int64) (int64, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.XTrim(key, maxLen)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) XAck(ctx context.Context, key, group string, ids ...string) (int64, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.XAck(key, group, ids...)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) XClaim(ctx context.Context, args *goredis.XClaimArgs) ([]goredis.XMessage, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.XClaim(args)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) XPendingExt(ctx context.Context, args *goredis.XPendingExtArgs) ([]goredis.XPendingExt, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.XPendingExt(args)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+// XInfo runs XINFO STREAM|GROUPS|CONSUMERS, go-redis v6 has no typed command for it.
+func (c *RedisClient) XInfo(ctx context.Context, vals ...interface{}) (interface{}, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.Do(append([]interface{}{"xinfo"}, vals...)...)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) BitPos(ctx context.Context, key string, bit int64, pos ...int64) (int64, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.BitPos(key, bit, pos...)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) GetRange(ctx context.Context, key string, start, end int64) (string, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.GetRange(key, start, end)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) StrLen(ctx context.Context, key string) (int64, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.StrLen(key)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) ObjectEncoding(ctx context.Context, key string) (string, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.ObjectEncoding(key)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) PFAdd(ctx context.Context, key string, vals ...interface{}) (int64, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.PFAdd(key, vals...)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) PFCount(ctx context.Context, keys ...string) (int64, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.PFCount(keys...)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) PFMerge(ctx context.Context, dest string, keys ...string) (string, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.PFMerge(dest, keys...)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) GeoAdd(ctx context.Context, key string, vals ...*goredis.GeoLocation) (int64, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.GeoAdd(key, vals...)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) GeoPos(ctx context.Context, key string, members ...string) ([]*goredis.GeoPos, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.GeoPos(key, members...)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+// GeoSearch runs GEOSEARCH (redis >= 6.2), go-redis v6 has no typed command for it.
+func (c *RedisClient) GeoSearch(ctx context.Context, key string, vals ...interface{}) (interface{}, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.Do(append([]interface{}{"geosearch", key}, vals...)...)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) MemoryUsage(ctx context.Context, key string, samples ...int) (int64, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.MemoryUsage(key, samples...)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) Dump(ctx context.Context, key string) (string, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.Dump(key)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) PTTL(ctx context.Context, key string) (time.Duration, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.PTTL(key)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) Restore(ctx context.Context, key string, ttl time.Duration, value string) (string, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.Restore(key, ttl, value)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) RestoreReplace(ctx context.Context, key string, ttl time.Duration, value string) (string, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.RestoreReplace(key, ttl, value)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+// DoCmd runs a raw command, used by console
+func (c *RedisClient) DoCmd(ctx context.Context, vals ...interface{}) (interface{}, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.Do(vals...)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) Publish(ctx context.Context, channel string, message interface{}) (int64, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.Publish(channel, message)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) Rename(ctx context.Context, key, newkey string) (string, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.Rename(key, newkey)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) RenameNX(ctx context.Context, key, newkey string) (bool, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.RenameNX(key, newkey)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) Move(ctx context.Context, key string, db int) (bool, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.Move(key, int64(db))
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) Persist(ctx context.Context, key string) (bool, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.Persist(key)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) ObjectRefCount(ctx context.Context, key string) (int64, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.ObjectRefCount(key)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) ObjectIdleTime(ctx context.Context, key string) (time.Duration, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.ObjectIdleTime(key)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) LIndex(ctx context.Context, key string, index int64) (string, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.LIndex(key, index)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) LRem(ctx context.Context, key string, count int64, value interface{}) (int64, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.LRem(key, count, value)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) LInsert(ctx context.Context, key, op string, pivot, value interface{}) (int64, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.LInsert(key, op, pivot, value)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) LTrim(ctx context.Context, key string, start, stop int64) (string, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.LTrim(key, start, stop)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
+
+func (c *RedisClient) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	tc := c.Client.Trace(ctx, opentracing.GlobalTracer())
+	cmd := tc.Eval(script, keys, args...)
+	c.handleCmdErr(ctx, cmd)
+
+	return cmd.Result()
+}
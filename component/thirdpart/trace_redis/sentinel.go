@@ -0,0 +1,244 @@
+package trace_redis
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fighthorse/redisAdmin/component/log"
+	goredis "github.com/go-redis/redis"
+)
+
+// ErrNoSentinel is returned when none of the sentinels can resolve the master.
+var ErrNoSentinel = errors.New("redis: all sentinels are unreachable")
+
+// A SentinelReplica is a replica reported by sentinel
+type SentinelReplica struct {
+	Addr  string `json:"addr"`
+	Flags string `json:"flags"`
+}
+
+// SentinelNodes describes the nodes a sentinel config is currently resolved to
+type SentinelNodes struct {
+	MasterName string            `json:"master_name"`
+	Sentinel   string            `json:"sentinel"`
+	Master     string            `json:"master"`
+	Replicas   []SentinelReplica `json:"replicas"`
+}
+
+// newSentinelWithLogger creates a failover client which always talks to the
+// master resolved by sentinels.
+func newSentinelWithLogger(config *Config, log log.Logger) (*Client, error) {
+	var client *goredis.Client
+	var stop func()
+	if config.SentinelPasswd == "" {
+		client = goredis.NewFailoverClient(&goredis.FailoverOptions{
+			MasterName:    config.MasterName,
+			SentinelAddrs: config.SentinelAddrs,
+			Password:      config.Passwd,
+			DB:            config.DB,
+			DialTimeout:   time.Duration(config.DialTimeout) * time.Millisecond,
+			ReadTimeout:   time.Duration(config.ReadTimeout) * time.Millisecond,
+			WriteTimeout:  time.Duration(config.WriteTimeout) * time.Millisecond,
+			PoolSize:      config.PoolSize,
+			PoolTimeout:   time.Duration(config.PoolTimeout) * time.Second,
+			MinIdleConns:  config.MinIdleConns,
+			MaxRetries:    config.MaxRetries,
+		})
+	} else {
+		// go-redis failover client can not auth to sentinels,
+		// so resolve the master by ourselves for every new connection,
+		// and close pooled connections to the old master on +switch-master.
+		conns := &sentinelConns{conns: map[*sentinelConn]struct{}{}}
+		ctx, cancel := context.WithCancel(context.Background())
+		stop = cancel
+		go conns.watch(ctx, config, log)
+		client = goredis.NewClient(&goredis.Options{
+			Addr: config.Addr,
+			Dialer: func() (net.Conn, error) {
+				addr, _, err := sentinelMasterAddr(config)
+				if err != nil {
+					return nil, err
+				}
+				cn, err := net.DialTimeout("tcp", addr, time.Duration(config.DialTimeout)*time.Millisecond)
+				if err != nil {
+					return nil, err
+				}
+				return conns.add(cn, addr), nil
+			},
+			Password:     config.Passwd,
+			DB:           config.DB,
+			DialTimeout:  time.Duration(config.DialTimeout) * time.Millisecond,
+			ReadTimeout:  time.Duration(config.ReadTimeout) * time.Millisecond,
+			WriteTimeout: time.Duration(config.WriteTimeout) * time.Millisecond,
+			PoolSize:     config.PoolSize,
+			PoolTimeout:  time.Duration(config.PoolTimeout) * time.Second,
+			MinIdleConns: config.MinIdleConns,
+			MaxRetries:   config.MaxRetries,
+		})
+	}
+
+	return &Client{
+		Client: client,
+		config: config,
+		log:    log,
+		stop:   stop,
+	}, nil
+}
+
+// sentinelConns tracks connections dialed to the resolved master
+type sentinelConns struct {
+	mux   sync.Mutex
+	conns map[*sentinelConn]struct{}
+}
+
+type sentinelConn struct {
+	net.Conn
+	addr  string
+	owner *sentinelConns
+}
+
+func (c *sentinelConn) Close() error {
+	c.owner.mux.Lock()
+	delete(c.owner.conns, c)
+	c.owner.mux.Unlock()
+	return c.Conn.Close()
+}
+
+func (s *sentinelConns) add(cn net.Conn, addr string) net.Conn {
+	c := &sentinelConn{Conn: cn, addr: addr, owner: s}
+	s.mux.Lock()
+	s.conns[c] = struct{}{}
+	s.mux.Unlock()
+	return c
+}
+
+// closeExcept closes connections not to addr, the pool drops them as bad
+// connections and retries on a new connection to the new master.
+func (s *sentinelConns) closeExcept(addr string) {
+	s.mux.Lock()
+	var stale []*sentinelConn
+	for c := range s.conns {
+		if c.addr != addr {
+			stale = append(stale, c)
+		}
+	}
+	s.mux.Unlock()
+	for _, c := range stale {
+		_ = c.Close()
+	}
+}
+
+// watch subscribes +switch-master on sentinels one by one until ctx is done.
+func (s *sentinelConns) watch(ctx context.Context, config *Config, log log.Logger) {
+	for i := 0; ; i++ {
+		if len(config.SentinelAddrs) == 0 {
+			return
+		}
+		sentinel := newSentinelClient(config, config.SentinelAddrs[i%len(config.SentinelAddrs)])
+		pubsub := sentinel.Subscribe("+switch-master")
+		if _, err := pubsub.Receive(); err == nil {
+			ch := pubsub.Channel()
+		loop:
+			for {
+				select {
+				case <-ctx.Done():
+					break loop
+				case msg, ok := <-ch:
+					if !ok {
+						break loop
+					}
+					// <master name> <old ip> <old port> <new ip> <new port>
+					fields := strings.Fields(msg.Payload)
+					if len(fields) == 5 && fields[0] == config.MasterName {
+						log.Warnf("sentinel: %s switched master to %s:%s", config.MasterName, fields[3], fields[4])
+						s.closeExcept(net.JoinHostPort(fields[3], fields[4]))
+					}
+				}
+			}
+		}
+		_ = pubsub.Close()
+		_ = sentinel.Close()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func newSentinelClient(config *Config, addr string) *goredis.SentinelClient {
+	return goredis.NewSentinelClient(&goredis.Options{
+		Addr:         addr,
+		Password:     config.SentinelPasswd,
+		DialTimeout:  time.Duration(config.DialTimeout) * time.Millisecond,
+		ReadTimeout:  time.Duration(config.ReadTimeout) * time.Millisecond,
+		WriteTimeout: time.Duration(config.WriteTimeout) * time.Millisecond,
+		MaxRetries:   config.MaxRetries,
+		PoolSize:     1,
+	})
+}
+
+// sentinelMasterAddr asks sentinels one by one for the master addr,
+// returns the addr and the sentinel which answered.
+func sentinelMasterAddr(config *Config) (string, string, error) {
+	for _, sentinelAddr := range config.SentinelAddrs {
+		sentinel := newSentinelClient(config, sentinelAddr)
+		addr, err := sentinel.GetMasterAddrByName(config.MasterName).Result()
+		_ = sentinel.Close()
+		if err != nil || len(addr) != 2 {
+			continue
+		}
+		return net.JoinHostPort(addr[0], addr[1]), sentinelAddr, nil
+	}
+	return "", "", ErrNoSentinel
+}
+
+// Sentinel returns the master and replicas currently resolved by sentinels,
+// it returns nil for other modes.
+func (c *Client) Sentinel() (*SentinelNodes, error) {
+	if c.config.Mode != ModeSentinel {
+		return nil, nil
+	}
+
+	master, sentinelAddr, err := sentinelMasterAddr(c.config)
+	if err != nil {
+		return nil, err
+	}
+	out := &SentinelNodes{
+		MasterName: c.config.MasterName,
+		Sentinel:   sentinelAddr,
+		Master:     master,
+	}
+
+	sentinel := newSentinelClient(c.config, sentinelAddr)
+	defer sentinel.Close()
+
+	cmd := goredis.NewSliceCmd("sentinel", "slaves", c.config.MasterName)
+	_ = sentinel.Process(cmd)
+	replicas, err := cmd.Result()
+	if err != nil {
+		return out, nil
+	}
+	for _, v := range replicas {
+		fields, ok := v.([]interface{})
+		if !ok {
+			continue
+		}
+		kv := make(map[string]string, len(fields)/2)
+		for i := 0; i+1 < len(fields); i += 2 {
+			k, _ := fields[i].(string)
+			val, _ := fields[i+1].(string)
+			kv[k] = val
+		}
+		out.Replicas = append(out.Replicas, SentinelReplica{
+			Addr:  net.JoinHostPort(kv["ip"], kv["port"]),
+			Flags: kv["flags"],
+		})
+	}
+	return out, nil
+}
@@ -68,11 +68,12 @@ func submitEndpoint(c *gin.Context) {
 		Ip:      ip.String(),
 		Token:   token,
 		Expires: day,
+		Role:    login.UserRole(person.Name),
 	}
 	gocache.Set(person.Name, data, 24*time.Hour)
 	log.Info(c.Request.Context(), "submitEndpoint", log.Fields{"person": person, "data": data})
 	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": map[string]interface{}{
-		"token": token, "exp": day,
+		"token": token, "exp": day, "role": data.Role,
 	}})
 }
 
@@ -18,8 +18,58 @@ func RegisterHttp(r *gin.Engine) {
 		redis.POST("/searchKey", SearchKey)
 		redis.POST("/searchNowKey", SearchNowKey)
 		redis.GET("/info", Info)
+		redis.GET("/info/detail", InfoDetail)
+		redis.POST("/info/detail", InfoDetail)
+		redis.GET("/info/history", InfoHistory)
+		redis.POST("/info/history", InfoHistory)
 		redis.POST("/handle", Handle)
-		redis.POST("/addCfg", AddCfg)
+		redis.POST("/addCfg", middleware.AdminRequired, AddCfg)
+		redis.POST("/updateCfg", middleware.AdminRequired, UpdateCfg)
+		redis.POST("/delCfg", middleware.AdminRequired, DelCfg)
+		redis.POST("/testCfg", middleware.AdminRequired, TestCfg)
+		redis.GET("/audit", middleware.AdminRequired, Audit)
+		redis.POST("/audit", middleware.AdminRequired, Audit)
+		redis.POST("/trash", Trash)
+		redis.POST("/trash/restore", TrashRestore)
+		redis.POST("/jobs", CreateJob)
+		redis.GET("/jobs/status", JobStatus)
+		redis.POST("/jobs/status", JobStatus)
+		redis.POST("/jobs/cancel", CancelJob)
+		redis.GET("/export", Export)
+		redis.POST("/export", Export)
+		redis.POST("/import", Import)
+		redis.POST("/rdb/analyze", middleware.AdminRequired, AnalyzeRdb)
+		redis.POST("/bigkeys", ScanBigKey)
+		redis.GET("/bigkeys/result", BigKeyResult)
+		redis.POST("/bigkeys/result", BigKeyResult)
+		redis.POST("/bigkeys/cancel", CancelBigKey)
+		redis.GET("/slowlog", Slowlog)
+		redis.POST("/slowlog", Slowlog)
+		redis.POST("/slowlog/reset", middleware.AdminRequired, SlowlogReset)
+		redis.GET("/clients", ClientList)
+		redis.POST("/clients", ClientList)
+		redis.POST("/clients/kill", ClientKill)
+		redis.POST("/console", Console)
+		redis.GET("/monitor", Monitor)
+		redis.GET("/keyspace", Keyspace)
+		redis.GET("/pubsub", PubsubChannels)
+		redis.POST("/pubsub", PubsubChannels)
+		redis.POST("/pubsub/publish", Publish)
+		redis.GET("/pubsub/subscribe", Subscribe)
+		redis.GET("/config", ConfigList)
+		redis.POST("/config", ConfigList)
+		redis.POST("/config/set", middleware.AdminRequired, ConfigSet)
+		redis.POST("/config/rewrite", middleware.AdminRequired, ConfigRewrite)
+		redis.GET("/config/baselines", Baselines)
+		redis.POST("/config/baseline", middleware.AdminRequired, SaveBaseline)
+		redis.POST("/config/baseline/del", middleware.AdminRequired, DelBaseline)
+		redis.POST("/diff", CreateDiff)
+		redis.GET("/diff/report", DiffReport)
+		redis.POST("/diff/report", DiffReport)
+		redis.POST("/diff/sync", DiffSync)
+		redis.POST("/migrate", CreateMigrate)
+		redis.POST("/migrate/resume", ResumeMigrate)
+		redis.GET("/migrate/checkpoints", ListMigrate)
 		redis.POST("/getKey", GetKey)
 	}
 
@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	"github.com/fighthorse/redisAdmin/component/self_errors"
+	"github.com/fighthorse/redisAdmin/internal/service/monitor"
 	"github.com/fighthorse/redisAdmin/internal/service/work"
 	"github.com/fighthorse/redisAdmin/protos"
 	"github.com/gin-gonic/gin"
@@ -44,7 +45,7 @@ func SearchKey(c *gin.Context) {
 
 	data, err := work.HandleKey(c, search)
 	if err != nil {
-		c.JSON(200, gin.H{"code": -1, "message": "" + err.Error(), "data": map[string]interface{}{}})
+		c.JSON(200, self_errors.ErrExport(err))
 		return
 	}
 	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
@@ -86,7 +87,7 @@ func GetKey(c *gin.Context) {
 }
 
 func Info(c *gin.Context) {
-	data, err := work.ListRedisCfg(c)
+	data, err := work.RedisInfo(c)
 	if err != nil {
 		c.JSON(200, gin.H{"code": -1, "message": "" + err.Error(), "data": map[string]interface{}{}})
 		return
@@ -102,8 +103,8 @@ func AddCfg(c *gin.Context) {
 		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
 		return
 	}
-	if db.Name == "" || db.Addr == "" {
-		err := errors.New("用户名称/密码不能为空")
+	if db.Name == "" {
+		err := errors.New("名称不能为空")
 		c.JSON(200, self_errors.JsonErrExport(self_errors.ParamsErr, err, ""))
 		return
 	}
@@ -116,6 +117,608 @@ func AddCfg(c *gin.Context) {
 	return
 }
 
+func UpdateCfg(c *gin.Context) {
+	var db protos.AddCfgReq
+	if err := c.ShouldBind(&db); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	if db.Name == "" {
+		err := errors.New("名称不能为空")
+		c.JSON(200, self_errors.JsonErrExport(self_errors.ParamsErr, err, ""))
+		return
+	}
+	data, err := work.UpdateRedisCfg(c, db)
+	if err != nil {
+		c.JSON(200, gin.H{"code": -1, "message": "" + err.Error(), "data": map[string]interface{}{}})
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
+	return
+}
+
+func DelCfg(c *gin.Context) {
+	var db protos.AddCfgReq
+	if err := c.ShouldBind(&db); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	if db.Name == "" {
+		err := errors.New("名称不能为空")
+		c.JSON(200, self_errors.JsonErrExport(self_errors.ParamsErr, err, ""))
+		return
+	}
+	data, err := work.DelRedisCfg(c, db)
+	if err != nil {
+		c.JSON(200, gin.H{"code": -1, "message": "" + err.Error(), "data": map[string]interface{}{}})
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
+	return
+}
+
+func TestCfg(c *gin.Context) {
+	var db protos.AddCfgReq
+	if err := c.ShouldBind(&db); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	data, err := work.TestRedisCfg(c, db)
+	if err != nil {
+		c.JSON(200, gin.H{"code": -1, "message": "" + err.Error(), "data": map[string]interface{}{}})
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
+	return
+}
+
+// Audit 查询审计日志
+func Audit(c *gin.Context) {
+	var search protos.AuditReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	data, err := work.QueryAudit(c, search)
+	if err != nil {
+		c.JSON(200, gin.H{"code": -1, "message": "" + err.Error(), "data": map[string]interface{}{}})
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
+	return
+}
+
+// Trash 删除/覆盖前的快照列表
+func Trash(c *gin.Context) {
+	var search protos.TrashReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	data, err := work.ListTrash(c, search)
+	if err != nil {
+		c.JSON(200, gin.H{"code": -1, "message": "" + err.Error(), "data": map[string]interface{}{}})
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
+	return
+}
+
+// TrashRestore 恢复快照
+func TrashRestore(c *gin.Context) {
+	var search protos.TrashReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	data, err := work.RestoreTrash(c, search)
+	if err != nil {
+		c.JSON(200, self_errors.ErrExport(err))
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
+	return
+}
+
+// CreateJob 创建批量删除/过期任务
+func CreateJob(c *gin.Context) {
+	var search protos.JobReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	data, err := work.CreateJob(c, search)
+	if err != nil {
+		c.JSON(200, self_errors.ErrExport(err))
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
+	return
+}
+
+// JobStatus 任务进度
+func JobStatus(c *gin.Context) {
+	var search protos.JobReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	data, err := work.JobStatus(c, search)
+	if err != nil {
+		c.JSON(200, self_errors.ErrExport(err))
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
+	return
+}
+
+// CancelJob 取消任务
+func CancelJob(c *gin.Context) {
+	var search protos.JobReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	data, err := work.CancelJob(c, search)
+	if err != nil {
+		c.JSON(200, self_errors.ErrExport(err))
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
+	return
+}
+
+// Export 导出 key, 以附件形式返回
+func Export(c *gin.Context) {
+	var search protos.ExportReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	fileName, data, err := work.ExportKeys(c, search)
+	if err != nil {
+		c.JSON(200, self_errors.ErrExport(err))
+		return
+	}
+	c.Header("Content-Disposition", "attachment; filename="+fileName)
+	c.Data(200, "application/octet-stream", data)
+	return
+}
+
+// Import 导入 key, 支持上传 file 或 data 字段
+func Import(c *gin.Context) {
+	var search protos.ImportReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	data, err := work.ImportKeys(c, search)
+	if err != nil {
+		c.JSON(200, self_errors.ErrExport(err))
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
+	return
+}
+
+// AnalyzeRdb 上传 rdb 文件离线分析内存
+func AnalyzeRdb(c *gin.Context) {
+	var search protos.RdbReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	data, err := work.AnalyzeRdb(c, search)
+	if err != nil {
+		c.JSON(200, self_errors.ErrExport(err))
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
+	return
+}
+
+// ScanBigKey 扫描大key/热key
+func ScanBigKey(c *gin.Context) {
+	var search protos.BigKeyReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	data, err := work.ScanBigKey(c, search)
+	if err != nil {
+		c.JSON(200, self_errors.ErrExport(err))
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
+	return
+}
+
+// BigKeyResult 扫描进度及结果
+func BigKeyResult(c *gin.Context) {
+	var search protos.BigKeyReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	data, err := work.BigKeyResult(c, search)
+	if err != nil {
+		c.JSON(200, self_errors.ErrExport(err))
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
+	return
+}
+
+// CancelBigKey 取消扫描
+func CancelBigKey(c *gin.Context) {
+	var search protos.BigKeyReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	data, err := work.CancelBigKey(c, search)
+	if err != nil {
+		c.JSON(200, self_errors.ErrExport(err))
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
+	return
+}
+
+// InfoDetail INFO 最近一次采样
+func InfoDetail(c *gin.Context) {
+	var search protos.InfoReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	data, err := monitor.Latest(search)
+	if err != nil {
+		c.JSON(200, gin.H{"code": -1, "message": "" + err.Error(), "data": map[string]interface{}{}})
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
+	return
+}
+
+// InfoHistory INFO 历史数据
+func InfoHistory(c *gin.Context) {
+	var search protos.InfoReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	data, err := monitor.History(search)
+	if err != nil {
+		c.JSON(200, gin.H{"code": -1, "message": "" + err.Error(), "data": map[string]interface{}{}})
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
+	return
+}
+
+// Slowlog 慢日志及按命令模板聚合
+func Slowlog(c *gin.Context) {
+	var search protos.SlowlogReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	data, err := work.Slowlog(c, search)
+	if err != nil {
+		c.JSON(200, self_errors.ErrExport(err))
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
+	return
+}
+
+// SlowlogReset 清空慢日志
+func SlowlogReset(c *gin.Context) {
+	var search protos.SlowlogReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	data, err := work.SlowlogReset(c, search)
+	if err != nil {
+		c.JSON(200, self_errors.ErrExport(err))
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
+	return
+}
+
+// ClientList 连接列表
+func ClientList(c *gin.Context) {
+	var search protos.ClientListReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	data, err := work.ClientList(c, search)
+	if err != nil {
+		c.JSON(200, self_errors.ErrExport(err))
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
+	return
+}
+
+// ClientKill 断开连接, 仅 admin
+func ClientKill(c *gin.Context) {
+	var search protos.ClientKillReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	data, err := work.ClientKill(c, search)
+	if err != nil {
+		c.JSON(200, self_errors.ErrExport(err))
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
+	return
+}
+
+// Console 执行 console 命令
+func Console(c *gin.Context) {
+	var search protos.ConsoleReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	data, err := work.Console(c, search)
+	if err != nil {
+		c.JSON(200, self_errors.ErrExport(err))
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
+	return
+}
+
+// Monitor websocket 推送 MONITOR 输出, 仅 admin
+func Monitor(c *gin.Context) {
+	var search protos.MonitorReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	if err := work.MonitorStream(c, search); err != nil && !c.Writer.Written() {
+		c.JSON(200, self_errors.ErrExport(err))
+	}
+	return
+}
+
+// Keyspace SSE 推送 key 变化事件
+func Keyspace(c *gin.Context) {
+	var search protos.KeyspaceReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	if err := work.KeyspaceStream(c, search); err != nil {
+		c.JSON(200, self_errors.ErrExport(err))
+	}
+	return
+}
+
+// PubsubChannels 频道及订阅数
+func PubsubChannels(c *gin.Context) {
+	var search protos.PubsubReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	data, err := work.PubsubChannels(c, search)
+	if err != nil {
+		c.JSON(200, self_errors.ErrExport(err))
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
+	return
+}
+
+// Publish 发布消息
+func Publish(c *gin.Context) {
+	var search protos.PublishReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	data, err := work.Publish(c, search)
+	if err != nil {
+		c.JSON(200, self_errors.ErrExport(err))
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
+	return
+}
+
+// Subscribe websocket 推送订阅消息
+func Subscribe(c *gin.Context) {
+	var search protos.SubscribeReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	if err := work.PubsubStream(c, search); err != nil && !c.Writer.Written() {
+		c.JSON(200, self_errors.ErrExport(err))
+	}
+	return
+}
+
+// ConfigList 分组配置及对比
+func ConfigList(c *gin.Context) {
+	var search protos.ConfigReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	data, err := work.ConfigList(c, search)
+	if err != nil {
+		c.JSON(200, self_errors.ErrExport(err))
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
+	return
+}
+
+// ConfigSet 修改配置, 仅 admin
+func ConfigSet(c *gin.Context) {
+	var search protos.ConfigSetReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	data, err := work.ConfigSet(c, search)
+	if err != nil {
+		c.JSON(200, self_errors.ErrExport(err))
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
+	return
+}
+
+// ConfigRewrite 写回配置文件, 仅 admin
+func ConfigRewrite(c *gin.Context) {
+	var search protos.ConfigReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	data, err := work.ConfigRewrite(c, search)
+	if err != nil {
+		c.JSON(200, self_errors.ErrExport(err))
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
+	return
+}
+
+// Baselines 基线列表
+func Baselines(c *gin.Context) {
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": work.ListBaseline(c)})
+	return
+}
+
+// SaveBaseline 保存当前配置为基线, 仅 admin
+func SaveBaseline(c *gin.Context) {
+	var search protos.BaselineReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	data, err := work.SaveBaseline(c, search)
+	if err != nil {
+		c.JSON(200, self_errors.ErrExport(err))
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
+	return
+}
+
+// DelBaseline 删除基线, 仅 admin
+func DelBaseline(c *gin.Context) {
+	var search protos.BaselineReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	if err := work.DelBaseline(c, search); err != nil {
+		c.JSON(200, self_errors.ErrExport(err))
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": map[string]interface{}{}})
+	return
+}
+
+// CreateDiff 创建跨实例对比任务
+func CreateDiff(c *gin.Context) {
+	var search protos.DiffReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	data, err := work.CreateDiff(c, search)
+	if err != nil {
+		c.JSON(200, self_errors.ErrExport(err))
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
+	return
+}
+
+// DiffReport 对比结果分页
+func DiffReport(c *gin.Context) {
+	var search protos.DiffReportReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	data, err := work.DiffReport(c, search)
+	if err != nil {
+		c.JSON(200, self_errors.ErrExport(err))
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
+	return
+}
+
+// DiffSync 同步选中的 key 到 target
+func DiffSync(c *gin.Context) {
+	var search protos.DiffSyncReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	data, err := work.DiffSync(c, search)
+	if err != nil {
+		c.JSON(200, self_errors.ErrExport(err))
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
+	return
+}
+
+// CreateMigrate 创建迁移任务
+func CreateMigrate(c *gin.Context) {
+	var search protos.MigrateReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	data, err := work.CreateMigrate(c, search)
+	if err != nil {
+		c.JSON(200, self_errors.ErrExport(err))
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
+	return
+}
+
+// ResumeMigrate 从断点恢复迁移
+func ResumeMigrate(c *gin.Context) {
+	var search protos.MigrateResumeReq
+	if err := c.ShouldBind(&search); err != nil {
+		c.JSON(200, self_errors.JsonErrExport(self_errors.JsonErr, err, ""))
+		return
+	}
+	data, err := work.ResumeMigrate(c, search)
+	if err != nil {
+		c.JSON(200, self_errors.ErrExport(err))
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
+	return
+}
+
+// ListMigrate 迁移断点列表
+func ListMigrate(c *gin.Context) {
+	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": work.ListMigrate(c)})
+	return
+}
+
 // 根据类型处理
 func Handle(c *gin.Context) {
 	//AddCfgReq
@@ -127,7 +730,7 @@ func Handle(c *gin.Context) {
 
 	data, err := work.HandleKey(c, search)
 	if err != nil {
-		c.JSON(200, gin.H{"code": -1, "message": "" + err.Error(), "data": map[string]interface{}{}})
+		c.JSON(200, self_errors.ErrExport(err))
 		return
 	}
 	c.JSON(200, gin.H{"code": 0, "message": "ok", "data": data})
@@ -0,0 +1,221 @@
+package rdb
+
+import (
+	"encoding/binary"
+)
+
+// lzfDecompress 解压 LZF 压缩的字符串, outLen 来自文件内容, 输出超过 outLen 时视为损坏
+func lzfDecompress(in []byte, outLen int) ([]byte, error) {
+	if outLen < 0 || outLen > MaxStringSize {
+		return nil, ErrCorrupted
+	}
+	out := make([]byte, 0, outLen)
+	for i := 0; i < len(in); {
+		ctrl := int(in[i])
+		i++
+		if ctrl < 32 {
+			n := ctrl + 1
+			if i+n > len(in) || len(out)+n > outLen {
+				return nil, ErrCorrupted
+			}
+			out = append(out, in[i:i+n]...)
+			i += n
+			continue
+		}
+		length := ctrl >> 5
+		if length == 7 {
+			if i >= len(in) {
+				return nil, ErrCorrupted
+			}
+			length += int(in[i])
+			i++
+		}
+		if i >= len(in) {
+			return nil, ErrCorrupted
+		}
+		ref := len(out) - (ctrl&0x1f)<<8 - int(in[i]) - 1
+		i++
+		if ref < 0 || len(out)+length+2 > outLen {
+			return nil, ErrCorrupted
+		}
+		for k := 0; k < length+2; k++ {
+			out = append(out, out[ref+k])
+		}
+	}
+	if len(out) != outLen {
+		return nil, ErrCorrupted
+	}
+	return out, nil
+}
+
+// ziplistLen 头部 zllen 为 65535 时需要遍历
+func ziplistLen(b []byte) (int64, error) {
+	if len(b) < 11 {
+		return 0, ErrCorrupted
+	}
+	if n := binary.LittleEndian.Uint16(b[8:10]); n < 0xFFFF {
+		return int64(n), nil
+	}
+	var n int64
+	for i := 10; ; n++ {
+		if i >= len(b) {
+			return 0, ErrCorrupted
+		}
+		if b[i] == 0xFF {
+			return n, nil
+		}
+		// prevlen
+		if b[i] < 254 {
+			i++
+		} else {
+			i += 5
+		}
+		if i >= len(b) {
+			return 0, ErrCorrupted
+		}
+		enc := b[i]
+		switch enc >> 6 {
+		case 0:
+			i += 1 + int(enc&0x3f)
+		case 1:
+			if i+1 >= len(b) {
+				return 0, ErrCorrupted
+			}
+			i += 2 + (int(enc&0x3f)<<8 | int(b[i+1]))
+		case 2:
+			if i+4 >= len(b) {
+				return 0, ErrCorrupted
+			}
+			i += 5 + int(binary.BigEndian.Uint32(b[i+1:i+5]))
+		default:
+			switch {
+			case enc == 0xC0:
+				i += 3
+			case enc == 0xD0:
+				i += 5
+			case enc == 0xE0:
+				i += 9
+			case enc == 0xF0:
+				i += 4
+			case enc == 0xFE:
+				i += 2
+			case enc >= 0xF1 && enc <= 0xFD:
+				i++
+			default:
+				return 0, ErrCorrupted
+			}
+		}
+	}
+}
+
+// listpackLen 头部元素个数为 65535 时需要遍历
+func listpackLen(b []byte) (int64, error) {
+	if len(b) < 7 {
+		return 0, ErrCorrupted
+	}
+	if n := binary.LittleEndian.Uint16(b[4:6]); n < 0xFFFF {
+		return int64(n), nil
+	}
+	var n int64
+	for i := 6; ; n++ {
+		if i >= len(b) {
+			return 0, ErrCorrupted
+		}
+		enc := b[i]
+		var size int
+		switch {
+		case enc == 0xFF:
+			return n, nil
+		case enc&0x80 == 0:
+			size = 1
+		case enc&0xC0 == 0x80:
+			size = 1 + int(enc&0x3f)
+		case enc&0xE0 == 0xC0:
+			size = 2
+		case enc&0xF0 == 0xE0:
+			if i+1 >= len(b) {
+				return 0, ErrCorrupted
+			}
+			size = 2 + (int(enc&0x0f)<<8 | int(b[i+1]))
+		case enc == 0xF0:
+			if i+4 >= len(b) {
+				return 0, ErrCorrupted
+			}
+			size = 5 + int(binary.LittleEndian.Uint32(b[i+1:i+5]))
+		case enc == 0xF1:
+			size = 3
+		case enc == 0xF2:
+			size = 4
+		case enc == 0xF3:
+			size = 5
+		case enc == 0xF4:
+			size = 9
+		default:
+			return 0, ErrCorrupted
+		}
+		i += size + backlenSize(size)
+	}
+}
+
+func backlenSize(l int) int {
+	switch {
+	case l < 128:
+		return 1
+	case l < 16384:
+		return 2
+	case l < 2097152:
+		return 3
+	case l < 268435456:
+		return 4
+	}
+	return 5
+}
+
+func intsetLen(b []byte) (int64, error) {
+	if len(b) < 8 {
+		return 0, ErrCorrupted
+	}
+	return int64(binary.LittleEndian.Uint32(b[4:8])), nil
+}
+
+// zipmapLen 返回 field 个数, 头部长度为 254 时需要遍历
+func zipmapLen(b []byte) (int64, error) {
+	if len(b) < 1 {
+		return 0, ErrCorrupted
+	}
+	if b[0] < 254 {
+		return int64(b[0]), nil
+	}
+	var n int64
+	i := 1
+	readLen := func() (int, bool) {
+		if i >= len(b) || b[i] == 255 {
+			return 0, false
+		}
+		if b[i] < 254 {
+			l := int(b[i])
+			i++
+			return l, true
+		}
+		if i+4 >= len(b) {
+			return 0, false
+		}
+		l := int(binary.LittleEndian.Uint32(b[i+1 : i+5]))
+		i += 5
+		return l, true
+	}
+	for {
+		kl, ok := readLen()
+		if !ok {
+			return n, nil
+		}
+		i += kl
+		vl, ok := readLen()
+		if !ok || i >= len(b) {
+			return 0, ErrCorrupted
+		}
+		free := int(b[i])
+		i += 1 + vl + free
+		n++
+	}
+}
@@ -0,0 +1,673 @@
+package rdb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+)
+
+// 解析 RDB 文件, 只统计每个 key 的类型/编码/元素数/序列化大小, 不保存 value
+
+// 支持的最高版本
+const MaxVersion = 11
+
+const (
+	opFunctionPreGA = 0xF6
+	opSlotInfo      = 0xF4
+	opFunction2     = 0xF5
+	opModuleAux     = 0xF7
+	opIdle          = 0xF8
+	opFreq          = 0xF9
+	opAux           = 0xFA
+	opResizeDB      = 0xFB
+	opExpireMs      = 0xFC
+	opExpire        = 0xFD
+	opSelectDB      = 0xFE
+	opEOF           = 0xFF
+)
+
+const (
+	typeString           = 0
+	typeList             = 1
+	typeSet              = 2
+	typeZset             = 3
+	typeHash             = 4
+	typeZset2            = 5
+	typeModulePreGA      = 6
+	typeModule2          = 7
+	typeHashZipmap       = 9
+	typeListZiplist      = 10
+	typeSetIntset        = 11
+	typeZsetZiplist      = 12
+	typeHashZiplist      = 13
+	typeListQuicklist    = 14
+	typeStreamListpacks  = 15
+	typeHashListpack     = 16
+	typeZsetListpack     = 17
+	typeListQuicklist2   = 18
+	typeStreamListpacks2 = 19
+	typeSetListpack      = 20
+	typeStreamListpacks3 = 21
+)
+
+// module 序列化的 opcode
+const (
+	moduleOpEOF    = 0
+	moduleOpSint   = 1
+	moduleOpUint   = 2
+	moduleOpFloat  = 3
+	moduleOpDouble = 4
+	moduleOpString = 5
+)
+
+var (
+	ErrInvalidHeader = errors.New("rdb: invalid header")
+	ErrUnsupported   = errors.New("rdb: unsupported data")
+	ErrCorrupted     = errors.New("rdb: corrupted data")
+)
+
+// MaxStringSize 单个字符串(含解压后)的最大长度, 防止损坏或构造的文件申请过大内存
+var MaxStringSize = 512 << 20
+
+var typeNames = map[byte][2]string{
+	typeString:           {"string", "string"},
+	typeList:             {"list", "linkedlist"},
+	typeSet:              {"set", "hashtable"},
+	typeZset:             {"zset", "skiplist"},
+	typeHash:             {"hash", "hashtable"},
+	typeZset2:            {"zset", "skiplist"},
+	typeModule2:          {"module", "module"},
+	typeHashZipmap:       {"hash", "zipmap"},
+	typeListZiplist:      {"list", "ziplist"},
+	typeSetIntset:        {"set", "intset"},
+	typeZsetZiplist:      {"zset", "ziplist"},
+	typeHashZiplist:      {"hash", "ziplist"},
+	typeListQuicklist:    {"list", "quicklist"},
+	typeStreamListpacks:  {"stream", "listpacks"},
+	typeHashListpack:     {"hash", "listpack"},
+	typeZsetListpack:     {"zset", "listpack"},
+	typeListQuicklist2:   {"list", "quicklist"},
+	typeStreamListpacks2: {"stream", "listpacks"},
+	typeSetListpack:      {"set", "listpack"},
+	typeStreamListpacks3: {"stream", "listpacks"},
+}
+
+// An Entry is a key read from rdb file
+type Entry struct {
+	Db       int
+	Key      string
+	Type     string // string, list, set, zset, hash, stream, module
+	Encoding string
+	Size     int64 // value 序列化后的字节数
+	Elements int64
+	Expire   int64 // 过期时间 unix 毫秒, 0 不过期
+}
+
+type parser struct {
+	r       *bufio.Reader
+	offset  int64
+	size    int64 // 输入总长度, 0 未知
+	version int
+}
+
+// Parse reads rdb from r and calls fn for every key, stops when fn returns an error.
+// size is the length of r used to reject oversized lengths, 0 if unknown.
+func Parse(r io.Reader, size int64, fn func(e *Entry) error) error {
+	p := &parser{r: bufio.NewReaderSize(r, 64*1024), size: size}
+	return p.parse(fn)
+}
+
+func (p *parser) parse(fn func(e *Entry) error) error {
+	header, err := p.read(9)
+	if err != nil || string(header[:5]) != "REDIS" {
+		return ErrInvalidHeader
+	}
+	p.version, err = strconv.Atoi(string(header[5:]))
+	if err != nil || p.version < 1 {
+		return ErrInvalidHeader
+	}
+	if p.version > MaxVersion {
+		return fmt.Errorf("%w: version %d", ErrUnsupported, p.version)
+	}
+
+	db := 0
+	var expire int64
+	for {
+		op, err := p.readByte()
+		if err != nil {
+			return err
+		}
+		switch op {
+		case opEOF:
+			return nil
+		case opSelectDB:
+			n, err := p.readLen()
+			if err != nil {
+				return err
+			}
+			db = int(n)
+		case opResizeDB:
+			if _, err := p.readLen(); err != nil {
+				return err
+			}
+			if _, err := p.readLen(); err != nil {
+				return err
+			}
+		case opSlotInfo:
+			for i := 0; i < 3; i++ {
+				if _, err := p.readLen(); err != nil {
+					return err
+				}
+			}
+		case opAux:
+			if err := p.skipString(); err != nil {
+				return err
+			}
+			if err := p.skipString(); err != nil {
+				return err
+			}
+		case opFunction2:
+			if err := p.skipString(); err != nil {
+				return err
+			}
+		case opFunctionPreGA:
+			return fmt.Errorf("%w: pre-GA function", ErrUnsupported)
+		case opModuleAux:
+			if err := p.skipModuleAux(); err != nil {
+				return err
+			}
+		case opIdle:
+			if _, err := p.readLen(); err != nil {
+				return err
+			}
+		case opFreq:
+			if _, err := p.readByte(); err != nil {
+				return err
+			}
+		case opExpireMs:
+			b, err := p.read(8)
+			if err != nil {
+				return err
+			}
+			expire = int64(binary.LittleEndian.Uint64(b))
+		case opExpire:
+			b, err := p.read(4)
+			if err != nil {
+				return err
+			}
+			expire = int64(binary.LittleEndian.Uint32(b)) * 1000
+		default:
+			names, ok := typeNames[op]
+			if !ok {
+				return fmt.Errorf("%w: type %d", ErrUnsupported, op)
+			}
+			key, err := p.readString()
+			if err != nil {
+				return err
+			}
+			start := p.offset
+			n, err := p.skipValue(op)
+			if err != nil {
+				return fmt.Errorf("key %q: %w", key, err)
+			}
+			e := &Entry{
+				Db:       db,
+				Key:      string(key),
+				Type:     names[0],
+				Encoding: names[1],
+				Size:     p.offset - start,
+				Elements: n,
+				Expire:   expire,
+			}
+			expire = 0
+			if err := fn(e); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// skipValue 跳过 value, 返回元素个数
+func (p *parser) skipValue(t byte) (int64, error) {
+	switch t {
+	case typeString:
+		return 1, p.skipString()
+	case typeList, typeSet:
+		return p.skipStrings(1)
+	case typeHash:
+		return p.skipStrings(2)
+	case typeZset:
+		n, err := p.readLen()
+		if err != nil {
+			return 0, err
+		}
+		for i := uint64(0); i < n; i++ {
+			if err := p.skipString(); err != nil {
+				return 0, err
+			}
+			l, err := p.readByte()
+			if err != nil {
+				return 0, err
+			}
+			// 253 nan, 254 +inf, 255 -inf
+			if l < 253 {
+				if err := p.skip(int64(l)); err != nil {
+					return 0, err
+				}
+			}
+		}
+		return int64(n), nil
+	case typeZset2:
+		n, err := p.readLen()
+		if err != nil {
+			return 0, err
+		}
+		for i := uint64(0); i < n; i++ {
+			if err := p.skipString(); err != nil {
+				return 0, err
+			}
+			if err := p.skip(8); err != nil {
+				return 0, err
+			}
+		}
+		return int64(n), nil
+	case typeModulePreGA:
+		return 0, fmt.Errorf("%w: pre-GA module", ErrUnsupported)
+	case typeModule2:
+		if _, err := p.readLen(); err != nil {
+			return 0, err
+		}
+		return 1, p.skipModuleValue()
+	case typeHashZipmap:
+		b, err := p.readString()
+		if err != nil {
+			return 0, err
+		}
+		return zipmapLen(b)
+	case typeListZiplist:
+		b, err := p.readString()
+		if err != nil {
+			return 0, err
+		}
+		return ziplistLen(b)
+	case typeZsetZiplist, typeHashZiplist:
+		b, err := p.readString()
+		if err != nil {
+			return 0, err
+		}
+		n, err := ziplistLen(b)
+		return n / 2, err
+	case typeSetIntset:
+		b, err := p.readString()
+		if err != nil {
+			return 0, err
+		}
+		return intsetLen(b)
+	case typeSetListpack:
+		b, err := p.readString()
+		if err != nil {
+			return 0, err
+		}
+		return listpackLen(b)
+	case typeZsetListpack, typeHashListpack:
+		b, err := p.readString()
+		if err != nil {
+			return 0, err
+		}
+		n, err := listpackLen(b)
+		return n / 2, err
+	case typeListQuicklist, typeListQuicklist2:
+		return p.skipQuicklist(t == typeListQuicklist2)
+	case typeStreamListpacks, typeStreamListpacks2, typeStreamListpacks3:
+		return p.skipStream(t)
+	}
+	return 0, fmt.Errorf("%w: type %d", ErrUnsupported, t)
+}
+
+func (p *parser) skipStrings(per uint64) (int64, error) {
+	n, err := p.readLen()
+	if err != nil {
+		return 0, err
+	}
+	for i := uint64(0); i < n*per; i++ {
+		if err := p.skipString(); err != nil {
+			return 0, err
+		}
+	}
+	return int64(n), nil
+}
+
+// skipQuicklist quicklist2 每个节点带 container 类型, 1 为单个元素, 2 为 listpack
+func (p *parser) skipQuicklist(v2 bool) (int64, error) {
+	nodes, err := p.readLen()
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for i := uint64(0); i < nodes; i++ {
+		container := uint64(2)
+		if v2 {
+			if container, err = p.readLen(); err != nil {
+				return 0, err
+			}
+		}
+		b, err := p.readString()
+		if err != nil {
+			return 0, err
+		}
+		var n int64
+		switch {
+		case container == 1:
+			n = 1
+		case v2:
+			n, err = listpackLen(b)
+		default:
+			n, err = ziplistLen(b)
+		}
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// skipStream 返回 stream 长度
+func (p *parser) skipStream(t byte) (int64, error) {
+	nodes, err := p.readLen()
+	if err != nil {
+		return 0, err
+	}
+	for i := uint64(0); i < nodes; i++ {
+		if err := p.skipString(); err != nil {
+			return 0, err
+		}
+		if err := p.skipString(); err != nil {
+			return 0, err
+		}
+	}
+	// length, last_id
+	length, err := p.readLen()
+	if err != nil {
+		return 0, err
+	}
+	lens := 2
+	if t >= typeStreamListpacks2 {
+		// first_id, max_deleted_id, entries_added
+		lens += 5
+	}
+	if err := p.skipLens(lens); err != nil {
+		return 0, err
+	}
+
+	groups, err := p.readLen()
+	if err != nil {
+		return 0, err
+	}
+	for i := uint64(0); i < groups; i++ {
+		if err := p.skipString(); err != nil {
+			return 0, err
+		}
+		lens := 2
+		if t >= typeStreamListpacks2 {
+			lens++
+		}
+		if err := p.skipLens(lens); err != nil {
+			return 0, err
+		}
+		// PEL: id(16) + delivery_time(8) + delivery_count
+		pel, err := p.readLen()
+		if err != nil {
+			return 0, err
+		}
+		for k := uint64(0); k < pel; k++ {
+			if err := p.skip(24); err != nil {
+				return 0, err
+			}
+			if _, err := p.readLen(); err != nil {
+				return 0, err
+			}
+		}
+		consumers, err := p.readLen()
+		if err != nil {
+			return 0, err
+		}
+		for k := uint64(0); k < consumers; k++ {
+			if err := p.skipString(); err != nil {
+				return 0, err
+			}
+			// seen_time, active_time
+			n := int64(8)
+			if t >= typeStreamListpacks3 {
+				n += 8
+			}
+			if err := p.skip(n); err != nil {
+				return 0, err
+			}
+			cpel, err := p.readLen()
+			if err != nil {
+				return 0, err
+			}
+			if err := p.skip(int64(cpel) * 16); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return int64(length), nil
+}
+
+func (p *parser) skipModuleAux() error {
+	// module id, when_opcode, when
+	if err := p.skipLens(3); err != nil {
+		return err
+	}
+	return p.skipModuleValue()
+}
+
+// skipModuleValue 按 opcode 跳过 module 数据直到 EOF
+func (p *parser) skipModuleValue() error {
+	for {
+		op, err := p.readLen()
+		if err != nil {
+			return err
+		}
+		switch op {
+		case moduleOpEOF:
+			return nil
+		case moduleOpSint, moduleOpUint:
+			_, err = p.readLen()
+		case moduleOpFloat:
+			err = p.skip(4)
+		case moduleOpDouble:
+			err = p.skip(8)
+		case moduleOpString:
+			err = p.skipString()
+		default:
+			return ErrCorrupted
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (p *parser) skipLens(n int) error {
+	for i := 0; i < n; i++ {
+		if _, err := p.readLen(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *parser) readByte() (byte, error) {
+	b, err := p.r.ReadByte()
+	if err != nil {
+		return 0, unexpected(err)
+	}
+	p.offset++
+	return b, nil
+}
+
+// read 长度来自文件内容, 分配前校验不超过剩余输入和 MaxStringSize
+func (p *parser) read(n int) ([]byte, error) {
+	if n < 0 || n > MaxStringSize || (p.size > 0 && int64(n) > p.size-p.offset) {
+		return nil, ErrCorrupted
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(p.r, b); err != nil {
+		return nil, unexpected(err)
+	}
+	p.offset += int64(n)
+	return b, nil
+}
+
+// skip 长度来自文件内容, 超过 2^63 时为负数, 同样校验不超过剩余输入
+func (p *parser) skip(n int64) error {
+	if n < 0 || (p.size > 0 && n > p.size-p.offset) {
+		return ErrCorrupted
+	}
+	if _, err := io.CopyN(ioutil.Discard, p.r, n); err != nil {
+		return unexpected(err)
+	}
+	p.offset += n
+	return nil
+}
+
+// readLenEnc 返回长度, encoded 为 true 时长度表示特殊编码类型
+func (p *parser) readLenEnc() (uint64, bool, error) {
+	b, err := p.readByte()
+	if err != nil {
+		return 0, false, err
+	}
+	switch b >> 6 {
+	case 0:
+		return uint64(b & 0x3f), false, nil
+	case 1:
+		next, err := p.readByte()
+		if err != nil {
+			return 0, false, err
+		}
+		return uint64(b&0x3f)<<8 | uint64(next), false, nil
+	case 3:
+		return uint64(b & 0x3f), true, nil
+	}
+	switch b {
+	case 0x80:
+		buf, err := p.read(4)
+		if err != nil {
+			return 0, false, err
+		}
+		return uint64(binary.BigEndian.Uint32(buf)), false, nil
+	case 0x81:
+		buf, err := p.read(8)
+		if err != nil {
+			return 0, false, err
+		}
+		return binary.BigEndian.Uint64(buf), false, nil
+	}
+	return 0, false, ErrCorrupted
+}
+
+func (p *parser) readLen() (uint64, error) {
+	n, encoded, err := p.readLenEnc()
+	if err == nil && encoded {
+		err = ErrCorrupted
+	}
+	return n, err
+}
+
+// 特殊编码的字符串
+const (
+	encInt8  = 0
+	encInt16 = 1
+	encInt32 = 2
+	encLZF   = 3
+)
+
+func (p *parser) readString() ([]byte, error) {
+	n, encoded, err := p.readLenEnc()
+	if err != nil {
+		return nil, err
+	}
+	if !encoded {
+		return p.read(int(n))
+	}
+	switch n {
+	case encInt8:
+		b, err := p.read(1)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(strconv.Itoa(int(int8(b[0])))), nil
+	case encInt16:
+		b, err := p.read(2)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(strconv.Itoa(int(int16(binary.LittleEndian.Uint16(b))))), nil
+	case encInt32:
+		b, err := p.read(4)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(strconv.Itoa(int(int32(binary.LittleEndian.Uint32(b))))), nil
+	case encLZF:
+		clen, err := p.readLen()
+		if err != nil {
+			return nil, err
+		}
+		ulen, err := p.readLen()
+		if err != nil {
+			return nil, err
+		}
+		if ulen > uint64(MaxStringSize) || clen > ulen {
+			return nil, ErrCorrupted
+		}
+		b, err := p.read(int(clen))
+		if err != nil {
+			return nil, err
+		}
+		return lzfDecompress(b, int(ulen))
+	}
+	return nil, ErrCorrupted
+}
+
+func (p *parser) skipString() error {
+	n, encoded, err := p.readLenEnc()
+	if err != nil {
+		return err
+	}
+	if !encoded {
+		return p.skip(int64(n))
+	}
+	switch n {
+	case encInt8:
+		return p.skip(1)
+	case encInt16:
+		return p.skip(2)
+	case encInt32:
+		return p.skip(4)
+	case encLZF:
+		clen, err := p.readLen()
+		if err != nil {
+			return err
+		}
+		if _, err := p.readLen(); err != nil {
+			return err
+		}
+		return p.skip(int64(clen))
+	}
+	return ErrCorrupted
+}
+
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
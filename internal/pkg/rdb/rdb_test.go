@@ -0,0 +1,244 @@
+package rdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+)
+
+// rdbFile 拼接头部, 内容和 EOF
+func rdbFile(version string, body ...[]byte) []byte {
+	out := []byte("REDIS" + version)
+	for _, b := range body {
+		out = append(out, b...)
+	}
+	out = append(out, opEOF)
+	return append(out, make([]byte, 8)...)
+}
+
+// str 长度小于 64 的普通字符串
+func str(s string) []byte {
+	return append([]byte{byte(len(s))}, s...)
+}
+
+func le32(n uint32) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, n)
+	return b
+}
+
+func le64(n uint64) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, n)
+	return b
+}
+
+func cat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+func parseAll(data []byte, size int64) ([]Entry, error) {
+	var out []Entry
+	err := Parse(bytes.NewReader(data), size, func(e *Entry) error {
+		out = append(out, *e)
+		return nil
+	})
+	return out, err
+}
+
+func TestParse(t *testing.T) {
+	// "a" 后接长度 9 的回溯引用, 解压为 10 个 a
+	lzf := []byte{0xC3, 5, 10, 0x00, 'a', 0xE0, 0x00, 0x00}
+	intset := cat(le32(2), le32(3), make([]byte, 6))
+	ziplist := cat(le32(14), le32(10), []byte{2, 0}, []byte{0, 0x01, 'a', 0xFF})
+	ziplistScan := cat(le32(16), le32(10), []byte{0xFF, 0xFF}, []byte{0, 0x01, 'a', 3, 0xFE, 7, 0xFF})
+	listpackScan := cat(le32(12), []byte{0xFF, 0xFF}, []byte{0x01, 1, 0x81, 'a', 2, 0xFF})
+	long := bytes.Repeat([]byte("x"), 100)
+
+	tests := []struct {
+		name string
+		body [][]byte
+		want []Entry
+	}{
+		{
+			name: "string",
+			body: [][]byte{{typeString}, str("k"), str("v")},
+			want: []Entry{{Key: "k", Type: "string", Encoding: "string", Size: 2, Elements: 1}},
+		},
+		{
+			name: "int8 key",
+			body: [][]byte{{typeString, 0xC0, 0x7B}, str("v")},
+			want: []Entry{{Key: "123", Type: "string", Encoding: "string", Size: 2, Elements: 1}},
+		},
+		{
+			name: "int16 negative key",
+			body: [][]byte{{typeString, 0xC1, 0xFE, 0xFF}, str("v")},
+			want: []Entry{{Key: "-2", Type: "string", Encoding: "string", Size: 2, Elements: 1}},
+		},
+		{
+			name: "int32 key",
+			body: [][]byte{{typeString, 0xC2}, le32(100000), str("v")},
+			want: []Entry{{Key: "100000", Type: "string", Encoding: "string", Size: 2, Elements: 1}},
+		},
+		{
+			name: "lzf key",
+			body: [][]byte{{typeString}, lzf, str("v")},
+			want: []Entry{{Key: "aaaaaaaaaa", Type: "string", Encoding: "string", Size: 2, Elements: 1}},
+		},
+		{
+			name: "14 bit length value",
+			body: [][]byte{{typeString}, str("k"), {0x40, 100}, long},
+			want: []Entry{{Key: "k", Type: "string", Encoding: "string", Size: 102, Elements: 1}},
+		},
+		{
+			name: "list",
+			body: [][]byte{{typeList}, str("l"), {2}, str("a"), str("b")},
+			want: []Entry{{Key: "l", Type: "list", Encoding: "linkedlist", Size: 5, Elements: 2}},
+		},
+		{
+			name: "select db and expire ms",
+			body: [][]byte{{opSelectDB, 3, opExpireMs}, le64(1700000000000), {typeString}, str("k"), str("v")},
+			want: []Entry{{Db: 3, Key: "k", Type: "string", Encoding: "string", Size: 2, Elements: 1, Expire: 1700000000000}},
+		},
+		{
+			name: "aux and resizedb skipped",
+			body: [][]byte{{opAux}, str("redis-ver"), str("7.0.0"), {opResizeDB, 1, 0}, {typeString}, str("k"), str("v")},
+			want: []Entry{{Key: "k", Type: "string", Encoding: "string", Size: 2, Elements: 1}},
+		},
+		{
+			name: "intset",
+			body: [][]byte{{typeSetIntset}, str("s"), str(string(intset))},
+			want: []Entry{{Key: "s", Type: "set", Encoding: "intset", Size: 15, Elements: 3}},
+		},
+		{
+			name: "ziplist header count",
+			body: [][]byte{{typeListZiplist}, str("z"), str(string(ziplist))},
+			want: []Entry{{Key: "z", Type: "list", Encoding: "ziplist", Size: 15, Elements: 2}},
+		},
+		{
+			name: "ziplist scanned",
+			body: [][]byte{{typeListZiplist}, str("z"), str(string(ziplistScan))},
+			want: []Entry{{Key: "z", Type: "list", Encoding: "ziplist", Size: 18, Elements: 2}},
+		},
+		{
+			name: "listpack scanned",
+			body: [][]byte{{typeSetListpack}, str("p"), str(string(listpackScan))},
+			want: []Entry{{Key: "p", Type: "set", Encoding: "listpack", Size: 13, Elements: 2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := rdbFile("0011", tt.body...)
+			got, err := parseAll(data, int64(len(data)))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Parse() got %d entries, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("entry %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		unsized bool
+		want    error
+	}{
+		{
+			name: "bad header",
+			data: []byte("RADIS0009\xff"),
+			want: ErrInvalidHeader,
+		},
+		{
+			name: "unsupported version",
+			data: rdbFile("0099"),
+			want: ErrUnsupported,
+		},
+		{
+			name: "unknown type",
+			data: rdbFile("0009", []byte{8}, str("k")),
+			want: ErrUnsupported,
+		},
+		{
+			name:    "truncated value",
+			data:    []byte("REDIS0009\x00\x01k\x05ab"),
+			unsized: true,
+			want:    io.ErrUnexpectedEOF,
+		},
+		{
+			name: "length beyond input",
+			data: []byte("REDIS0009\x00\x05ab"),
+			want: ErrCorrupted,
+		},
+		{
+			name:    "length beyond max",
+			data:    cat([]byte("REDIS0009\x00\x80"), []byte{0x7F, 0xFF, 0xFF, 0xFF}),
+			unsized: true,
+			want:    ErrCorrupted,
+		},
+		{
+			name:    "lzf length beyond max",
+			data:    cat([]byte("REDIS0009\x00\xC3\x05\x80"), []byte{0x7F, 0xFF, 0xFF, 0xFF}, []byte{0x00, 'a', 0xE0, 0x00, 0x00}),
+			unsized: true,
+			want:    ErrCorrupted,
+		},
+		{
+			name:    "negative skip length",
+			data:    cat([]byte{'R', 'E', 'D', 'I', 'S', '0', '0', '0', '9', opAux, 0x81}, []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xF0}, str("v")),
+			unsized: true,
+			want:    ErrCorrupted,
+		},
+		{
+			name: "skip length beyond input",
+			data: rdbFile("0009", []byte{opAux, 0x80}, []byte{0x00, 0x00, 0x10, 0x00}, str("v")),
+			want: ErrCorrupted,
+		},
+		{
+			name: "skipped value beyond input",
+			data: rdbFile("0009", []byte{typeList, 1, 'l', 1, 0x40, 0xFF}),
+			want: ErrCorrupted,
+		},
+		{
+			name: "lzf length mismatch",
+			data: rdbFile("0009", []byte{typeString, 0xC3, 5, 20, 0x00, 'a', 0xE0, 0x00, 0x00}, str("v")),
+			want: ErrCorrupted,
+		},
+		{
+			name: "lzf output overflow",
+			data: rdbFile("0009", []byte{typeString, 0xC3, 5, 6, 0x00, 'a', 0xE0, 0x00, 0x00}, str("v")),
+			want: ErrCorrupted,
+		},
+		{
+			name: "lzf bad back reference",
+			data: rdbFile("0009", []byte{typeString, 0xC3, 4, 4, 0x00, 'a', 0x20, 0x05}, str("v")),
+			want: ErrCorrupted,
+		},
+		{
+			name: "short intset",
+			data: rdbFile("0009", []byte{typeSetIntset}, str("s"), str("abc")),
+			want: ErrCorrupted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			size := int64(len(tt.data))
+			if tt.unsized {
+				size = 0
+			}
+			_, err := parseAll(tt.data, size)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Parse() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
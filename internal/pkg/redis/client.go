@@ -3,12 +3,16 @@ package redis
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/fighthorse/redisAdmin/component/thirdpart/trace_redis"
 )
 
 var (
 	Others = map[string]*redisInstance{}
+	// 每个连接创建的其他 db 实例在 Others 中的名称
+	dbNames = map[string][]string{}
+	mux     sync.RWMutex
 )
 
 func Init() {
@@ -23,25 +27,61 @@ func LoadOthersNew(name string) {
 	cfg := &redisInstance{}
 	cfg.name = name
 	cfg.Client = trace_redis.NewClient(cfg.name)
+	mux.Lock()
 	Others[name] = cfg
+	mux.Unlock()
+}
+
+// Remove 删除连接及其各db实例并关闭, 连接配置修改/删除时使用
+func Remove(name string) {
+	var removed []*redisInstance
+	mux.Lock()
+	for _, k := range append([]string{name}, dbNames[name]...) {
+		if v, ok := Others[k]; ok {
+			removed = append(removed, v)
+			delete(Others, k)
+		}
+	}
+	delete(dbNames, name)
+	mux.Unlock()
+	for _, v := range removed {
+		trace_redis.CloseClient(v.Client)
+	}
 }
 
 func LoadOthersDB(name string, db int) *redisInstance {
+	mux.RLock()
 	dbZero, ok := Others[name]
+	mux.RUnlock()
 	if !ok {
-		return nil
+		// 配置存在但未初始化
+		if !trace_redis.HasCfg(name) {
+			return nil
+		}
+		LoadOthersNew(name)
+		mux.RLock()
+		dbZero = Others[name]
+		mux.RUnlock()
 	}
 	if db == 0 {
 		return dbZero
 	}
 	nameNew := fmt.Sprintf("%s_%d", name, db)
-	if ll, ok := Others[nameNew]; ok {
+	mux.RLock()
+	ll, ok := Others[nameNew]
+	mux.RUnlock()
+	if ok {
 		return ll
 	}
 	ll, err := dbZero.Select(context.Background(), db)
 	if err != nil {
 		return nil
 	}
+	mux.Lock()
+	if _, ok := Others[nameNew]; !ok {
+		dbNames[name] = append(dbNames[name], nameNew)
+	}
 	Others[nameNew] = ll
+	mux.Unlock()
 	return ll
 }
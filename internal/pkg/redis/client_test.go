@@ -0,0 +1,44 @@
+package redis
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestRemove(t *testing.T) {
+	oldOthers, oldDbNames := Others, dbNames
+	defer func() { Others, dbNames = oldOthers, oldDbNames }()
+	Others = map[string]*redisInstance{
+		"a":     {name: "a"},
+		"a_1":   {name: "a"},
+		"a_3":   {name: "a"},
+		"a_b":   {name: "a_b"},
+		"a_b_2": {name: "a_b"},
+	}
+	dbNames = map[string][]string{
+		"a":   {"a_1", "a_3"},
+		"a_b": {"a_b_2"},
+	}
+
+	Remove("a")
+	var left []string
+	for k := range Others {
+		left = append(left, k)
+	}
+	sort.Strings(left)
+	if want := []string{"a_b", "a_b_2"}; !reflect.DeepEqual(left, want) {
+		t.Errorf("Remove(a) left %q, want %q", left, want)
+	}
+	if _, ok := dbNames["a"]; ok {
+		t.Error("Remove(a) kept db names of a")
+	}
+	if want := []string{"a_b_2"}; !reflect.DeepEqual(dbNames["a_b"], want) {
+		t.Errorf("Remove(a) db names of a_b = %q, want %q", dbNames["a_b"], want)
+	}
+
+	Remove("a_b")
+	if len(Others) != 0 || len(dbNames) != 0 {
+		t.Errorf("Remove(a_b) left %v %v", Others, dbNames)
+	}
+}
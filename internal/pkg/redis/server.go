@@ -13,6 +13,9 @@ type redisInstance struct {
 }
 
 func (r *redisInstance) Select(ctx context.Context, db int) (*redisInstance, error) {
+	if r.Client.IsCluster() && db != 0 {
+		return nil, trace_redis.ErrClusterSelect
+	}
 	cfg := &redisInstance{
 		Client: trace_redis.NewClientDb(r.name, db),
 		name:   r.name,
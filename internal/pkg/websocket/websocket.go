@@ -0,0 +1,265 @@
+// Package websocket 最小的 RFC6455 服务端实现, 只支持未分片压缩的文本/二进制消息
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const guid = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// 帧类型
+const (
+	OpContinuation = 0x0
+	OpText         = 0x1
+	OpBinary       = 0x2
+	OpClose        = 0x8
+	OpPing         = 0x9
+	OpPong         = 0xA
+)
+
+// 关闭码
+const (
+	CloseNormal       = 1000
+	CloseGoingAway    = 1001
+	CloseProtocol     = 1002
+	CloseTooBig       = 1009
+	ClosePolicy       = 1008
+	CloseInternalErr  = 1011
+	closeNoStatusRcvd = 1005
+)
+
+var (
+	ErrBadHandshake = errors.New("websocket: bad handshake")
+	ErrTooBig       = errors.New("websocket: message too big")
+	ErrProtocol     = errors.New("websocket: protocol error")
+)
+
+// MaxMessageSize 读取客户端消息的最大字节数
+var MaxMessageSize int64 = 64 << 10
+
+// Conn 写操作加锁, 读只能在一个 goroutine 中进行
+type Conn struct {
+	conn net.Conn
+	br   *bufio.Reader
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// Upgrade 校验握手请求并接管连接
+func Upgrade(w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	if r.Method != http.MethodGet ||
+		!headerContains(r.Header, "Connection", "upgrade") ||
+		!headerContains(r.Header, "Upgrade", "websocket") ||
+		r.Header.Get("Sec-Websocket-Version") != "13" {
+		http.Error(w, "websocket handshake required", http.StatusBadRequest)
+		return nil, ErrBadHandshake
+	}
+	key := r.Header.Get("Sec-Websocket-Key")
+	if key == "" {
+		http.Error(w, "websocket handshake required", http.StatusBadRequest)
+		return nil, ErrBadHandshake
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, errors.New("websocket: response does not implement http.Hijacker")
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	resp := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n\r\n"
+	_ = conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	if _, err := conn.Write([]byte(resp)); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return &Conn{conn: conn, br: rw.Reader}, nil
+}
+
+func acceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + guid))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func headerContains(h http.Header, name, value string) bool {
+	for _, v := range h[http.CanonicalHeaderKey(name)] {
+		for _, s := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(s), value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WriteText 发送文本消息
+func (c *Conn) WriteText(data []byte) error {
+	return c.writeFrame(OpText, data)
+}
+
+// WriteClose 发送关闭帧并关闭连接
+func (c *Conn) WriteClose(code int, reason string) error {
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+	payload := make([]byte, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	copy(payload[2:], reason)
+	err := c.writeFrame(OpClose, payload)
+	_ = c.Close()
+	return err
+}
+
+func (c *Conn) writeFrame(op byte, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return net.ErrClosed
+	}
+	// 服务端发送的帧不加掩码
+	header := make([]byte, 0, 10)
+	header = append(header, 0x80|op)
+	switch n := len(data); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 126, byte(n>>8), byte(n))
+	default:
+		header = append(header, 127)
+		header = append(header, make([]byte, 8)...)
+		binary.BigEndian.PutUint64(header[2:], uint64(n))
+	}
+	_ = c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	if _, err := c.conn.Write(header); err != nil {
+		return err
+	}
+	_, err := c.conn.Write(data)
+	return err
+}
+
+// ReadMessage 读取一条完整消息, 自动回复 ping/close, 收到 close 时返回 io.EOF
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	var (
+		op  int
+		msg []byte
+	)
+	for {
+		fin, frameOp, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+		switch frameOp {
+		case OpPing:
+			if err := c.writeFrame(OpPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case OpPong:
+			continue
+		case OpClose:
+			code := closeNoStatusRcvd
+			if len(payload) >= 2 {
+				code = int(binary.BigEndian.Uint16(payload))
+			}
+			_ = c.WriteClose(code, "")
+			return 0, nil, io.EOF
+		case OpContinuation:
+			if op == 0 {
+				return 0, nil, ErrProtocol
+			}
+		case OpText, OpBinary:
+			if op != 0 {
+				return 0, nil, ErrProtocol
+			}
+			op = frameOp
+		default:
+			return 0, nil, ErrProtocol
+		}
+		if int64(len(msg)+len(payload)) > MaxMessageSize {
+			_ = c.WriteClose(CloseTooBig, "")
+			return 0, nil, ErrTooBig
+		}
+		msg = append(msg, payload...)
+		if fin {
+			return op, msg, nil
+		}
+	}
+}
+
+func (c *Conn) readFrame() (bool, int, []byte, error) {
+	var h [2]byte
+	if _, err := io.ReadFull(c.br, h[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin := h[0]&0x80 != 0
+	op := int(h[0] & 0x0F)
+	masked := h[1]&0x80 != 0
+	// 客户端发送的帧必须加掩码, 不支持扩展
+	if !masked || h[0]&0x70 != 0 {
+		_ = c.WriteClose(CloseProtocol, "")
+		return false, 0, nil, ErrProtocol
+	}
+	n := int64(h[1] & 0x7F)
+	switch n {
+	case 126:
+		var b [2]byte
+		if _, err := io.ReadFull(c.br, b[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = int64(binary.BigEndian.Uint16(b[:]))
+	case 127:
+		var b [8]byte
+		if _, err := io.ReadFull(c.br, b[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = int64(binary.BigEndian.Uint64(b[:]))
+	}
+	// 控制帧不能超过 125 字节且不能分片
+	if op >= OpClose && (n > 125 || !fin) {
+		_ = c.WriteClose(CloseProtocol, "")
+		return false, 0, nil, ErrProtocol
+	}
+	if n < 0 || n > MaxMessageSize {
+		_ = c.WriteClose(CloseTooBig, "")
+		return false, 0, nil, ErrTooBig
+	}
+	var mask [4]byte
+	if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, op, payload, nil
+}
+
+// Close 直接关闭底层连接
+func (c *Conn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	return c.conn.Close()
+}
@@ -41,5 +41,7 @@ func Check(c *gin.Context, token string) (*protos.Person, error) {
 	if dataInfo.Ip != ip.String() {
 		return nil, errors.New("ip发生变化重新登录")
 	}
+	// 角色以配置为准
+	dataInfo.Role = UserRole(dataInfo.Name)
 	return &dataInfo, nil
 }
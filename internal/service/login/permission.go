@@ -0,0 +1,235 @@
+package login
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fighthorse/redisAdmin/component/conf"
+	"github.com/fighthorse/redisAdmin/component/self_errors"
+)
+
+// 角色
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+var roleLevel = map[string]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleAdmin:  3,
+}
+
+// FindUser 查找登录用户配置
+func FindUser(userName string) (conf.LoginUser, bool) {
+	for _, v := range conf.GConfig.LoginUser {
+		if v.UserName == userName {
+			return v, true
+		}
+	}
+	return conf.LoginUser{}, false
+}
+
+// UserRole 用户角色, 未配置时为 viewer
+func UserRole(userName string) string {
+	u, ok := FindUser(userName)
+	if !ok {
+		return ""
+	}
+	if _, ok := roleLevel[u.Role]; !ok {
+		return RoleViewer
+	}
+	return u.Role
+}
+
+// HasRole 用户角色不低于 role
+func HasRole(userName, role string) bool {
+	return roleLevel[UserRole(userName)] >= roleLevel[role]
+}
+
+// CheckAccess 校验实例/db/key 访问权限, key 为空时只校验实例和db
+func CheckAccess(userName, instance, db, key string, write bool) error {
+	u, ok := FindUser(userName)
+	if !ok {
+		return &self_errors.PermissionError{Reason: "账户不存在"}
+	}
+	if db == "" {
+		db = "0"
+	}
+	if write && !HasRole(userName, RoleEditor) {
+		return &self_errors.PermissionError{Reason: "只读账户不允许修改数据"}
+	}
+
+	for _, rule := range u.Deny {
+		if matchRule(rule, instance, db, key, false) {
+			return &self_errors.PermissionError{Reason: fmt.Sprintf("禁止访问 %s/%s %s", instance, db, key)}
+		}
+	}
+	if len(u.Allow) == 0 {
+		return nil
+	}
+	for _, rule := range u.Allow {
+		if !matchRule(rule, instance, db, key, true) {
+			continue
+		}
+		if write && rule.ReadOnly {
+			continue
+		}
+		return nil
+	}
+	return &self_errors.PermissionError{Reason: fmt.Sprintf("没有权限访问 %s/%s %s", instance, db, key)}
+}
+
+// CheckInstance 用户能否看到该实例, 实例上任一 db 有权限即可
+func CheckInstance(userName, instance string) error {
+	u, ok := FindUser(userName)
+	if !ok {
+		return &self_errors.PermissionError{Reason: "账户不存在"}
+	}
+	for _, rule := range u.Deny {
+		if matchAll(rule.Db) && matchRule(rule, instance, "", "", false) {
+			return &self_errors.PermissionError{Reason: fmt.Sprintf("禁止访问 %s", instance)}
+		}
+	}
+	if len(u.Allow) == 0 {
+		return nil
+	}
+	for _, rule := range u.Allow {
+		if matchAll(rule.Instance) || rule.Instance == instance {
+			return nil
+		}
+	}
+	return &self_errors.PermissionError{Reason: fmt.Sprintf("没有权限访问 %s", instance)}
+}
+
+// HasKeyRules 用户在该实例/db 上配置了 key 级别的规则
+func HasKeyRules(userName, instance, db string) bool {
+	u, ok := FindUser(userName)
+	if !ok {
+		return false
+	}
+	if db == "" {
+		db = "0"
+	}
+	for _, rules := range [][]conf.AccessRule{u.Allow, u.Deny} {
+		for _, rule := range rules {
+			if !matchAll(rule.Key) && matchRule(conf.AccessRule{Instance: rule.Instance, Db: rule.Db}, instance, db, "", true) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchRule allow 规则在 key 为空时只匹配实例和db, deny 规则带 key 时只拒绝具体key
+func matchRule(rule conf.AccessRule, instance, db, key string, allow bool) bool {
+	if !matchAll(rule.Instance) && rule.Instance != instance {
+		return false
+	}
+	if !matchAll(rule.Db) && rule.Db != db {
+		return false
+	}
+	if matchAll(rule.Key) {
+		return true
+	}
+	if key == "" {
+		return allow
+	}
+	return MatchPattern(rule.Key, key)
+}
+
+func matchAll(s string) bool {
+	return s == "" || s == "*"
+}
+
+// MatchPattern redis 风格通配符匹配, 支持 * 和 ?
+func MatchPattern(pattern, s string) bool {
+	if pattern == "" {
+		return s == ""
+	}
+	switch pattern[0] {
+	case '*':
+		for i := 0; i <= len(s); i++ {
+			if MatchPattern(pattern[1:], s[i:]) {
+				return true
+			}
+		}
+		return false
+	case '?':
+		return s != "" && MatchPattern(pattern[1:], s[1:])
+	}
+	return s != "" && pattern[0] == s[0] && MatchPattern(pattern[1:], s[1:])
+}
+
+// 连接池上不安全或会阻塞连接的命令, 不受配置影响始终禁止
+var blockedCommands = []string{
+	"SELECT", "MULTI", "EXEC", "DISCARD", "WATCH", "UNWATCH", "MONITOR",
+	"SUBSCRIBE", "PSUBSCRIBE", "SSUBSCRIBE", "UNSUBSCRIBE", "PUNSUBSCRIBE", "SUNSUBSCRIBE",
+	"SYNC", "PSYNC", "QUIT", "RESET", "AUTH", "HELLO", "CLIENT REPLY",
+}
+
+// 未配置 [console] deny 时默认禁用的命令
+var defaultDenyCommands = []string{
+	"FLUSHALL", "FLUSHDB", "CONFIG SET", "CONFIG REWRITE", "CONFIG RESETSTAT", "KEYS", "SHUTDOWN",
+	"DEBUG", "REPLICAOF", "SLAVEOF", "CLUSTER RESET", "CLUSTER FAILOVER", "SCRIPT FLUSH", "FUNCTION FLUSH",
+	"SWAPDB", "MIGRATE", "ACL SETUSER", "ACL DELUSER",
+}
+
+// CheckCommand 校验 console 命令, 用户 deny 优先, 全局 deny 只能由用户 allow 中明确列出的命令放开,
+// 配置了 allow 时只允许列表内命令
+func CheckCommand(userName, cmd, sub string) error {
+	u, ok := FindUser(userName)
+	if !ok {
+		return &self_errors.PermissionError{Reason: "账户不存在"}
+	}
+	name := strings.ToUpper(cmd)
+	if sub != "" {
+		name += " " + strings.ToUpper(sub)
+	}
+	if matchCommand(blockedCommands, cmd, sub) {
+		return &self_errors.PermissionError{Reason: fmt.Sprintf("console 不支持命令 %s", name)}
+	}
+	if matchCommand(u.DenyCommands, cmd, sub) {
+		return &self_errors.PermissionError{Reason: fmt.Sprintf("禁止执行命令 %s", name)}
+	}
+	deny := conf.GConfig.Console.Deny
+	if deny == nil {
+		deny = defaultDenyCommands
+	}
+	if matchCommand(deny, cmd, sub) && !matchCommand(explicitCommands(u.AllowCommands), cmd, sub) {
+		return &self_errors.PermissionError{Reason: fmt.Sprintf("禁止执行命令 %s", name)}
+	}
+	if len(u.AllowCommands) > 0 && !matchCommand(u.AllowCommands, cmd, sub) {
+		return &self_errors.PermissionError{Reason: fmt.Sprintf("没有权限执行命令 %s", name)}
+	}
+	return nil
+}
+
+// explicitCommands 去掉 "*", 只保留明确列出的命令
+func explicitCommands(list []string) []string {
+	var out []string
+	for _, v := range list {
+		if fields := strings.Fields(v); len(fields) > 0 && fields[0] != "*" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// matchCommand 列表项为 "CMD" 或 "CMD SUB", "*" 匹配所有命令
+func matchCommand(list []string, cmd, sub string) bool {
+	for _, v := range list {
+		fields := strings.Fields(v)
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] != "*" && !strings.EqualFold(fields[0], cmd) {
+			continue
+		}
+		if len(fields) == 1 || strings.EqualFold(fields[1], sub) {
+			return true
+		}
+	}
+	return false
+}
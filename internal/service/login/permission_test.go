@@ -0,0 +1,175 @@
+package login
+
+import (
+	"testing"
+
+	"github.com/fighthorse/redisAdmin/component/conf"
+)
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		want    bool
+	}{
+		{"", "", true},
+		{"", "a", false},
+		{"abc", "abc", true},
+		{"abc", "abd", false},
+		{"abc", "ab", false},
+		{"ab", "abc", false},
+		{"*", "", true},
+		{"*", "anything", true},
+		{"**", "", true},
+		{"a*", "a", true},
+		{"a*", "abc", true},
+		{"a*", "ba", false},
+		{"*c", "abc", true},
+		{"*c", "abd", false},
+		{"a*c", "ac", true},
+		{"a*c", "abbbc", true},
+		{"a*c", "abcd", false},
+		{"*b*", "abc", true},
+		{"*b*", "ac", false},
+		{"?", "", false},
+		{"?", "a", true},
+		{"?", "ab", false},
+		{"a?c", "abc", true},
+		{"a?c", "ac", false},
+		{"??*", "a", false},
+		{"??*", "ab", true},
+		{"session:*", "session:1", true},
+		{"session:*", "sessions:1", false},
+		{"user:*:name", "user:1:name", true},
+		{"user:*:name", "user:1:age", false},
+		{"*:*", "a:b:c", true},
+		{"a*b*c", "axxbxxc", true},
+		{"a*b*c", "axxcxxb", false},
+	}
+	for _, tt := range tests {
+		if got := MatchPattern(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("MatchPattern(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestCheckAccess(t *testing.T) {
+	old := conf.GConfig.LoginUser
+	defer func() { conf.GConfig.LoginUser = old }()
+	conf.GConfig.LoginUser = []conf.LoginUser{
+		{UserName: "admin", Role: RoleAdmin},
+		{
+			UserName: "viewer",
+			Allow:    []conf.AccessRule{{Instance: "base", Db: "0"}},
+			Deny:     []conf.AccessRule{{Instance: "base", Key: "secret*"}},
+		},
+		{
+			UserName: "editor",
+			Role:     RoleEditor,
+			Allow:    []conf.AccessRule{{Instance: "base", Key: "app:*"}, {Instance: "cache", ReadOnly: true}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		user     string
+		instance string
+		db       string
+		key      string
+		write    bool
+		wantErr  bool
+	}{
+		{name: "unknown user", user: "nobody", instance: "base", wantErr: true},
+		{name: "admin any", user: "admin", instance: "other", db: "3", key: "k", write: true},
+		{name: "viewer read", user: "viewer", instance: "base", key: "k"},
+		{name: "viewer empty db is 0", user: "viewer", instance: "base", db: ""},
+		{name: "viewer other db", user: "viewer", instance: "base", db: "1", wantErr: true},
+		{name: "viewer other instance", user: "viewer", instance: "cache", wantErr: true},
+		{name: "viewer denied key", user: "viewer", instance: "base", key: "secret:1", wantErr: true},
+		{name: "viewer instance only with deny key", user: "viewer", instance: "base"},
+		{name: "viewer write", user: "viewer", instance: "base", key: "k", write: true, wantErr: true},
+		{name: "editor allowed key", user: "editor", instance: "base", key: "app:1", write: true},
+		{name: "editor other key", user: "editor", instance: "base", key: "user:1", wantErr: true},
+		{name: "editor instance only", user: "editor", instance: "base"},
+		{name: "editor read only rule read", user: "editor", instance: "cache", key: "k"},
+		{name: "editor read only rule write", user: "editor", instance: "cache", key: "k", write: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckAccess(tt.user, tt.instance, tt.db, tt.key, tt.write)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckAccess() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if !HasKeyRules("viewer", "base", "0") || !HasKeyRules("editor", "base", "") {
+		t.Error("HasKeyRules() = false, want true")
+	}
+	if HasKeyRules("viewer", "cache", "0") || HasKeyRules("admin", "base", "0") {
+		t.Error("HasKeyRules() = true, want false")
+	}
+
+	for _, tt := range []struct {
+		user, instance string
+		wantErr        bool
+	}{
+		{user: "admin", instance: "other"},
+		{user: "viewer", instance: "base"},
+		{user: "viewer", instance: "cache", wantErr: true},
+		{user: "editor", instance: "cache"},
+		{user: "editor", instance: "other", wantErr: true},
+		{user: "nobody", instance: "base", wantErr: true},
+	} {
+		if err := CheckInstance(tt.user, tt.instance); (err != nil) != tt.wantErr {
+			t.Errorf("CheckInstance(%s, %s) error = %v, wantErr %v", tt.user, tt.instance, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCheckCommand(t *testing.T) {
+	oldUsers, oldDeny := conf.GConfig.LoginUser, conf.GConfig.Console.Deny
+	defer func() { conf.GConfig.LoginUser, conf.GConfig.Console.Deny = oldUsers, oldDeny }()
+	conf.GConfig.LoginUser = []conf.LoginUser{
+		{UserName: "plain"},
+		{UserName: "wildcard", AllowCommands: []string{"*"}},
+		{UserName: "limited", AllowCommands: []string{"GET", "CONFIG GET"}},
+		{UserName: "flusher", AllowCommands: []string{"*", "FLUSHDB"}, DenyCommands: []string{"DEBUG"}},
+	}
+
+	tests := []struct {
+		name    string
+		deny    []string
+		user    string
+		cmd     string
+		sub     string
+		wantErr bool
+	}{
+		{name: "unknown user", user: "nobody", cmd: "GET", wantErr: true},
+		{name: "blocked", user: "plain", cmd: "select", sub: "1", wantErr: true},
+		{name: "blocked sub", user: "plain", cmd: "CLIENT", sub: "REPLY", wantErr: true},
+		{name: "plain get", user: "plain", cmd: "GET", sub: "k"},
+		{name: "default deny flushall", user: "plain", cmd: "flushall", wantErr: true},
+		{name: "default deny config set", user: "plain", cmd: "CONFIG", sub: "set", wantErr: true},
+		{name: "default deny keys", user: "plain", cmd: "KEYS", sub: "*", wantErr: true},
+		{name: "config get allowed", user: "plain", cmd: "CONFIG", sub: "GET"},
+		{name: "configured deny replaces default", deny: []string{"GET"}, user: "plain", cmd: "KEYS", sub: "*"},
+		{name: "configured deny", deny: []string{"GET"}, user: "plain", cmd: "GET", sub: "k", wantErr: true},
+		{name: "wildcard allow keeps global deny", user: "wildcard", cmd: "FLUSHALL", wantErr: true},
+		{name: "wildcard allow", user: "wildcard", cmd: "HGETALL", sub: "h"},
+		{name: "limited allowed", user: "limited", cmd: "CONFIG", sub: "GET"},
+		{name: "limited not listed", user: "limited", cmd: "SET", sub: "k", wantErr: true},
+		{name: "limited global deny", user: "limited", cmd: "KEYS", sub: "*", wantErr: true},
+		{name: "explicit allow overrides global deny", user: "flusher", cmd: "FLUSHDB"},
+		{name: "user deny", user: "flusher", cmd: "DEBUG", sub: "OBJECT", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf.GConfig.Console.Deny = tt.deny
+			err := CheckCommand(tt.user, tt.cmd, tt.sub)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckCommand(%s %s) error = %v, wantErr %v", tt.cmd, tt.sub, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package monitor
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fighthorse/redisAdmin/component/thirdpart/trace_redis"
+	"github.com/fighthorse/redisAdmin/protos"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	goredis "github.com/go-redis/redis"
+)
+
+var (
+	scrapeTimeout = 5 * time.Second
+
+	nodeLabels = []string{"instance", "node"}
+
+	descUp             = prometheus.NewDesc("redis_up", "1 if all nodes of the instance answered INFO in time", []string{"instance"}, nil)
+	descScrapeDuration = prometheus.NewDesc("redis_scrape_duration_seconds", "time spent scraping the instance", []string{"instance"}, nil)
+	descNodeUp         = prometheus.NewDesc("redis_node_up", "1 if the node answered INFO in time", nodeLabels, nil)
+	descMemoryUsed     = prometheus.NewDesc("redis_memory_used_bytes", "used_memory", nodeLabels, nil)
+	descMemoryRss      = prometheus.NewDesc("redis_memory_used_rss_bytes", "used_memory_rss", nodeLabels, nil)
+	descMemoryMax      = prometheus.NewDesc("redis_memory_max_bytes", "maxmemory", nodeLabels, nil)
+	descOps            = prometheus.NewDesc("redis_instantaneous_ops_per_sec", "instantaneous_ops_per_sec", nodeLabels, nil)
+	descHits           = prometheus.NewDesc("redis_keyspace_hits_total", "keyspace_hits", nodeLabels, nil)
+	descMisses         = prometheus.NewDesc("redis_keyspace_misses_total", "keyspace_misses", nodeLabels, nil)
+	descHitRatio       = prometheus.NewDesc("redis_keyspace_hit_ratio", "keyspace_hits / (keyspace_hits + keyspace_misses)", nodeLabels, nil)
+	descClients        = prometheus.NewDesc("redis_connected_clients", "connected_clients", nodeLabels, nil)
+	descBlocked        = prometheus.NewDesc("redis_blocked_clients", "blocked_clients", nodeLabels, nil)
+	descEvicted        = prometheus.NewDesc("redis_evicted_keys_total", "evicted_keys", nodeLabels, nil)
+	descExpired        = prometheus.NewDesc("redis_expired_keys_total", "expired_keys", nodeLabels, nil)
+	descCommands       = prometheus.NewDesc("redis_commands_processed_total", "total_commands_processed", nodeLabels, nil)
+	descSlaves         = prometheus.NewDesc("redis_connected_slaves", "connected_slaves", nodeLabels, nil)
+	descReplLag        = prometheus.NewDesc("redis_replication_lag_seconds", "lag reported by master for each slave", []string{"instance", "node", "slave"}, nil)
+	descReplOffsetLag  = prometheus.NewDesc("redis_replication_offset_lag_bytes", "master_repl_offset minus slave offset", []string{"instance", "node", "slave"}, nil)
+	descMasterLink     = prometheus.NewDesc("redis_master_link_up", "1 if master_link_status is up, only for slaves", nodeLabels, nil)
+	descDbKeys         = prometheus.NewDesc("redis_db_keys", "keys of each db", []string{"instance", "node", "db"}, nil)
+	descDbExpires      = prometheus.NewDesc("redis_db_keys_expiring", "keys with ttl of each db", []string{"instance", "node", "db"}, nil)
+	descCmdCalls       = prometheus.NewDesc("redis_command_calls_total", "calls of each command", []string{"instance", "node", "cmd"}, nil)
+	descCmdDuration    = prometheus.NewDesc("redis_command_duration_seconds_total", "total time spent of each command", []string{"instance", "node", "cmd"}, nil)
+	descCmdLatency     = prometheus.NewDesc("redis_command_latency_seconds", "latency percentiles of each command", []string{"instance", "node", "cmd", "quantile"}, nil)
+)
+
+// An Exporter collects INFO of configured instances at scrape time
+type Exporter struct {
+	timeout   time.Duration
+	instances []string // 为空时采集全部连接
+}
+
+// NewExporter creates an exporter, instances are all configured connections when empty.
+func NewExporter(timeout time.Duration, instances ...string) *Exporter {
+	return &Exporter{timeout: timeout, instances: instances}
+}
+
+// Describe implements prometheus.Collector
+func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		descUp, descScrapeDuration, descNodeUp, descMemoryUsed, descMemoryRss, descMemoryMax, descOps,
+		descHits, descMisses, descHitRatio, descClients, descBlocked, descEvicted, descExpired, descCommands,
+		descSlaves, descReplLag, descReplOffsetLag, descMasterLink, descDbKeys, descDbExpires,
+		descCmdCalls, descCmdDuration, descCmdLatency,
+	} {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector
+func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
+	names := e.instances
+	if len(names) == 0 {
+		names = trace_redis.CfgNames()
+	}
+	var wg sync.WaitGroup
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			e.collectInstance(ch, name)
+		}(name)
+	}
+	wg.Wait()
+}
+
+type nodeInfo struct {
+	node string
+	info *protos.RedisInfo
+	err  error
+}
+
+// collectInstance 超时未返回的节点视为 down, 不等待其结果
+func (e *Exporter) collectInstance(ch chan<- prometheus.Metric, name string) {
+	start := time.Now()
+	res := make(chan []nodeInfo, 1)
+	go func() {
+		var list []nodeInfo
+		var mu sync.Mutex
+		client, err := trace_redis.Mgr().NewClient(name)
+		if err != nil {
+			res <- []nodeInfo{{err: err}}
+			return
+		}
+		_ = client.ForEachMaster(func(node *goredis.Client) error {
+			text, err := node.Info("all").Result()
+			item := nodeInfo{node: node.Options().Addr, err: err}
+			if err == nil {
+				item.info = ParseInfo(text)
+			}
+			mu.Lock()
+			list = append(list, item)
+			mu.Unlock()
+			return nil
+		})
+		res <- list
+	}()
+
+	up := 0.0
+	select {
+	case list := <-res:
+		up = 1
+		for _, v := range list {
+			if v.err != nil {
+				up = 0
+				if v.node != "" {
+					ch <- prometheus.MustNewConstMetric(descNodeUp, prometheus.GaugeValue, 0, name, v.node)
+				}
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(descNodeUp, prometheus.GaugeValue, 1, name, v.node)
+			collectNode(ch, name, v.node, v.info)
+		}
+	case <-time.After(e.timeout):
+	}
+	ch <- prometheus.MustNewConstMetric(descUp, prometheus.GaugeValue, up, name)
+	ch <- prometheus.MustNewConstMetric(descScrapeDuration, prometheus.GaugeValue, time.Since(start).Seconds(), name)
+}
+
+func collectNode(ch chan<- prometheus.Metric, name, node string, info *protos.RedisInfo) {
+	gauge := func(d *prometheus.Desc, v float64, labels ...string) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.GaugeValue, v, append([]string{name, node}, labels...)...)
+	}
+	counter := func(d *prometheus.Desc, v float64, labels ...string) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.CounterValue, v, append([]string{name, node}, labels...)...)
+	}
+
+	gauge(descMemoryUsed, float64(info.Memory.UsedMemory))
+	gauge(descMemoryRss, float64(info.Memory.UsedMemoryRss))
+	gauge(descMemoryMax, float64(info.Memory.Maxmemory))
+	gauge(descOps, float64(info.Stats.InstantaneousOpsPerSec))
+	counter(descHits, float64(info.Stats.KeyspaceHits))
+	counter(descMisses, float64(info.Stats.KeyspaceMisses))
+	if total := info.Stats.KeyspaceHits + info.Stats.KeyspaceMisses; total > 0 {
+		gauge(descHitRatio, float64(info.Stats.KeyspaceHits)/float64(total))
+	}
+	gauge(descClients, float64(info.Clients.ConnectedClients))
+	gauge(descBlocked, float64(info.Clients.BlockedClients))
+	counter(descEvicted, float64(info.Stats.EvictedKeys))
+	counter(descExpired, float64(info.Stats.ExpiredKeys))
+	counter(descCommands, float64(info.Stats.TotalCommandsProcessed))
+
+	repl := info.Replication
+	gauge(descSlaves, float64(repl.ConnectedSlaves))
+	for _, v := range repl.Slaves {
+		slave := v.Ip + ":" + strconv.Itoa(v.Port)
+		gauge(descReplLag, float64(v.Lag), slave)
+		gauge(descReplOffsetLag, float64(repl.MasterReplOffset-v.Offset), slave)
+	}
+	if repl.Role == "slave" {
+		linkUp := 0.0
+		if repl.MasterLinkStatus == "up" {
+			linkUp = 1
+		}
+		gauge(descMasterLink, linkUp)
+	}
+
+	for db, v := range info.Keyspace {
+		gauge(descDbKeys, float64(v.Keys), db)
+		gauge(descDbExpires, float64(v.Expires), db)
+	}
+	for cmd, v := range info.Commandstats {
+		counter(descCmdCalls, float64(v.Calls), cmd)
+		counter(descCmdDuration, float64(v.Usec)/1e6, cmd)
+	}
+	for cmd, v := range info.Latencystats {
+		gauge(descCmdLatency, v.P50/1e6, cmd, "0.5")
+		gauge(descCmdLatency, v.P99/1e6, cmd, "0.99")
+		gauge(descCmdLatency, v.P999/1e6, cmd, "0.999")
+	}
+}
+
+// ScrapeHandler /scrape?target=name 只采集一个连接, 同 redis_exporter 的多目标模式
+func ScrapeHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target := r.URL.Query().Get("target")
+		if target == "" || !trace_redis.HasCfg(target) {
+			http.Error(w, "unknown target", http.StatusBadRequest)
+			return
+		}
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(NewExporter(scrapeTimeout, target))
+		promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+	})
+}
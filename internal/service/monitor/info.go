@@ -0,0 +1,134 @@
+package monitor
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/fighthorse/redisAdmin/component/metrics"
+	"github.com/fighthorse/redisAdmin/protos"
+	"github.com/mitchellh/mapstructure"
+)
+
+// ParseInfo 解析 INFO 返回的文本, 无法识别的字段只保存在 Raw 中
+func ParseInfo(text string) *protos.RedisInfo {
+	raw := map[string]map[string]string{}
+	section := ""
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			section = strings.ToLower(strings.TrimSpace(line[1:]))
+			continue
+		}
+		idx := strings.Index(line, ":")
+		if idx < 0 {
+			continue
+		}
+		if raw[section] == nil {
+			raw[section] = map[string]string{}
+		}
+		raw[section][line[:idx]] = line[idx+1:]
+	}
+
+	info := &protos.RedisInfo{
+		Keyspace:     map[string]protos.InfoKeyspace{},
+		Commandstats: map[string]protos.InfoCommand{},
+		Latencystats: map[string]protos.InfoLatency{},
+		Raw:          raw,
+	}
+	decode(raw["server"], &info.Server)
+	decode(raw["clients"], &info.Clients)
+	decode(raw["memory"], &info.Memory)
+	decode(raw["persistence"], &info.Persistence)
+	decode(raw["stats"], &info.Stats)
+	decode(raw["replication"], &info.Replication)
+	for k, v := range raw["replication"] {
+		// slave0:ip=127.0.0.1,port=6380,state=online,offset=1,lag=0
+		if !strings.HasPrefix(k, "slave") || !strings.Contains(v, "=") {
+			continue
+		}
+		var slave protos.InfoSlave
+		decode(parseKv(v), &slave)
+		info.Replication.Slaves = append(info.Replication.Slaves, slave)
+	}
+	for k, v := range raw["keyspace"] {
+		var ks protos.InfoKeyspace
+		decode(parseKv(v), &ks)
+		info.Keyspace[k] = ks
+	}
+	for k, v := range raw["commandstats"] {
+		var cmd protos.InfoCommand
+		decode(parseKv(v), &cmd)
+		info.Commandstats[strings.TrimPrefix(k, "cmdstat_")] = cmd
+	}
+	for k, v := range raw["latencystats"] {
+		var latency protos.InfoLatency
+		decode(parseKv(v), &latency)
+		info.Latencystats[strings.TrimPrefix(k, "latency_percentiles_usec_")] = latency
+	}
+	return info
+}
+
+// decode 字符串按字段类型转换, 无法转换的字段保持零值
+func decode(kv map[string]string, out interface{}) {
+	if len(kv) == 0 {
+		return
+	}
+	in := make(map[string]interface{}, len(kv))
+	for k, v := range kv {
+		in[k] = v
+	}
+	_ = mapstructure.WeakDecode(in, out)
+}
+
+// parseKv "k1=v1,k2=v2" 格式
+func parseKv(s string) map[string]string {
+	out := map[string]string{}
+	for _, v := range strings.Split(s, ",") {
+		ll := strings.SplitN(v, "=", 2)
+		if len(ll) == 2 {
+			out[ll[0]] = ll[1]
+		}
+	}
+	return out
+}
+
+// gauges INFO 中的数值字段
+func gauges(info *protos.RedisInfo) []metrics.RedisGauge {
+	var out []metrics.RedisGauge
+	for section, fields := range info.Raw {
+		switch section {
+		case "keyspace", "commandstats", "latencystats":
+			continue
+		}
+		for k, v := range fields {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				out = append(out, metrics.RedisGauge{Metric: "info", Key: section, Field: k, Value: f})
+			}
+		}
+	}
+	for db, v := range info.Keyspace {
+		out = append(out,
+			metrics.RedisGauge{Metric: "keyspace", Key: db, Field: "keys", Value: float64(v.Keys)},
+			metrics.RedisGauge{Metric: "keyspace", Key: db, Field: "expires", Value: float64(v.Expires)},
+			metrics.RedisGauge{Metric: "keyspace", Key: db, Field: "avg_ttl", Value: float64(v.AvgTtl)},
+		)
+	}
+	for cmd, v := range info.Commandstats {
+		out = append(out,
+			metrics.RedisGauge{Metric: "command", Key: cmd, Field: "calls", Value: float64(v.Calls)},
+			metrics.RedisGauge{Metric: "command", Key: cmd, Field: "usec", Value: float64(v.Usec)},
+			metrics.RedisGauge{Metric: "command", Key: cmd, Field: "usec_per_call", Value: v.UsecPerCall},
+		)
+	}
+	for cmd, v := range info.Latencystats {
+		out = append(out,
+			metrics.RedisGauge{Metric: "command", Key: cmd, Field: "p50", Value: v.P50},
+			metrics.RedisGauge{Metric: "command", Key: cmd, Field: "p99", Value: v.P99},
+			metrics.RedisGauge{Metric: "command", Key: cmd, Field: "p99.9", Value: v.P999},
+		)
+	}
+	return out
+}
@@ -0,0 +1,104 @@
+package monitor
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fighthorse/redisAdmin/protos"
+)
+
+const infoText = "# Server\r\n" +
+	"redis_version:7.2.4\r\n" +
+	"redis_mode:standalone\r\n" +
+	"arch_bits:64\r\n" +
+	"uptime_in_seconds:3600\r\n" +
+	"\r\n" +
+	"# Clients\r\n" +
+	"connected_clients:12\r\n" +
+	"maxclients:10000\r\n" +
+	"\r\n" +
+	"# Memory\r\n" +
+	"used_memory:1048576\r\n" +
+	"maxmemory_policy:allkeys-lru\r\n" +
+	"mem_fragmentation_ratio:1.25\r\n" +
+	"\r\n" +
+	"# Replication\r\n" +
+	"role:master\r\n" +
+	"connected_slaves:1\r\n" +
+	"slave0:ip=10.0.0.2,port=6380,state=online,offset=1024,lag=1\r\n" +
+	"master_repl_offset:1024\r\n" +
+	"\r\n" +
+	"# Commandstats\r\n" +
+	"cmdstat_get:calls=10,usec=50,usec_per_call=5.00,rejected_calls=0,failed_calls=1\r\n" +
+	"\r\n" +
+	"# Latencystats\r\n" +
+	"latency_percentiles_usec_get:p50=1.003,p99=5.023,p99.9=9.999\r\n" +
+	"\r\n" +
+	"# Keyspace\r\n" +
+	"db0:keys=100,expires=10,avg_ttl=3000\r\n" +
+	"db3:keys=1,expires=0,avg_ttl=0\r\n" +
+	"# Custom\r\n" +
+	"no_colon_line\r\n" +
+	"module_field:a:b\r\n"
+
+func TestParseInfo(t *testing.T) {
+	info := ParseInfo(infoText)
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{name: "server version", got: info.Server.RedisVersion, want: "7.2.4"},
+		{name: "server int", got: info.Server.ArchBits, want: 64},
+		{name: "server uptime", got: info.Server.UptimeInSeconds, want: int64(3600)},
+		{name: "clients", got: info.Clients.ConnectedClients, want: int64(12)},
+		{name: "memory", got: info.Memory.UsedMemory, want: int64(1048576)},
+		{name: "memory float", got: info.Memory.MemFragmentationRatio, want: 1.25},
+		{name: "memory policy", got: info.Memory.MaxmemoryPolicy, want: "allkeys-lru"},
+		{name: "replication role", got: info.Replication.Role, want: "master"},
+		{name: "replication slaves", got: info.Replication.Slaves, want: []protos.InfoSlave{{Ip: "10.0.0.2", Port: 6380, State: "online", Offset: 1024, Lag: 1}}},
+		{name: "commandstats", got: info.Commandstats["get"], want: protos.InfoCommand{Calls: 10, Usec: 50, UsecPerCall: 5, FailedCalls: 1}},
+		{name: "latencystats", got: info.Latencystats["get"], want: protos.InfoLatency{P50: 1.003, P99: 5.023, P999: 9.999}},
+		{name: "keyspace", got: info.Keyspace, want: map[string]protos.InfoKeyspace{
+			"db0": {Keys: 100, Expires: 10, AvgTtl: 3000},
+			"db3": {Keys: 1},
+		}},
+		{name: "raw unknown section", got: info.Raw["custom"], want: map[string]string{"module_field": "a:b"}},
+		{name: "raw known field", got: info.Raw["server"]["redis_mode"], want: "standalone"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("ParseInfo() %s = %#v, want %#v", tt.name, tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInfoEmpty(t *testing.T) {
+	info := ParseInfo("")
+	if len(info.Raw) != 0 || len(info.Keyspace) != 0 || info.Server.RedisVersion != "" {
+		t.Errorf("ParseInfo(\"\") = %+v, want empty", info)
+	}
+}
+
+func TestParseKv(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want map[string]string
+	}{
+		{name: "empty", in: "", want: map[string]string{}},
+		{name: "pairs", in: "keys=1,expires=2", want: map[string]string{"keys": "1", "expires": "2"}},
+		{name: "value with equals", in: "a=b=c", want: map[string]string{"a": "b=c"}},
+		{name: "skip invalid", in: "a=1,broken,b=2", want: map[string]string{"a": "1", "b": "2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseKv(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseKv(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package monitor
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fighthorse/redisAdmin/component/conf"
+	"github.com/fighthorse/redisAdmin/component/metrics"
+	"github.com/fighthorse/redisAdmin/component/thirdpart/trace_redis"
+	"github.com/fighthorse/redisAdmin/internal/pkg/redis"
+	"github.com/fighthorse/redisAdmin/protos"
+	"github.com/prometheus/client_golang/prometheus"
+
+	goredis "github.com/go-redis/redis"
+)
+
+var (
+	// DefaultFields 历史数据默认字段
+	DefaultFields = []string{"used_memory", "instantaneous_ops_per_sec", "connected_clients", "keyspace_hits", "keyspace_misses"}
+
+	ErrNoSample = errors.New("暂无采集数据")
+
+	mux     sync.RWMutex
+	history = 360
+	rings   = map[string]map[string]*ring{} // instance -> node -> samples
+)
+
+// ring 固定长度的采样环形缓冲
+type ring struct {
+	items []protos.InfoSample
+	next  int
+	full  bool
+}
+
+func (r *ring) add(s protos.InfoSample) {
+	if len(r.items) < cap(r.items) {
+		r.items = append(r.items, s)
+		return
+	}
+	r.items[r.next] = s
+	r.next = (r.next + 1) % len(r.items)
+	r.full = true
+}
+
+// list 按时间顺序返回
+func (r *ring) list() []protos.InfoSample {
+	out := make([]protos.InfoSample, 0, len(r.items))
+	if r.full {
+		out = append(out, r.items[r.next:]...)
+		out = append(out, r.items[:r.next]...)
+		return out
+	}
+	return append(out, r.items...)
+}
+
+func (r *ring) last() (protos.InfoSample, bool) {
+	if len(r.items) == 0 {
+		return protos.InfoSample{}, false
+	}
+	if !r.full {
+		return r.items[len(r.items)-1], true
+	}
+	return r.items[(r.next+len(r.items)-1)%len(r.items)], true
+}
+
+// Init 按配置间隔采集所有连接的 INFO
+func Init() {
+	cfg := conf.GConfig.Monitor
+	interval := time.Duration(cfg.Interval * float64(time.Second))
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	if cfg.History > 0 {
+		history = cfg.History
+	}
+	if cfg.ScrapeTimeout > 0 {
+		scrapeTimeout = time.Duration(cfg.ScrapeTimeout * float64(time.Second))
+	}
+	// inner /metrics 采集所有连接
+	if cfg.Exporter {
+		prometheus.MustRegister(NewExporter(scrapeTimeout))
+	}
+	go func() {
+		sampleAll()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			sampleAll()
+		}
+	}()
+}
+
+func sampleAll() {
+	names := trace_redis.CfgNames()
+	var wg sync.WaitGroup
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			sampleInstance(name)
+		}(name)
+	}
+	wg.Wait()
+
+	// 删除已移除连接的数据
+	exist := make(map[string]bool, len(names))
+	for _, name := range names {
+		exist[name] = true
+	}
+	mux.Lock()
+	for name := range rings {
+		if !exist[name] {
+			delete(rings, name)
+		}
+	}
+	mux.Unlock()
+	for _, name := range metrics.RedisInfoInstances() {
+		if !exist[name] {
+			metrics.DelRedisInfo(name)
+		}
+	}
+}
+
+// sampleInstance 集群时采集每个 master 节点
+func sampleInstance(name string) {
+	defer func() {
+		// 连接配置错误时 NewClient 会 panic
+		_ = recover()
+	}()
+	client := redis.LoadOthersDB(name, 0)
+	if client == nil {
+		return
+	}
+	_ = client.Client.ForEachMaster(func(node *goredis.Client) error {
+		record(name, node.Options().Addr, node.Info("all"))
+		return nil
+	})
+}
+
+func record(name, node string, cmd *goredis.StringCmd) {
+	sample := protos.InfoSample{
+		Time: time.Now().Unix(),
+		Node: node,
+	}
+	text, err := cmd.Result()
+	if err != nil {
+		sample.Error = err.Error()
+	} else {
+		sample.Info = ParseInfo(text)
+		metrics.SetRedisInfo(name, node, gauges(sample.Info))
+	}
+
+	mux.Lock()
+	defer mux.Unlock()
+	if rings[name] == nil {
+		rings[name] = map[string]*ring{}
+	}
+	r, ok := rings[name][node]
+	if !ok {
+		r = &ring{items: make([]protos.InfoSample, 0, history)}
+		rings[name][node] = r
+	}
+	r.add(sample)
+}
+
+// Latest 实例各节点最近一次采样
+func Latest(req protos.InfoReq) ([]protos.InfoSample, error) {
+	mux.RLock()
+	defer mux.RUnlock()
+	var out []protos.InfoSample
+	for node, r := range rings[req.Client] {
+		if req.Node != "" && node != req.Node {
+			continue
+		}
+		if s, ok := r.last(); ok {
+			out = append(out, s)
+		}
+	}
+	if len(out) == 0 {
+		return nil, ErrNoSample
+	}
+	sort.Slice(out, func(i, k int) bool { return out[i].Node < out[k].Node })
+	return out, nil
+}
+
+// History 实例各节点指定字段的历史数据
+func History(req protos.InfoReq) ([]protos.InfoHistory, error) {
+	fields := DefaultFields
+	if req.Fields != "" {
+		fields = strings.Split(req.Fields, ",")
+	}
+	mux.RLock()
+	defer mux.RUnlock()
+	var out []protos.InfoHistory
+	for node, r := range rings[req.Client] {
+		if req.Node != "" && node != req.Node {
+			continue
+		}
+		h := protos.InfoHistory{Node: node}
+		for _, s := range r.list() {
+			if s.Time < req.Since || s.Info == nil {
+				continue
+			}
+			h.Points = append(h.Points, protos.InfoPoint{Time: s.Time, Values: fieldValues(s.Info, fields)})
+		}
+		out = append(out, h)
+	}
+	if len(out) == 0 {
+		return nil, ErrNoSample
+	}
+	sort.Slice(out, func(i, k int) bool { return out[i].Node < out[k].Node })
+	return out, nil
+}
+
+// fieldValues 在所有 section 中查找字段
+func fieldValues(info *protos.RedisInfo, fields []string) map[string]float64 {
+	out := make(map[string]float64, len(fields))
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		for _, kv := range info.Raw {
+			if v, ok := kv[field]; ok {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					out[field] = f
+				}
+				break
+			}
+		}
+	}
+	return out
+}
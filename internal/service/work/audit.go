@@ -0,0 +1,119 @@
+package work
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/fighthorse/redisAdmin/component/conf"
+	"github.com/fighthorse/redisAdmin/component/log"
+	"github.com/fighthorse/redisAdmin/component/thirdpart/trace_redis"
+	"github.com/fighthorse/redisAdmin/internal/service/login"
+	"github.com/fighthorse/redisAdmin/protos"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// AuditMaxLimit 单次查询最多返回条数
+	AuditMaxLimit = 500
+)
+
+// keySize 修改前 key 占用内存, 不存在或不支持时为 0
+func keySize(c *gin.Context, client *trace_redis.RedisClient, key string) int64 {
+	if key == "" {
+		return 0
+	}
+	size, _ := client.MemoryUsage(c.Request.Context(), key)
+	return size
+}
+
+// Audit 记录一次数据修改
+func Audit(c *gin.Context, req protos.SearchKeyReq, oldSize int64, out interface{}, err error) {
+	fields := log.Fields{
+		"user":     currentUserName(c),
+		"ip":       c.ClientIP(),
+		"instance": req.Client,
+		"db":       req.Db,
+		"command":  req.Type,
+		"key":      req.Key,
+		"old_size": oldSize,
+		"result":   "ok",
+	}
+	if res, ok := out.(protos.KeysInfo); ok && res.Data != "" {
+		fields["result"] = res.Data
+	}
+	if err != nil {
+		fields["result"] = "error"
+		fields["error"] = err.Error()
+	}
+	log.AuditLog(c.Request.Context(), fields)
+}
+
+// QueryAudit 从审计日志文件中按条件查询, 返回最近的 limit 条
+func QueryAudit(c *gin.Context, req protos.AuditReq) ([]protos.AuditLog, error) {
+	fileName := conf.GConfig.Log.Audit.FilePath
+	if fileName == "" {
+		return nil, errors.New("未配置审计日志")
+	}
+	limit := req.Limit
+	if limit <= 0 || limit > AuditMaxLimit {
+		limit = AuditMaxLimit
+	}
+	var start, end time.Time
+	if req.Start != "" {
+		start, _ = time.ParseInLocation("2006-01-02 15:04:05", req.Start, time.Local)
+	}
+	if req.End != "" {
+		end, _ = time.ParseInLocation("2006-01-02 15:04:05", req.End, time.Local)
+	}
+
+	f, err := os.Open(fileName)
+	if os.IsNotExist(err) {
+		return []protos.AuditLog{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// 环形缓冲保留最近 limit 条, head 为最旧一条的位置
+	out := make([]protos.AuditLog, 0, limit)
+	head := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var item protos.AuditLog
+		if err := json.Unmarshal(scanner.Bytes(), &item); err != nil {
+			continue
+		}
+		if req.User != "" && item.User != req.User {
+			continue
+		}
+		if req.Client != "" && item.Instance != req.Client {
+			continue
+		}
+		if req.Key != "" && !login.MatchPattern(req.Key, item.Key) {
+			continue
+		}
+		if !start.IsZero() || !end.IsZero() {
+			t, err := time.Parse(time.RFC3339, item.Time)
+			if err != nil || (!start.IsZero() && t.Before(start)) || (!end.IsZero() && t.After(end)) {
+				continue
+			}
+		}
+		if len(out) < limit {
+			out = append(out, item)
+			continue
+		}
+		out[head] = item
+		head = (head + 1) % limit
+	}
+	// 倒序, 最新的在前
+	res := make([]protos.AuditLog, len(out))
+	for i := range out {
+		res[i] = out[(head+len(out)-1-i)%len(out)]
+	}
+	return res, scanner.Err()
+}
@@ -0,0 +1,67 @@
+package work
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fighthorse/redisAdmin/component/conf"
+	"github.com/fighthorse/redisAdmin/protos"
+)
+
+func TestQueryAudit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "audit.log")
+	var lines []string
+	for i := 1; i <= 7; i++ {
+		user := "alice"
+		if i%2 == 0 {
+			user = "bob"
+		}
+		lines = append(lines, fmt.Sprintf(`{"user":%q,"instance":"base","key":"k%d"}`, user, i))
+	}
+	lines = append(lines, "not json")
+	if err := ioutil.WriteFile(fileName, []byte(strings.Join(lines, "\n")), 0600); err != nil {
+		t.Fatal(err)
+	}
+	old := conf.GConfig.Log.Audit.FilePath
+	defer func() { conf.GConfig.Log.Audit.FilePath = old }()
+	conf.GConfig.Log.Audit.FilePath = fileName
+
+	tests := []struct {
+		name string
+		req  protos.AuditReq
+		want []string
+	}{
+		{name: "all", req: protos.AuditReq{}, want: []string{"k7", "k6", "k5", "k4", "k3", "k2", "k1"}},
+		{name: "limit", req: protos.AuditReq{Limit: 3}, want: []string{"k7", "k6", "k5"}},
+		{name: "limit one", req: protos.AuditReq{Limit: 1}, want: []string{"k7"}},
+		{name: "user filter", req: protos.AuditReq{User: "bob", Limit: 2}, want: []string{"k6", "k4"}},
+		{name: "fewer than limit", req: protos.AuditReq{User: "bob", Limit: 10}, want: []string{"k6", "k4", "k2"}},
+		{name: "key filter", req: protos.AuditReq{Key: "k1*"}, want: []string{"k1"}},
+		{name: "no match", req: protos.AuditReq{Client: "other"}, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := QueryAudit(nil, tt.req)
+			if err != nil {
+				t.Fatalf("QueryAudit() error = %v", err)
+			}
+			got := make([]string, 0, len(out))
+			for _, v := range out {
+				got = append(got, v.Key)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("QueryAudit() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
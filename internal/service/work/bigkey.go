@@ -0,0 +1,384 @@
+package work
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fighthorse/redisAdmin/internal/pkg/redis"
+	"github.com/fighthorse/redisAdmin/internal/service/login"
+	"github.com/fighthorse/redisAdmin/protos"
+	"github.com/gin-gonic/gin"
+
+	goredis "github.com/go-redis/redis"
+)
+
+var (
+	// BigKeyDefaultTop 默认每个类型/前缀保留 key 个数
+	BigKeyDefaultTop = 20
+	// BigKeyMaxTop 每个类型/前缀最多保留 key 个数
+	BigKeyMaxTop = 200
+	// BigKeyMaxPrefix 最多统计前缀个数, 超出的计入 "other"
+	BigKeyMaxPrefix = 1000
+	// BigKeyBatch 每批扫描 key 数
+	BigKeyBatch int64 = 100
+
+	bigKeyScans = struct {
+		sync.Mutex
+		items map[string]*bigKeyScan
+	}{items: map[string]*bigKeyScan{}}
+)
+
+// bigKeyScan 每个 client/db 保留最近一次扫描结果
+type bigKeyScan struct {
+	mux    sync.Mutex
+	info   protos.BigKeyInfo
+	cancel context.CancelFunc
+	top    int
+	types  map[string]*bigKeyStat
+	prefix map[string]*bigKeyStat
+	hot    *bigKeyHeap
+}
+
+type bigKeyStat struct {
+	protos.BigKeyStat
+	heap *bigKeyHeap
+}
+
+func bigKeyName(client, db string) string {
+	if db == "" {
+		db = "0"
+	}
+	return client + "/" + db
+}
+
+// ScanBigKey 后台扫描 key 大小, 同一 client/db 同时只能有一个扫描
+func ScanBigKey(c *gin.Context, req protos.BigKeyReq) (interface{}, error) {
+	if req.Pattern == "" {
+		req.Pattern = "*"
+	}
+	top := req.Top
+	if top <= 0 {
+		top = BigKeyDefaultTop
+	}
+	if top > BigKeyMaxTop {
+		top = BigKeyMaxTop
+	}
+	level := req.Level
+	if level <= 0 {
+		level = 1
+	} else {
+		level = level + 1
+	}
+	db, _ := strconv.Atoi(req.Db)
+	client := redis.LoadOthersDB(req.Client, db)
+	if client == nil {
+		return nil, errors.New("redis client create error")
+	}
+
+	name := bigKeyName(req.Client, req.Db)
+	bigKeyScans.Lock()
+	if old, ok := bigKeyScans.items[name]; ok && old.snapshot().Status == JobRunning {
+		bigKeyScans.Unlock()
+		return nil, errors.New("扫描进行中")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &bigKeyScan{
+		info: protos.BigKeyInfo{
+			Client:    req.Client,
+			Db:        req.Db,
+			Pattern:   req.Pattern,
+			User:      currentUserName(c),
+			Status:    JobRunning,
+			CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
+		},
+		cancel: cancel,
+		top:    top,
+		types:  map[string]*bigKeyStat{},
+		prefix: map[string]*bigKeyStat{},
+		hot:    newBigKeyHeap(func(v protos.BigKey) int64 { return v.Freq }),
+	}
+	bigKeyScans.items[name] = s
+	bigKeyScans.Unlock()
+
+	go func() {
+		defer cancel()
+		start := time.Now()
+		var mu sync.Mutex
+		var done int64
+		err := client.Client.ForEachMaster(func(node *goredis.Client) error {
+			lfu := req.Hot && isLfu(node)
+			if lfu {
+				s.mux.Lock()
+				s.info.Lfu = true
+				s.mux.Unlock()
+			}
+			return scanEach(node, req.Pattern, BigKeyBatch, func(keys []string) error {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				items, err := sampleKeys(node, keys, lfu)
+				if err != nil {
+					return err
+				}
+				s.add(items, level)
+
+				if req.Rate <= 0 {
+					return nil
+				}
+				mu.Lock()
+				done += int64(len(keys))
+				wait := time.Duration(done)*time.Second/time.Duration(req.Rate) - time.Since(start)
+				mu.Unlock()
+				if wait > 0 {
+					select {
+					case <-ctx.Done():
+						return ctx.Err()
+					case <-time.After(wait):
+					}
+				}
+				return nil
+			})
+		})
+		s.finish(err)
+	}()
+	return s.snapshot(), nil
+}
+
+// isLfu maxmemory-policy 为 LFU 时 OBJECT FREQ 才可用
+func isLfu(node *goredis.Client) bool {
+	res, err := node.ConfigGet("maxmemory-policy").Result()
+	if err != nil || len(res) < 2 {
+		return false
+	}
+	policy, _ := res[1].(string)
+	return strings.Contains(policy, "lfu")
+}
+
+// sampleKeys 两次 pipeline: TYPE + MEMORY USAGE, 然后按类型取长度和 OBJECT FREQ
+func sampleKeys(node *goredis.Client, keys []string, lfu bool) ([]protos.BigKey, error) {
+	pipe := node.Pipeline()
+	defer pipe.Close()
+	typeCmds := make([]*goredis.StatusCmd, len(keys))
+	sizeCmds := make([]*goredis.IntCmd, len(keys))
+	for k, v := range keys {
+		typeCmds[k] = pipe.Type(v)
+		sizeCmds[k] = pipe.MemoryUsage(v)
+	}
+	if _, err := pipe.Exec(); err != nil && err != goredis.Nil {
+		return nil, err
+	}
+
+	lenCmds := make([]*goredis.IntCmd, len(keys))
+	freqCmds := make([]*goredis.Cmd, len(keys))
+	for k, v := range keys {
+		switch typeCmds[k].Val() {
+		case "string":
+			lenCmds[k] = pipe.StrLen(v)
+		case "hash":
+			lenCmds[k] = pipe.HLen(v)
+		case "list":
+			lenCmds[k] = pipe.LLen(v)
+		case "set":
+			lenCmds[k] = pipe.SCard(v)
+		case "zset":
+			lenCmds[k] = pipe.ZCard(v)
+		case "stream":
+			lenCmds[k] = pipe.XLen(v)
+		}
+		if lfu {
+			freqCmds[k] = pipe.Do("object", "freq", v)
+		}
+	}
+	if _, err := pipe.Exec(); err != nil && err != goredis.Nil {
+		return nil, err
+	}
+
+	out := make([]protos.BigKey, 0, len(keys))
+	for k, v := range keys {
+		t := typeCmds[k].Val()
+		// 扫描过程中已删除
+		if t == "" || t == "none" {
+			continue
+		}
+		item := protos.BigKey{Key: v, Type: t, Size: sizeCmds[k].Val()}
+		if lenCmds[k] != nil {
+			item.Elements = lenCmds[k].Val()
+		}
+		if freqCmds[k] != nil {
+			item.Freq, _ = freqCmds[k].Int64()
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+func (s *bigKeyScan) add(items []protos.BigKey, level int) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	for _, v := range items {
+		s.info.Scanned++
+		s.info.Size += v.Size
+		s.stat(s.types, v.Type, v)
+		prefix := keyPrefix(v.Key, level+1)
+		if _, ok := s.prefix[prefix]; !ok && len(s.prefix) >= BigKeyMaxPrefix {
+			prefix = "other"
+		}
+		s.stat(s.prefix, prefix, v)
+		if v.Freq > 0 {
+			s.hot.pushTop(v, s.top)
+		}
+	}
+}
+
+func (s *bigKeyScan) stat(m map[string]*bigKeyStat, name string, v protos.BigKey) {
+	st, ok := m[name]
+	if !ok {
+		st = &bigKeyStat{
+			BigKeyStat: protos.BigKeyStat{Name: name},
+			heap:       newBigKeyHeap(func(v protos.BigKey) int64 { return v.Size }),
+		}
+		m[name] = st
+	}
+	st.Keys++
+	st.Size += v.Size
+	st.Elements += v.Elements
+	st.heap.pushTop(v, s.top)
+}
+
+func (s *bigKeyScan) finish(err error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.info.FinishedAt = time.Now().Format("2006-01-02 15:04:05")
+	switch {
+	case err == nil:
+		s.info.Status = JobDone
+	case err == context.Canceled:
+		s.info.Status = JobCanceled
+	default:
+		s.info.Status = JobFailed
+		s.info.Error = err.Error()
+	}
+}
+
+// snapshot 当前进度及结果, 结果按大小倒序
+func (s *bigKeyScan) snapshot() protos.BigKeyInfo {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	info := s.info
+	info.Types = sortBigKeyStats(s.types)
+	info.Prefix = sortBigKeyStats(s.prefix)
+	info.TopByType = make(map[string][]protos.BigKey, len(info.Types))
+	for _, v := range info.Types {
+		info.TopByType[v.Name] = v.Top
+	}
+	if s.hot != nil {
+		info.HotKeys = s.hot.sorted()
+	}
+	return info
+}
+
+func sortBigKeyStats(m map[string]*bigKeyStat) []protos.BigKeyStat {
+	out := make([]protos.BigKeyStat, 0, len(m))
+	for _, v := range m {
+		st := v.BigKeyStat
+		st.Top = v.heap.sorted()
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, k int) bool { return out[i].Size > out[k].Size })
+	return out
+}
+
+// BigKeyResult 最近一次扫描结果, 过滤当前用户无权查看的 key
+func BigKeyResult(c *gin.Context, req protos.BigKeyReq) (interface{}, error) {
+	bigKeyScans.Lock()
+	s, ok := bigKeyScans.items[bigKeyName(req.Client, req.Db)]
+	bigKeyScans.Unlock()
+	if !ok {
+		return nil, errors.New("没有扫描结果")
+	}
+	info := s.snapshot()
+
+	user := currentUserName(c)
+	allow := func(list []protos.BigKey) []protos.BigKey {
+		out := make([]protos.BigKey, 0, len(list))
+		for _, v := range list {
+			if login.CheckAccess(user, info.Client, info.Db, v.Key, false) == nil {
+				out = append(out, v)
+			}
+		}
+		return out
+	}
+	for k, v := range info.TopByType {
+		info.TopByType[k] = allow(v)
+	}
+	for k := range info.Types {
+		info.Types[k].Top = info.TopByType[info.Types[k].Name]
+	}
+	prefix := make([]protos.BigKeyStat, 0, len(info.Prefix))
+	for _, v := range info.Prefix {
+		if v.Name != "other" && login.CheckAccess(user, info.Client, info.Db, v.Name, false) != nil {
+			continue
+		}
+		v.Top = allow(v.Top)
+		prefix = append(prefix, v)
+	}
+	info.Prefix = prefix
+	info.HotKeys = allow(info.HotKeys)
+	return info, nil
+}
+
+// CancelBigKey 取消进行中的扫描
+func CancelBigKey(c *gin.Context, req protos.BigKeyReq) (interface{}, error) {
+	bigKeyScans.Lock()
+	s, ok := bigKeyScans.items[bigKeyName(req.Client, req.Db)]
+	bigKeyScans.Unlock()
+	if !ok || s.snapshot().Status != JobRunning {
+		return nil, errors.New("没有进行中的扫描")
+	}
+	s.cancel()
+	return nil, nil
+}
+
+// bigKeyHeap 按 value 的最小堆, 保留 top N
+type bigKeyHeap struct {
+	list  []protos.BigKey
+	value func(protos.BigKey) int64
+}
+
+func newBigKeyHeap(value func(protos.BigKey) int64) *bigKeyHeap {
+	return &bigKeyHeap{value: value}
+}
+
+func (h *bigKeyHeap) Len() int           { return len(h.list) }
+func (h *bigKeyHeap) Less(i, k int) bool { return h.value(h.list[i]) < h.value(h.list[k]) }
+func (h *bigKeyHeap) Swap(i, k int)      { h.list[i], h.list[k] = h.list[k], h.list[i] }
+func (h *bigKeyHeap) Push(x interface{}) { h.list = append(h.list, x.(protos.BigKey)) }
+func (h *bigKeyHeap) Pop() interface{} {
+	x := h.list[len(h.list)-1]
+	h.list = h.list[:len(h.list)-1]
+	return x
+}
+
+func (h *bigKeyHeap) pushTop(v protos.BigKey, top int) {
+	if h.Len() >= top && h.value(v) <= h.value(h.list[0]) {
+		return
+	}
+	heap.Push(h, v)
+	if h.Len() > top {
+		heap.Pop(h)
+	}
+}
+
+// sorted 倒序返回, 不改变堆
+func (h *bigKeyHeap) sorted() []protos.BigKey {
+	out := make([]protos.BigKey, len(h.list))
+	copy(out, h.list)
+	sort.Slice(out, func(i, k int) bool { return h.value(out[i]) > h.value(out[k]) })
+	return out
+}
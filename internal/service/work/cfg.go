@@ -1,28 +1,167 @@
 package work
 
 import (
+	"errors"
+	"strings"
+
 	"github.com/fighthorse/redisAdmin/component/conf"
+	"github.com/fighthorse/redisAdmin/component/profile"
 	"github.com/fighthorse/redisAdmin/component/thirdpart/trace_redis"
 	"github.com/fighthorse/redisAdmin/internal/pkg/redis"
+	"github.com/fighthorse/redisAdmin/internal/service/login"
 	"github.com/fighthorse/redisAdmin/protos"
 	"github.com/gin-gonic/gin"
 )
 
-func AddRedisCfg(c *gin.Context, data protos.AddCfgReq) (interface{}, error) {
+func toRedisCfg(data protos.AddCfgReq) conf.Redis {
 	v := conf.Redis{
-		Name: data.Name,
-		Addr: data.Addr,
-		Pwd:  data.Pwd,
+		Name:        data.Name,
+		Mode:        data.Mode,
+		Addr:        data.Addr,
+		Pwd:         data.Pwd,
+		Db:          float64(data.Db),
+		MasterName:  data.MasterName,
+		SentinelPwd: data.SentinelPwd,
+	}
+	for _, addr := range strings.Split(data.SentinelAddrs, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			v.SentinelAddrs = append(v.SentinelAddrs, addr)
+		}
+	}
+	return v
+}
+
+// checkRedisCfg sentinel 模式必须指定 master 名称和 sentinel 地址
+func checkRedisCfg(v conf.Redis) error {
+	switch v.Mode {
+	case "", trace_redis.ModeSingle, trace_redis.ModeCluster:
+		if v.Addr == "" {
+			return errors.New("addr 不能为空")
+		}
+	case trace_redis.ModeSentinel:
+		if v.MasterName == "" || len(v.SentinelAddrs) == 0 {
+			return errors.New("sentinel 模式需要 master_name 和 sentinel_addrs")
+		}
+	default:
+		return errors.New("mode 只支持 single, cluster, sentinel")
+	}
+	return nil
+}
+
+func AddRedisCfg(c *gin.Context, data protos.AddCfgReq) (interface{}, error) {
+	if trace_redis.HasCfg(data.Name) {
+		return nil, errors.New("连接名称已存在")
+	}
+	v := toRedisCfg(data)
+	if err := checkRedisCfg(v); err != nil {
+		return nil, err
+	}
+	if err := profile.Save(v, currentUserName(c)); err != nil {
+		return nil, err
 	}
 	trace_redis.AddCfg(v)
 
 	redis.LoadOthersNew(data.Name)
 
-	d := trace_redis.ListCfg()
+	d := visibleCfg(c)
+	return d, nil
+}
+
+// UpdateRedisCfg 修改页面添加的连接, 配置文件中的连接不能修改
+func UpdateRedisCfg(c *gin.Context, data protos.AddCfgReq) (interface{}, error) {
+	old, err := profile.Get(data.Name)
+	if err != nil {
+		return nil, errors.New("只能修改页面添加的连接")
+	}
+	v := toRedisCfg(data)
+	// 密码为空时保留原密码
+	if v.Pwd == "" {
+		v.Pwd = old.Pwd
+	}
+	if v.SentinelPwd == "" {
+		v.SentinelPwd = old.SentinelPwd
+	}
+	if err := checkRedisCfg(v); err != nil {
+		return nil, err
+	}
+	if err := profile.Save(v, currentUserName(c)); err != nil {
+		return nil, err
+	}
+	trace_redis.AddCfg(v)
+	redis.Remove(data.Name)
+
+	d := visibleCfg(c)
 	return d, nil
 }
 
+// DelRedisCfg 删除页面添加的连接
+func DelRedisCfg(c *gin.Context, data protos.AddCfgReq) (interface{}, error) {
+	if !profile.Has(data.Name) {
+		return nil, errors.New("只能删除页面添加的连接")
+	}
+	if err := profile.Del(data.Name); err != nil {
+		return nil, err
+	}
+	trace_redis.DelCfg(data.Name)
+	redis.Remove(data.Name)
+
+	d := visibleCfg(c)
+	return d, nil
+}
+
+// TestRedisCfg 测试连接是否可用
+func TestRedisCfg(c *gin.Context, data protos.AddCfgReq) (interface{}, error) {
+	v := toRedisCfg(data)
+	if err := checkRedisCfg(v); err != nil {
+		return nil, err
+	}
+	if err := trace_redis.TestCfg(v); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"ping": "PONG"}, nil
+}
+
 func ListRedisCfg(c *gin.Context) (map[string]interface{}, error) {
+	return visibleCfg(c), nil
+}
+
+// visibleCfg 当前登录用户有权限的连接
+func visibleCfg(c *gin.Context) map[string]interface{} {
+	name := currentUserName(c)
 	d := trace_redis.ListCfg()
+	for k := range d {
+		if login.CheckInstance(name, k) != nil {
+			delete(d, k)
+		}
+	}
+	return d
+}
+
+// RedisInfo 连接列表, sentinel 模式附带当前解析到的 master/replicas
+func RedisInfo(c *gin.Context) (map[string]interface{}, error) {
+	d := visibleCfg(c)
+	for name, v := range d {
+		info, ok := v.(map[string]interface{})
+		if !ok || info["mode"] != trace_redis.ModeSentinel {
+			continue
+		}
+		client := redis.LoadOthersDB(name, 0)
+		if client == nil {
+			continue
+		}
+		nodes, err := client.Client.Sentinel()
+		if err != nil {
+			info["sentinel"] = err.Error()
+			continue
+		}
+		info["sentinel"] = nodes
+	}
 	return d, nil
 }
+
+func currentUserName(c *gin.Context) string {
+	if person := CurrentUser(c); person != nil {
+		return person.Name
+	}
+	return ""
+}
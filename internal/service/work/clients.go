@@ -0,0 +1,243 @@
+package work
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fighthorse/redisAdmin/internal/pkg/redis"
+	"github.com/fighthorse/redisAdmin/protos"
+	"github.com/gin-gonic/gin"
+
+	goredis "github.com/go-redis/redis"
+)
+
+var (
+	// ClientDefaultLimit 默认返回的连接数
+	ClientDefaultLimit = 500
+)
+
+// ClientList CLIENT LIST 解析后按条件过滤/排序/分组
+func ClientList(c *gin.Context, req protos.ClientListReq) (*protos.ClientListRes, error) {
+	client := redis.LoadOthersDB(req.Client, 0)
+	if client == nil {
+		return nil, errors.New("redis client create error")
+	}
+	res := &protos.ClientListRes{}
+	var mu sync.Mutex
+	var all []protos.ClientInfo
+	_ = client.Client.ForEachMaster(func(node *goredis.Client) error {
+		addr := node.Options().Addr
+		text, err := node.ClientList().Result()
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			res.Errors = append(res.Errors, addr+": "+err.Error())
+			return nil
+		}
+		all = append(all, parseClientList(addr, text)...)
+		return nil
+	})
+
+	var list []protos.ClientInfo
+	for _, v := range all {
+		if req.Filter != "" && !strings.Contains(v.Addr, req.Filter) && !strings.Contains(v.Name, req.Filter) &&
+			!strings.Contains(v.User, req.Filter) && !strings.Contains(v.Cmd, req.Filter) {
+			continue
+		}
+		if !hasFlags(v.Flags, req.Flags) {
+			continue
+		}
+		list = append(list, v)
+	}
+	sortClients(list, req.Sort, req.Asc)
+	res.Total = len(list)
+	res.Groups = groupClients(list, req.Group)
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = ClientDefaultLimit
+	}
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	res.Clients = list
+	return res, nil
+}
+
+// parseClientList 每行为 k=v 以空格分隔
+func parseClientList(node, text string) []protos.ClientInfo {
+	var out []protos.ClientInfo
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		kv := map[string]string{}
+		for _, field := range strings.Fields(line) {
+			ll := strings.SplitN(field, "=", 2)
+			if len(ll) == 2 {
+				kv[ll[0]] = ll[1]
+			}
+		}
+		v := protos.ClientInfo{
+			Node:  node,
+			Addr:  kv["addr"],
+			Name:  kv["name"],
+			Cmd:   kv["cmd"],
+			Flags: kv["flags"],
+			User:  kv["user"],
+		}
+		v.Id, _ = strconv.ParseInt(kv["id"], 10, 64)
+		v.Age, _ = strconv.ParseInt(kv["age"], 10, 64)
+		v.Idle, _ = strconv.ParseInt(kv["idle"], 10, 64)
+		v.Db, _ = strconv.Atoi(kv["db"])
+		if mem, ok := kv["tot-mem"]; ok {
+			v.Memory, _ = strconv.ParseInt(mem, 10, 64)
+		} else {
+			qbuf, _ := strconv.ParseInt(kv["qbuf"], 10, 64)
+			omem, _ := strconv.ParseInt(kv["omem"], 10, 64)
+			v.Memory = qbuf + omem
+		}
+		v.Ip = v.Addr
+		if host, _, err := net.SplitHostPort(v.Addr); err == nil {
+			v.Ip = host
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+func hasFlags(flags, want string) bool {
+	for _, f := range want {
+		if !strings.ContainsRune(flags, f) {
+			return false
+		}
+	}
+	return true
+}
+
+func sortClients(list []protos.ClientInfo, field string, asc bool) {
+	value := func(v protos.ClientInfo) int64 {
+		switch field {
+		case "age":
+			return v.Age
+		case "memory":
+			return v.Memory
+		case "db":
+			return int64(v.Db)
+		case "id":
+			return v.Id
+		}
+		return v.Idle
+	}
+	sort.SliceStable(list, func(i, k int) bool {
+		if asc {
+			return value(list[i]) < value(list[k])
+		}
+		return value(list[i]) > value(list[k])
+	})
+}
+
+// groupClients 按连接数倒序
+func groupClients(list []protos.ClientInfo, field string) []protos.ClientGroup {
+	if field == "" {
+		return nil
+	}
+	groups := map[string]*protos.ClientGroup{}
+	for _, v := range list {
+		var name string
+		switch field {
+		case "name":
+			name = v.Name
+		case "user":
+			name = v.User
+		case "cmd":
+			name = v.Cmd
+		default:
+			name = v.Ip
+		}
+		g, ok := groups[name]
+		if !ok {
+			g = &protos.ClientGroup{Name: name}
+			groups[name] = g
+		}
+		g.Count++
+		g.Memory += v.Memory
+		if v.Age > g.MaxAge {
+			g.MaxAge = v.Age
+		}
+		if v.Idle > g.MaxIdle {
+			g.MaxIdle = v.Idle
+		}
+	}
+	out := make([]protos.ClientGroup, 0, len(groups))
+	for _, g := range groups {
+		out = append(out, *g)
+	}
+	sort.Slice(out, func(i, k int) bool { return out[i].Count > out[k].Count })
+	return out
+}
+
+// ClientKill 按 id/addr/user 断开连接, 返回断开的连接数
+func ClientKill(c *gin.Context, req protos.ClientKillReq) (interface{}, error) {
+	var args []interface{}
+	switch {
+	case req.Id > 0:
+		args = []interface{}{"client", "kill", "id", req.Id}
+	case req.Addr != "":
+		args = []interface{}{"client", "kill", "addr", req.Addr}
+	case req.User != "":
+		args = []interface{}{"client", "kill", "user", req.User}
+	default:
+		return nil, errors.New("id/addr/user 不能同时为空")
+	}
+	// id 和 addr 只在单个节点内唯一, 只有 user 在所有 master 执行
+	if (req.Id > 0 || req.Addr != "") && req.Node == "" {
+		return nil, errors.New("按 id/addr 断开连接时 node 不能为空")
+	}
+	client := redis.LoadOthersDB(req.Client, 0)
+	if client == nil {
+		return nil, errors.New("redis client create error")
+	}
+
+	var mu sync.Mutex
+	var killed int64
+	found := false
+	err := client.Client.ForEachMaster(func(node *goredis.Client) error {
+		if req.Node != "" && node.Options().Addr != req.Node {
+			return nil
+		}
+		mu.Lock()
+		found = true
+		mu.Unlock()
+		n, err := node.Do(args...).Int64()
+		// 连接不在该节点上
+		if err != nil && strings.Contains(err.Error(), "No such client") {
+			return nil
+		}
+		mu.Lock()
+		killed += n
+		mu.Unlock()
+		return err
+	})
+	if err == nil && !found {
+		return nil, fmt.Errorf("节点 %s 不存在", req.Node)
+	}
+
+	handle := protos.SearchKeyReq{
+		Client: req.Client,
+		Type:   "CLIENT_KILL",
+		Key:    fmt.Sprintf("%v", args[2:]),
+	}
+	out := protos.KeysInfo{Data: fmt.Sprintf("断开连接:%d个", killed)}
+	Audit(c, handle, 0, out, err)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,65 @@
+package work
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fighthorse/redisAdmin/protos"
+)
+
+func TestParseClientList(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []protos.ClientInfo
+	}{
+		{name: "empty", text: "", want: nil},
+		{
+			name: "redis 7",
+			text: "id=3 addr=127.0.0.1:52555 laddr=127.0.0.1:6379 fd=8 name=app age=120 idle=5 flags=N db=2 sub=0 psub=0 qbuf=26 omem=0 tot-mem=22298 cmd=client|list user=default\n",
+			want: []protos.ClientInfo{{Node: "n1", Id: 3, Addr: "127.0.0.1:52555", Ip: "127.0.0.1", Name: "app", Age: 120, Idle: 5, Db: 2, Cmd: "client|list", Flags: "N", User: "default", Memory: 22298}},
+		},
+		{
+			name: "redis 3 without tot-mem",
+			text: "id=1 addr=10.0.0.1:4000 fd=5 name= age=10 idle=10 flags=S db=0 qbuf=100 omem=50 cmd=replconf\r\n",
+			want: []protos.ClientInfo{{Node: "n1", Id: 1, Addr: "10.0.0.1:4000", Ip: "10.0.0.1", Age: 10, Idle: 10, Cmd: "replconf", Flags: "S", Memory: 150}},
+		},
+		{
+			name: "ipv6 and unix socket",
+			text: "id=4 addr=[::1]:6000 flags=N\nid=5 addr=/tmp/redis.sock:0 flags=U\n\n",
+			want: []protos.ClientInfo{
+				{Node: "n1", Id: 4, Addr: "[::1]:6000", Ip: "::1", Flags: "N"},
+				{Node: "n1", Id: 5, Addr: "/tmp/redis.sock:0", Ip: "/tmp/redis.sock", Flags: "U"},
+			},
+		},
+		{
+			name: "invalid fields ignored",
+			text: "id=x broken addr=1.2.3.4:5 age=-\n",
+			want: []protos.ClientInfo{{Node: "n1", Addr: "1.2.3.4:5", Ip: "1.2.3.4"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseClientList("n1", tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseClientList() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasFlags(t *testing.T) {
+	tests := []struct {
+		flags, want string
+		ok          bool
+	}{
+		{flags: "N", want: "", ok: true},
+		{flags: "SM", want: "S", ok: true},
+		{flags: "SM", want: "MS", ok: true},
+		{flags: "N", want: "P", ok: false},
+	}
+	for _, tt := range tests {
+		if got := hasFlags(tt.flags, tt.want); got != tt.ok {
+			t.Errorf("hasFlags(%q, %q) = %v, want %v", tt.flags, tt.want, got, tt.ok)
+		}
+	}
+}
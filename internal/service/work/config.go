@@ -0,0 +1,429 @@
+package work
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fighthorse/redisAdmin/component/conf"
+	"github.com/fighthorse/redisAdmin/component/log"
+	"github.com/fighthorse/redisAdmin/component/thirdpart/trace_redis"
+	"github.com/fighthorse/redisAdmin/internal/pkg/redis"
+	"github.com/fighthorse/redisAdmin/protos"
+	"github.com/gin-gonic/gin"
+
+	goredis "github.com/go-redis/redis"
+)
+
+// 密码类参数不返回明文, 也不参与对比
+var secretParams = map[string]bool{
+	"requirepass":              true,
+	"masterauth":               true,
+	"masteruser":               true,
+	"tls-key-file-pass":        true,
+	"tls-client-key-file-pass": true,
+}
+
+const secretMask = "******"
+
+// configGroups 按前缀分组, 先匹配的优先
+var configGroups = []struct {
+	name     string
+	prefixes []string
+}{
+	{"memory", []string{"maxmemory", "lfu-", "lru-", "active-defrag", "activedefrag", "lazyfree-", "oom-"}},
+	{"persistence", []string{"save", "appendonly", "appendfsync", "appendfilename", "appenddirname", "aof-", "auto-aof-", "rdb", "dbfilename", "dir", "stop-writes-on-bgsave-error", "no-appendfsync-on-rewrite"}},
+	{"replication", []string{"repl-", "replica", "slave", "min-replicas", "min-slaves", "masterauth", "masteruser", "masterhost", "masterport"}},
+	{"network", []string{"bind", "port", "tcp-", "timeout", "unixsocket", "protected-mode", "maxclients", "tls-", "client-output-buffer-limit", "client-query-buffer-limit", "proto-max-bulk-len", "io-threads"}},
+	{"security", []string{"requirepass", "acl", "enable-"}},
+	{"cluster", []string{"cluster-"}},
+	{"scripting", []string{"lua-", "busy-reply-threshold"}},
+	{"slowlog", []string{"slowlog-", "latency-"}},
+	{"encoding", []string{"hash-max-", "list-", "set-max-", "zset-max-", "stream-node-", "hll-"}},
+	{"notification", []string{"notify-keyspace-events"}},
+}
+
+func configGroup(name string) string {
+	for _, g := range configGroups {
+		for _, p := range g.prefixes {
+			if strings.HasPrefix(name, p) {
+				return g.name
+			}
+		}
+	}
+	return "other"
+}
+
+// baselineStore 保存的配置基线, 未配置 file_path 时只保存在内存
+type baselineStore struct {
+	once sync.Once
+	mux  sync.RWMutex
+	data map[string]*protos.ConfigBaseline
+}
+
+var baselines = &baselineStore{}
+
+func (s *baselineStore) load() {
+	s.once.Do(func() {
+		s.data = map[string]*protos.ConfigBaseline{}
+		fileName := conf.GConfig.Baseline.FilePath
+		if fileName == "" {
+			return
+		}
+		b, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			return
+		}
+		var list []*protos.ConfigBaseline
+		if err := json.Unmarshal(b, &list); err != nil {
+			log.Error(context.Background(), "parse config baseline error", log.Fields{"error": err.Error()})
+			return
+		}
+		for _, v := range list {
+			s.data[v.Name] = v
+		}
+	})
+}
+
+// flush 需持有写锁
+func (s *baselineStore) flush() error {
+	fileName := conf.GConfig.Baseline.FilePath
+	if fileName == "" {
+		return nil
+	}
+	list := make([]*protos.ConfigBaseline, 0, len(s.data))
+	for _, v := range s.data {
+		list = append(list, v)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	b, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(path.Dir(fileName), 0755); err != nil {
+		return err
+	}
+	tmp := fileName + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, fileName)
+}
+
+func (s *baselineStore) get(name string) (*protos.ConfigBaseline, bool) {
+	s.load()
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	v, ok := s.data[name]
+	return v, ok
+}
+
+// configNodes 指定 node 时只返回该节点, 否则返回所有 master 并按地址排序
+func configNodes(client *trace_redis.RedisClient, node string) []*goredis.Client {
+	var mu sync.Mutex
+	var nodes []*goredis.Client
+	_ = client.ForEachMaster(func(n *goredis.Client) error {
+		if node != "" && n.Options().Addr != node {
+			return nil
+		}
+		mu.Lock()
+		nodes = append(nodes, n)
+		mu.Unlock()
+		return nil
+	})
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Options().Addr < nodes[j].Options().Addr })
+	return nodes
+}
+
+// loadConfig CONFIG GET * 转为 map, 密码类参数已屏蔽
+func loadConfig(instance, node string) (map[string]string, string, error) {
+	client := redis.LoadOthersDB(instance, 0)
+	if client == nil {
+		return nil, "", errors.New("redis client create error")
+	}
+	nodes := configNodes(client.Client, node)
+	if len(nodes) == 0 {
+		return nil, "", fmt.Errorf("节点 %s 不存在", node)
+	}
+	res, err := nodes[0].ConfigGet("*").Result()
+	if err != nil {
+		return nil, "", err
+	}
+	params := make(map[string]string, len(res)/2)
+	for i := 0; i+1 < len(res); i += 2 {
+		name := toString(res[i])
+		value := toString(res[i+1])
+		if secretParams[name] && value != "" {
+			value = secretMask
+		}
+		params[name] = value
+	}
+	return params, nodes[0].Options().Addr, nil
+}
+
+// ConfigList 分组返回配置, 可与基线或其他实例对比
+func ConfigList(c *gin.Context, req protos.ConfigReq) (*protos.ConfigRes, error) {
+	params, node, err := loadConfig(req.Client, req.Node)
+	if err != nil {
+		return nil, err
+	}
+	res := &protos.ConfigRes{Node: node}
+
+	var compare map[string]string
+	switch {
+	case req.Baseline != "":
+		b, ok := baselines.get(req.Baseline)
+		if !ok {
+			return nil, fmt.Errorf("基线 %s 不存在", req.Baseline)
+		}
+		if err := CheckRead(c, b.Client); err != nil {
+			return nil, err
+		}
+		// 旧版本保存的基线可能包含未屏蔽的参数
+		compare = make(map[string]string, len(b.Params))
+		for name, value := range b.Params {
+			if secretParams[name] && value != "" {
+				value = secretMask
+			}
+			compare[name] = value
+		}
+	case req.Compare != "":
+		if err := CheckRead(c, req.Compare); err != nil {
+			return nil, err
+		}
+		compare, _, err = loadConfig(req.Compare, "")
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", req.Compare, err)
+		}
+	}
+
+	names := make([]string, 0, len(params))
+	for name := range params {
+		if req.Filter != "" && !strings.Contains(name, req.Filter) {
+			continue
+		}
+		names = append(names, name)
+	}
+	// 对比方有而当前实例没有的参数
+	for name := range compare {
+		if _, ok := params[name]; !ok && (req.Filter == "" || strings.Contains(name, req.Filter)) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	groups := map[string]*protos.ConfigGroup{}
+	var order []string
+	for _, name := range names {
+		p := protos.ConfigParam{Name: name, Value: params[name]}
+		if compare != nil {
+			cv, ok := compare[name]
+			p.Compare = cv
+			p.Diff = !secretParams[name] && (!ok || cv != p.Value)
+			if _, has := params[name]; !has {
+				p.Diff = true
+			}
+			if p.Diff {
+				res.Diffs++
+			}
+		}
+		if req.OnlyDiff && !p.Diff {
+			continue
+		}
+		g := configGroup(name)
+		if groups[g] == nil {
+			groups[g] = &protos.ConfigGroup{Name: g}
+			order = append(order, g)
+		}
+		groups[g].Params = append(groups[g].Params, p)
+	}
+	sort.Strings(order)
+	for _, g := range order {
+		res.Groups = append(res.Groups, *groups[g])
+	}
+	return res, nil
+}
+
+// ConfigSet 在指定节点或所有 master 上执行 CONFIG SET
+func ConfigSet(c *gin.Context, req protos.ConfigSetReq) (interface{}, error) {
+	if req.Name == "" {
+		return nil, errors.New("参数名不能为空")
+	}
+	client := redis.LoadOthersDB(req.Client, 0)
+	if client == nil {
+		return nil, errors.New("redis client create error")
+	}
+	nodes := configNodes(client.Client, req.Node)
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("节点 %s 不存在", req.Node)
+	}
+	var done []string
+	var err error
+	for _, node := range nodes {
+		addr := node.Options().Addr
+		old := ""
+		if res, e := node.ConfigGet(req.Name).Result(); e == nil && len(res) >= 2 {
+			old = toString(res[1])
+		}
+		err = node.ConfigSet(req.Name, req.Value).Err()
+		fields := log.Fields{
+			"user":     currentUserName(c),
+			"instance": req.Client,
+			"node":     addr,
+			"param":    req.Name,
+			"old":      old,
+			"new":      req.Value,
+		}
+		if secretParams[req.Name] {
+			fields["old"], fields["new"] = secretMask, secretMask
+		}
+		if err != nil {
+			fields["error"] = err.Error()
+			log.Warn(c.Request.Context(), "config set failed", fields)
+			break
+		}
+		log.Info(c.Request.Context(), "config set", fields)
+		done = append(done, addr)
+	}
+	if strings.Contains(req.Name, "notify-keyspace-events") {
+		notifyMux.Lock()
+		delete(notifyCache, req.Client)
+		notifyMux.Unlock()
+	}
+
+	handle := protos.SearchKeyReq{
+		Client: req.Client,
+		Type:   "CONFIG_SET",
+		Key:    req.Name,
+	}
+	out := protos.KeysInfo{Data: fmt.Sprintf("已修改节点:%s", strings.Join(done, ","))}
+	Audit(c, handle, 0, out, err)
+	if err != nil {
+		return done, fmt.Errorf("已修改 %d 个节点, %s", len(done), err)
+	}
+	return done, nil
+}
+
+// ConfigRewrite 将当前配置写回配置文件
+func ConfigRewrite(c *gin.Context, req protos.ConfigReq) (interface{}, error) {
+	client := redis.LoadOthersDB(req.Client, 0)
+	if client == nil {
+		return nil, errors.New("redis client create error")
+	}
+	nodes := configNodes(client.Client, req.Node)
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("节点 %s 不存在", req.Node)
+	}
+	var done []string
+	var err error
+	for _, node := range nodes {
+		addr := node.Options().Addr
+		err = node.ConfigRewrite().Err()
+		fields := log.Fields{
+			"user":     currentUserName(c),
+			"instance": req.Client,
+			"node":     addr,
+		}
+		if err != nil {
+			fields["error"] = err.Error()
+			log.Warn(c.Request.Context(), "config rewrite failed", fields)
+			break
+		}
+		log.Info(c.Request.Context(), "config rewrite", fields)
+		done = append(done, addr)
+	}
+
+	handle := protos.SearchKeyReq{
+		Client: req.Client,
+		Type:   "CONFIG_REWRITE",
+	}
+	out := protos.KeysInfo{Data: fmt.Sprintf("已写回节点:%s", strings.Join(done, ","))}
+	Audit(c, handle, 0, out, err)
+	if err != nil {
+		return done, fmt.Errorf("已写回 %d 个节点, %s", len(done), err)
+	}
+	return done, nil
+}
+
+// SaveBaseline 保存实例当前配置为基线, 同名覆盖
+func SaveBaseline(c *gin.Context, req protos.BaselineReq) (*protos.ConfigBaseline, error) {
+	if req.Name == "" {
+		return nil, errors.New("基线名称不能为空")
+	}
+	params, node, err := loadConfig(req.Client, req.Node)
+	if err != nil {
+		return nil, err
+	}
+	b := &protos.ConfigBaseline{
+		Name:      req.Name,
+		Client:    req.Client,
+		Node:      node,
+		Params:    params,
+		CreatedBy: currentUserName(c),
+		CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
+	}
+	baselines.load()
+	baselines.mux.Lock()
+	defer baselines.mux.Unlock()
+	old := baselines.data[req.Name]
+	baselines.data[req.Name] = b
+	if err := baselines.flush(); err != nil {
+		if old == nil {
+			delete(baselines.data, req.Name)
+		} else {
+			baselines.data[req.Name] = old
+		}
+		return nil, err
+	}
+	log.Info(c.Request.Context(), "config baseline saved", log.Fields{
+		"user":     b.CreatedBy,
+		"instance": req.Client,
+		"node":     node,
+		"baseline": req.Name,
+	})
+	return b, nil
+}
+
+// ListBaseline 基线列表, 不返回参数
+func ListBaseline(c *gin.Context) []protos.ConfigBaseline {
+	baselines.load()
+	baselines.mux.RLock()
+	defer baselines.mux.RUnlock()
+	out := make([]protos.ConfigBaseline, 0, len(baselines.data))
+	for _, v := range baselines.data {
+		item := *v
+		item.Params = nil
+		out = append(out, item)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// DelBaseline 删除基线
+func DelBaseline(c *gin.Context, req protos.BaselineReq) error {
+	baselines.load()
+	baselines.mux.Lock()
+	defer baselines.mux.Unlock()
+	old, ok := baselines.data[req.Name]
+	if !ok {
+		return fmt.Errorf("基线 %s 不存在", req.Name)
+	}
+	delete(baselines.data, req.Name)
+	if err := baselines.flush(); err != nil {
+		baselines.data[req.Name] = old
+		return err
+	}
+	log.Info(c.Request.Context(), "config baseline deleted", log.Fields{
+		"user":     currentUserName(c),
+		"baseline": req.Name,
+	})
+	return nil
+}
@@ -0,0 +1,395 @@
+package work
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fighthorse/redisAdmin/component/self_errors"
+	"github.com/fighthorse/redisAdmin/component/thirdpart/trace_redis"
+	"github.com/fighthorse/redisAdmin/internal/pkg/redis"
+	"github.com/fighthorse/redisAdmin/internal/service/login"
+	"github.com/fighthorse/redisAdmin/protos"
+	"github.com/gin-gonic/gin"
+
+	goredis "github.com/go-redis/redis"
+)
+
+// 每个实例的 COMMAND 信息, 用于判断读写和 key 位置
+var (
+	commandInfos = map[string]map[string]*goredis.CommandInfo{}
+	commandMux   sync.RWMutex
+)
+
+// Console 执行一条命令, 命令需在白名单内且对涉及的 key 有权限
+func Console(c *gin.Context, req protos.ConsoleReq) (*protos.ConsoleRes, error) {
+	args, err := SplitArgs(req.Command)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, errors.New("命令不能为空")
+	}
+	person := CurrentUser(c)
+	if person == nil {
+		return nil, &self_errors.PermissionError{Reason: "需要登录"}
+	}
+	sub := ""
+	if len(args) > 1 {
+		sub = args[1]
+	}
+	if err := login.CheckCommand(person.Name, args[0], sub); err != nil {
+		return nil, err
+	}
+
+	db, _ := strconv.Atoi(req.Db)
+	client := redis.LoadOthersDB(req.Client, db)
+	if client == nil {
+		return nil, errors.New("redis client create error")
+	}
+	info := commandInfo(req.Client, client.Client, args[0], sub)
+	// 未知命令按写命令处理
+	write := info == nil || !info.ReadOnly
+	if info != nil && hasFlag(info.Flags, "admin") && !login.HasRole(person.Name, login.RoleAdmin) {
+		return nil, &self_errors.PermissionError{Reason: fmt.Sprintf("命令 %s 需要管理员权限", strings.ToUpper(args[0]))}
+	}
+	keyRules := login.HasKeyRules(person.Name, req.Client, req.Db)
+	keys, err := consoleKeys(c, client.Client, info, args, keyRules)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		// FLUSHDB/SWAPDB 等无 key 的写命令作用于整个 db, 无法按 key 规则校验
+		if keyRules && write {
+			return nil, &self_errors.PermissionError{Reason: fmt.Sprintf("账户配置了 key 权限, 不允许执行 %s", strings.ToUpper(args[0]))}
+		}
+		keys = []string{""}
+	}
+	for _, key := range keys {
+		if err := login.CheckAccess(person.Name, req.Client, req.Db, key, write); err != nil {
+			return nil, err
+		}
+	}
+	// MOVE/COPY ... DB/SWAPDB 写入其他 db, 目标 db 同样需要写权限
+	for db, key := range targetDbKeys(args) {
+		if err := login.CheckAccess(person.Name, req.Client, db, key, true); err != nil {
+			return nil, err
+		}
+	}
+
+	vals := make([]interface{}, len(args))
+	for i, v := range args {
+		vals[i] = v
+	}
+	start := time.Now()
+	reply, err := client.Client.DoCmd(c, vals...)
+	res := &protos.ConsoleRes{
+		Command:  strings.Join(args, " "),
+		Duration: time.Since(start).Milliseconds(),
+	}
+	switch {
+	case err == goredis.Nil:
+		res.Reply = protos.ReplyNode{Type: "nil"}
+	case err != nil:
+		// 错误回复也展示给用户, 和 redis-cli 一致
+		res.Reply = protos.ReplyNode{Type: "error", Value: err.Error()}
+	default:
+		res.Reply = replyNode(reply)
+	}
+
+	if write {
+		handle := protos.SearchKeyReq{
+			Client: req.Client,
+			Db:     req.Db,
+			Type:   "CONSOLE",
+			Key:    res.Command,
+		}
+		out := protos.KeysInfo{Data: res.Reply.Value}
+		Audit(c, handle, 0, out, err)
+	}
+	return res, nil
+}
+
+// commandInfo 按需通过 COMMAND INFO 加载命令信息, 成功的查询才缓存, 未知命令同样缓存, 获取失败返回 nil
+// redis 7 中 CONFIG/CLIENT/OBJECT 等容器命令的 flags 在子命令条目上, 有子命令时优先使用
+func commandInfo(name string, client *trace_redis.RedisClient, cmd, sub string) *goredis.CommandInfo {
+	cmd = strings.ToLower(cmd)
+	commandMux.RLock()
+	infos := commandInfos[name]
+	_, ok := infos[cmd]
+	commandMux.RUnlock()
+	if !ok {
+		res, err := client.Do("command", "info", cmd).Result()
+		if err != nil {
+			// 网络错误等不缓存, 下次重新查询
+			return nil
+		}
+		var info *goredis.CommandInfo
+		var subs []*goredis.CommandInfo
+		if list, _ := res.([]interface{}); len(list) > 0 {
+			info, subs = parseCommandInfo(list[0])
+		}
+		commandMux.Lock()
+		infos = commandInfos[name]
+		if infos == nil {
+			infos = map[string]*goredis.CommandInfo{}
+			commandInfos[name] = infos
+		}
+		infos[cmd] = info
+		for _, v := range subs {
+			infos[v.Name] = v
+		}
+		commandMux.Unlock()
+	}
+	commandMux.RLock()
+	defer commandMux.RUnlock()
+	if sub != "" {
+		if info, ok := infos[cmd+"|"+strings.ToLower(sub)]; ok {
+			return info
+		}
+	}
+	return infos[cmd]
+}
+
+// parseCommandInfo 解析 COMMAND INFO 的一条回复, 只取前 6 个字段,
+// redis 6 之后追加的 acl 分类、tips、key specs 忽略, 子命令在第 10 个字段
+func parseCommandInfo(v interface{}) (*goredis.CommandInfo, []*goredis.CommandInfo) {
+	fields, _ := v.([]interface{})
+	if len(fields) < 6 {
+		return nil, nil
+	}
+	info := &goredis.CommandInfo{
+		Name:        strings.ToLower(toString(fields[0])),
+		Arity:       int8(toInt64(fields[1])),
+		FirstKeyPos: int8(toInt64(fields[3])),
+		LastKeyPos:  int8(toInt64(fields[4])),
+		StepCount:   int8(toInt64(fields[5])),
+	}
+	flags, _ := fields[2].([]interface{})
+	for _, f := range flags {
+		flag := toString(f)
+		info.Flags = append(info.Flags, flag)
+		if flag == "readonly" {
+			info.ReadOnly = true
+		}
+	}
+
+	var subs []*goredis.CommandInfo
+	if len(fields) > 9 {
+		list, _ := fields[9].([]interface{})
+		for _, item := range list {
+			if sub, _ := parseCommandInfo(item); sub != nil {
+				subs = append(subs, sub)
+			}
+		}
+	}
+	return info, subs
+}
+
+func hasFlag(flags []string, flag string) bool {
+	for _, v := range flags {
+		if v == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// 脚本可以访问任意 key, 无法按参数校验
+var scriptCommands = map[string]bool{
+	"EVAL": true, "EVALSHA": true, "EVAL_RO": true, "EVALSHA_RO": true, "FCALL": true, "FCALL_RO": true,
+}
+
+// consoleKeys 命令涉及的 key, movablekeys 命令通过 COMMAND GETKEYS 解析
+// 配置了 key 级别规则的用户, 无法确定涉及哪些 key 的命令直接拒绝
+func consoleKeys(c *gin.Context, client *trace_redis.RedisClient, info *goredis.CommandInfo, args []string, keyRules bool) ([]string, error) {
+	if !keyRules {
+		return commandKeys(info, args), nil
+	}
+	name := strings.ToUpper(args[0])
+	denied := &self_errors.PermissionError{Reason: fmt.Sprintf("账户配置了 key 权限, 不允许执行 %s", name)}
+	if info == nil || scriptCommands[name] {
+		return nil, denied
+	}
+	// SORT 的 BY/GET 按模式读取其他 key, GETKEYS 不会返回
+	if (name == "SORT" || name == "SORT_RO") && len(args) > 2 {
+		for _, v := range args[2:] {
+			if strings.EqualFold(v, "by") || strings.EqualFold(v, "get") {
+				return nil, denied
+			}
+		}
+	}
+	if !hasFlag(info.Flags, "movablekeys") {
+		return commandKeys(info, args), nil
+	}
+	vals := make([]interface{}, 0, len(args)+2)
+	vals = append(vals, "command", "getkeys")
+	for _, v := range args {
+		vals = append(vals, v)
+	}
+	res, err := client.DoCmd(c, vals...)
+	if err != nil {
+		return nil, denied
+	}
+	list, _ := res.([]interface{})
+	keys := make([]string, 0, len(list))
+	for _, v := range list {
+		keys = append(keys, toString(v))
+	}
+	return keys, nil
+}
+
+// targetDbKeys MOVE key db、COPY src dst DB db 和 SWAPDB 写入的目标 db 及 key
+func targetDbKeys(args []string) map[string]string {
+	switch strings.ToUpper(args[0]) {
+	case "SWAPDB":
+		if len(args) > 2 {
+			return map[string]string{args[1]: "", args[2]: ""}
+		}
+	case "MOVE":
+		if len(args) > 2 {
+			return map[string]string{args[2]: args[1]}
+		}
+	case "COPY":
+		for i := 3; i+1 < len(args); i++ {
+			if strings.EqualFold(args[i], "db") {
+				return map[string]string{args[i+1]: args[2]}
+			}
+		}
+	}
+	return nil
+}
+
+// commandKeys 按 first/last/step 取出参数中的 key, last 为负数时从末尾计算
+func commandKeys(info *goredis.CommandInfo, args []string) []string {
+	if info == nil || info.FirstKeyPos <= 0 {
+		return nil
+	}
+	last := int(info.LastKeyPos)
+	if last < 0 {
+		last = len(args) + last
+	}
+	step := int(info.StepCount)
+	if step <= 0 {
+		step = 1
+	}
+	var keys []string
+	for i := int(info.FirstKeyPos); i <= last && i < len(args); i += step {
+		keys = append(keys, args[i])
+	}
+	return keys
+}
+
+// replyNode 将 redis 回复转换为树形结构
+func replyNode(v interface{}) protos.ReplyNode {
+	switch vv := v.(type) {
+	case nil:
+		return protos.ReplyNode{Type: "nil"}
+	case string:
+		return protos.ReplyNode{Type: "string", Value: vv}
+	case int64:
+		return protos.ReplyNode{Type: "integer", Value: strconv.FormatInt(vv, 10)}
+	case []interface{}:
+		node := protos.ReplyNode{Type: "array", Value: strconv.Itoa(len(vv))}
+		for _, item := range vv {
+			node.Children = append(node.Children, replyNode(item))
+		}
+		return node
+	case error:
+		return protos.ReplyNode{Type: "error", Value: vv.Error()}
+	}
+	return protos.ReplyNode{Type: "string", Value: toString(v)}
+}
+
+// SplitArgs 按 redis-cli 规则拆分命令行, 支持单双引号和转义
+func SplitArgs(line string) ([]string, error) {
+	var args []string
+	i := 0
+	for {
+		for i < len(line) && isSpace(line[i]) {
+			i++
+		}
+		if i >= len(line) {
+			return args, nil
+		}
+		var cur []byte
+		inq, insq := false, false
+		for done := false; !done; {
+			if i >= len(line) {
+				if inq || insq {
+					return nil, errors.New("引号不匹配")
+				}
+				break
+			}
+			ch := line[i]
+			switch {
+			case inq:
+				if ch == '\\' && i+3 < len(line) && line[i+1] == 'x' && isHex(line[i+2]) && isHex(line[i+3]) {
+					b, _ := strconv.ParseUint(line[i+2:i+4], 16, 8)
+					cur = append(cur, byte(b))
+					i += 3
+				} else if ch == '\\' && i+1 < len(line) {
+					i++
+					switch line[i] {
+					case 'n':
+						cur = append(cur, '\n')
+					case 'r':
+						cur = append(cur, '\r')
+					case 't':
+						cur = append(cur, '\t')
+					case 'b':
+						cur = append(cur, '\b')
+					case 'a':
+						cur = append(cur, '\a')
+					default:
+						cur = append(cur, line[i])
+					}
+				} else if ch == '"' {
+					// 引号结束后必须是空白或结尾
+					if i+1 < len(line) && !isSpace(line[i+1]) {
+						return nil, errors.New("引号后需要空格")
+					}
+					done = true
+				} else {
+					cur = append(cur, ch)
+				}
+			case insq:
+				if ch == '\\' && i+1 < len(line) && line[i+1] == '\'' {
+					i++
+					cur = append(cur, '\'')
+				} else if ch == '\'' {
+					if i+1 < len(line) && !isSpace(line[i+1]) {
+						return nil, errors.New("引号后需要空格")
+					}
+					done = true
+				} else {
+					cur = append(cur, ch)
+				}
+			default:
+				switch ch {
+				case ' ', '\n', '\r', '\t', 0:
+					done = true
+				case '"':
+					inq = true
+				case '\'':
+					insq = true
+				default:
+					cur = append(cur, ch)
+				}
+			}
+			i++
+		}
+		args = append(args, string(cur))
+	}
+}
+
+func isSpace(ch byte) bool {
+	return ch == ' ' || ch == '\n' || ch == '\r' || ch == '\t'
+}
+
+func isHex(ch byte) bool {
+	return (ch >= '0' && ch <= '9') || (ch >= 'a' && ch <= 'f') || (ch >= 'A' && ch <= 'F')
+}
@@ -0,0 +1,164 @@
+package work
+
+import (
+	"reflect"
+	"testing"
+
+	goredis "github.com/go-redis/redis"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    []string
+		wantErr bool
+	}{
+		{name: "empty", line: "", want: nil},
+		{name: "spaces only", line: " \t\r\n ", want: nil},
+		{name: "plain", line: "GET key", want: []string{"GET", "key"}},
+		{name: "extra spaces", line: "  SET   k  v  ", want: []string{"SET", "k", "v"}},
+		{name: "tabs and newlines", line: "SET\tk\nv", want: []string{"SET", "k", "v"}},
+		{name: "double quotes", line: `SET k "hello world"`, want: []string{"SET", "k", "hello world"}},
+		{name: "single quotes", line: `SET k 'hello world'`, want: []string{"SET", "k", "hello world"}},
+		{name: "empty quotes", line: `SET k ""`, want: []string{"SET", "k", ""}},
+		{name: "quote inside word", line: `SET k"e y" v`, want: []string{"SET", "ke y", "v"}},
+		{name: "escapes", line: `SET k "a\nb\tc\rd\be\af"`, want: []string{"SET", "k", "a\nb\tc\rd\be\af"}},
+		{name: "escaped quote", line: `SET k "say \"hi\""`, want: []string{"SET", "k", `say "hi"`}},
+		{name: "escaped backslash", line: `SET k "a\\b"`, want: []string{"SET", "k", `a\b`}},
+		{name: "hex escape", line: `SET k "\x41\x62\xff"`, want: []string{"SET", "k", "Ab\xff"}},
+		{name: "invalid hex escape", line: `SET k "\xzz"`, want: []string{"SET", "k", "xzz"}},
+		{name: "short hex escape", line: `SET k "\x4"`, want: []string{"SET", "k", "x4"}},
+		{name: "single quote escape", line: `SET k 'it\'s'`, want: []string{"SET", "k", "it's"}},
+		{name: "single quote keeps backslash", line: `SET k 'a\nb'`, want: []string{"SET", "k", `a\nb`}},
+		{name: "unbalanced double quote", line: `SET k "abc`, wantErr: true},
+		{name: "unbalanced single quote", line: `SET k 'abc`, wantErr: true},
+		{name: "trailing backslash in quotes", line: `SET k "abc\`, wantErr: true},
+		{name: "text after closing quote", line: `SET k "abc"def`, wantErr: true},
+		{name: "text after closing single quote", line: `SET k 'abc'def`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SplitArgs(tt.line)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("SplitArgs(%q) = %q, want error", tt.line, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SplitArgs(%q) error = %v", tt.line, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitArgs(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		info *goredis.CommandInfo
+		args []string
+		want []string
+	}{
+		{name: "unknown command", info: nil, args: []string{"FOO", "k"}, want: nil},
+		{name: "no keys", info: &goredis.CommandInfo{FirstKeyPos: 0}, args: []string{"PING"}, want: nil},
+		{name: "single key", info: &goredis.CommandInfo{FirstKeyPos: 1, LastKeyPos: 1, StepCount: 1}, args: []string{"GET", "k"}, want: []string{"k"}},
+		{name: "all keys", info: &goredis.CommandInfo{FirstKeyPos: 1, LastKeyPos: -1, StepCount: 1}, args: []string{"DEL", "a", "b", "c"}, want: []string{"a", "b", "c"}},
+		{name: "step", info: &goredis.CommandInfo{FirstKeyPos: 1, LastKeyPos: -1, StepCount: 2}, args: []string{"MSET", "a", "1", "b", "2"}, want: []string{"a", "b"}},
+		{name: "last from end", info: &goredis.CommandInfo{FirstKeyPos: 1, LastKeyPos: -2, StepCount: 1}, args: []string{"BLPOP", "a", "b", "0"}, want: []string{"a", "b"}},
+		{name: "missing args", info: &goredis.CommandInfo{FirstKeyPos: 1, LastKeyPos: 1, StepCount: 1}, args: []string{"GET"}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := commandKeys(tt.info, tt.args)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("commandKeys(%q) = %q, want %q", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCommandInfo(t *testing.T) {
+	flags := func(v ...string) []interface{} {
+		out := make([]interface{}, len(v))
+		for i, f := range v {
+			out[i] = f
+		}
+		return out
+	}
+	tests := []struct {
+		name     string
+		reply    interface{}
+		want     *goredis.CommandInfo
+		wantSubs []string
+	}{
+		{name: "unknown command", reply: nil, want: nil},
+		{name: "short entry", reply: []interface{}{"get", int64(2)}, want: nil},
+		{
+			name:  "redis 5",
+			reply: []interface{}{"get", int64(2), flags("readonly", "fast"), int64(1), int64(1), int64(1)},
+			want:  &goredis.CommandInfo{Name: "get", Arity: 2, Flags: []string{"readonly", "fast"}, FirstKeyPos: 1, LastKeyPos: 1, StepCount: 1, ReadOnly: true},
+		},
+		{
+			name:  "redis 6",
+			reply: []interface{}{"mset", int64(-3), flags("write", "denyoom"), int64(1), int64(-1), int64(2), flags("@write", "@string")},
+			want:  &goredis.CommandInfo{Name: "mset", Arity: -3, Flags: []string{"write", "denyoom"}, FirstKeyPos: 1, LastKeyPos: -1, StepCount: 2},
+		},
+		{
+			name: "redis 7 container",
+			reply: []interface{}{"CONFIG", int64(-2), flags(), int64(0), int64(0), int64(0), flags("@slow"), flags(), []interface{}{},
+				[]interface{}{
+					[]interface{}{"config|set", int64(-4), flags("admin", "noscript"), int64(0), int64(0), int64(0), flags("@admin"), flags(), []interface{}{}, []interface{}{}},
+					[]interface{}{"config|get", int64(-3), flags("admin", "noscript"), int64(0), int64(0), int64(0), flags("@admin"), flags(), []interface{}{}, []interface{}{}},
+				}},
+			want:     &goredis.CommandInfo{Name: "config", Arity: -2, FirstKeyPos: 0, LastKeyPos: 0, StepCount: 0},
+			wantSubs: []string{"config|set", "config|get"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, subs := parseCommandInfo(tt.reply)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseCommandInfo() = %+v, want %+v", got, tt.want)
+			}
+			var names []string
+			for _, v := range subs {
+				names = append(names, v.Name)
+				if !hasFlag(v.Flags, "admin") {
+					t.Errorf("subcommand %s flags = %q, want admin", v.Name, v.Flags)
+				}
+			}
+			if !reflect.DeepEqual(names, tt.wantSubs) {
+				t.Errorf("parseCommandInfo() subs = %q, want %q", names, tt.wantSubs)
+			}
+		})
+	}
+}
+
+func TestTargetDbKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want map[string]string
+	}{
+		{name: "get", args: []string{"GET", "k"}, want: nil},
+		{name: "move", args: []string{"MOVE", "k", "3"}, want: map[string]string{"3": "k"}},
+		{name: "move missing db", args: []string{"move", "k"}, want: nil},
+		{name: "copy same db", args: []string{"COPY", "a", "b"}, want: nil},
+		{name: "copy replace", args: []string{"COPY", "a", "b", "REPLACE"}, want: nil},
+		{name: "copy db", args: []string{"COPY", "a", "b", "db", "2"}, want: map[string]string{"2": "b"}},
+		{name: "copy replace db", args: []string{"copy", "a", "b", "REPLACE", "DB", "5"}, want: map[string]string{"5": "b"}},
+		{name: "swapdb", args: []string{"SWAPDB", "0", "1"}, want: map[string]string{"0": "", "1": ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := targetDbKeys(tt.args)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("targetDbKeys(%q) = %v, want %v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,545 @@
+package work
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fighthorse/redisAdmin/component/thirdpart/trace_redis"
+	"github.com/fighthorse/redisAdmin/internal/pkg/redis"
+	"github.com/fighthorse/redisAdmin/internal/service/login"
+	"github.com/fighthorse/redisAdmin/protos"
+	"github.com/gin-gonic/gin"
+
+	goredis "github.com/go-redis/redis"
+)
+
+var (
+	// DiffMaxKeys 最多对比的 source key 数, 超出后不再检查 target 独有的 key
+	DiffMaxKeys = 100000
+	// DiffMaxItems 最多保留的差异数
+	DiffMaxItems = 10000
+	// DiffMaxFields 每个 key 最多返回的不同元素数
+	DiffMaxFields = 20
+	// DiffMaxElements 元素超过该数量时只对比长度
+	DiffMaxElements int64 = 10000
+	// DiffTtlTolerance 两边都有过期时间时允许的误差
+	DiffTtlTolerance = time.Minute
+
+	errDiffLimit = errors.New("diff limit")
+)
+
+// CreateDiff 对比两个实例/db 中 pattern 匹配的 key, 后台执行, 状态通过 /jobs/status 查询
+func CreateDiff(c *gin.Context, req protos.DiffReq) (interface{}, error) {
+	if req.Pattern == "" {
+		req.Pattern = "*"
+	}
+	if req.Target == "" {
+		return nil, errors.New("target 不能为空")
+	}
+	if req.Db == "" {
+		req.Db = "0"
+	}
+	if req.TargetDb == "" {
+		req.TargetDb = req.Db
+	}
+	if req.Client == req.Target && req.Db == req.TargetDb {
+		return nil, errors.New("source 和 target 相同")
+	}
+	if req.Batch <= 0 {
+		req.Batch = JobDefaultBatch
+	}
+	if req.Batch > JobMaxBatch {
+		req.Batch = JobMaxBatch
+	}
+	user := currentUserName(c)
+	if err := login.CheckAccess(user, req.Target, req.TargetDb, "", false); err != nil {
+		return nil, err
+	}
+	db, _ := strconv.Atoi(req.Db)
+	source := redis.LoadOthersDB(req.Client, db)
+	targetDb, _ := strconv.Atoi(req.TargetDb)
+	target := redis.LoadOthersDB(req.Target, targetDb)
+	if source == nil || target == nil {
+		return nil, errors.New("redis client create error")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	j := &job{
+		info: protos.JobInfo{
+			User:      user,
+			Client:    req.Client,
+			Db:        req.Db,
+			Target:    req.Target,
+			TargetDb:  req.TargetDb,
+			Action:    "compare",
+			Pattern:   req.Pattern,
+			Status:    JobRunning,
+			CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
+		},
+		ip:     c.ClientIP(),
+		cancel: cancel,
+	}
+	jobs.add(j)
+
+	go func() {
+		defer cancel()
+		j.finish(runDiff(ctx, j, req, source.Client, target.Client))
+	}()
+	return j.snapshot(), nil
+}
+
+// runDiff 先扫描 source 逐个对比, 再扫描 target 找出只在 target 中的 key
+func runDiff(ctx context.Context, j *job, req protos.DiffReq, source, target *trace_redis.RedisClient) error {
+	user := j.snapshot().User
+	start := time.Now()
+	var mu sync.Mutex
+	var done int64
+	seen := map[string]bool{}
+	truncated := false
+
+	err := source.ForEachMaster(func(node *goredis.Client) error {
+		return scanEach(node, req.Pattern, req.Batch, func(keys []string) error {
+			for _, k := range keys {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				mu.Lock()
+				if len(seen) >= DiffMaxKeys {
+					truncated = true
+					mu.Unlock()
+					return errDiffLimit
+				}
+				seen[k] = true
+				mu.Unlock()
+
+				j.update(func(info *protos.JobInfo) { info.Scanned++ })
+				if login.CheckAccess(user, req.Client, req.Db, k, false) != nil ||
+					login.CheckAccess(user, req.Target, req.TargetDb, k, false) != nil {
+					j.update(func(info *protos.JobInfo) { info.Skipped++ })
+					continue
+				}
+				item, err := diffKey(ctx, source, target, k, !req.NoValue)
+				if err != nil {
+					return err
+				}
+				j.addDiff(item)
+				j.update(func(info *protos.JobInfo) { info.Processed++ })
+
+				mu.Lock()
+				done++
+				n := done
+				mu.Unlock()
+				if err := diffWait(ctx, start, n, req.Rate); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil && err != errDiffLimit {
+		return err
+	}
+	if truncated {
+		j.update(func(info *protos.JobInfo) {
+			info.Error = fmt.Sprintf("source 超过 %d 个 key, 未检查只在 target 中的 key", DiffMaxKeys)
+		})
+		return nil
+	}
+
+	return target.ForEachMaster(func(node *goredis.Client) error {
+		return scanEach(node, req.Pattern, req.Batch, func(keys []string) error {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			for _, k := range keys {
+				mu.Lock()
+				ok := seen[k]
+				mu.Unlock()
+				if ok {
+					continue
+				}
+				j.update(func(info *protos.JobInfo) { info.Scanned++ })
+				if login.CheckAccess(user, req.Target, req.TargetDb, k, false) != nil {
+					j.update(func(info *protos.JobInfo) { info.Skipped++ })
+					continue
+				}
+				typeInfo, _ := target.Type(k).Result()
+				j.addDiff(&protos.DiffItem{Key: k, Kind: "only_target", TargetType: typeInfo, TargetTtl: keyPTTL(ctx, target, k)})
+				j.update(func(info *protos.JobInfo) { info.Processed++ })
+			}
+			return nil
+		})
+	})
+}
+
+func diffWait(ctx context.Context, start time.Time, done, rate int64) error {
+	if rate <= 0 {
+		return nil
+	}
+	wait := time.Duration(done)*time.Second/time.Duration(rate) - time.Since(start)
+	if wait <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(wait):
+	}
+	return nil
+}
+
+// addDiff 记录差异, 超过 DiffMaxItems 后只计数
+func (j *job) addDiff(item *protos.DiffItem) {
+	if item == nil {
+		return
+	}
+	j.mux.Lock()
+	defer j.mux.Unlock()
+	j.info.Diffs++
+	if len(j.diffs) < DiffMaxItems {
+		j.diffs = append(j.diffs, *item)
+	}
+}
+
+// keyPTTL 毫秒, -1 不过期
+func keyPTTL(ctx context.Context, client *trace_redis.RedisClient, key string) int64 {
+	pttl, err := client.PTTL(ctx, key)
+	if err != nil || pttl < 0 {
+		return -1
+	}
+	return int64(pttl / time.Millisecond)
+}
+
+// diffKey 对比单个 key, 相同时返回 nil
+func diffKey(ctx context.Context, source, target *trace_redis.RedisClient, key string, value bool) (*protos.DiffItem, error) {
+	st, err := source.Type(key).Result()
+	if err != nil {
+		return nil, err
+	}
+	tt, err := target.Type(key).Result()
+	if err != nil {
+		return nil, err
+	}
+	item := &protos.DiffItem{Key: key, SourceType: st, TargetType: tt}
+	switch {
+	case st == "none" && tt == "none":
+		// 扫描后被删除
+		return nil, nil
+	case tt == "none":
+		item.Kind = "only_source"
+		item.SourceTtl = keyPTTL(ctx, source, key)
+		return item, nil
+	case st == "none":
+		item.Kind = "only_target"
+		item.TargetTtl = keyPTTL(ctx, target, key)
+		return item, nil
+	case st != tt:
+		item.Kind = "type"
+		return item, nil
+	}
+
+	item.SourceTtl = keyPTTL(ctx, source, key)
+	item.TargetTtl = keyPTTL(ctx, target, key)
+	if value {
+		fields, err := diffValue(ctx, source, target, key, st)
+		if err != nil && err != ErrKeyNotFound {
+			return nil, err
+		}
+		if len(fields) > 0 {
+			item.Kind = "value"
+			item.Fields = fields
+			return item, nil
+		}
+	}
+	if ttlDiffer(item.SourceTtl, item.TargetTtl) {
+		item.Kind = "ttl"
+		return item, nil
+	}
+	return nil, nil
+}
+
+func ttlDiffer(a, b int64) bool {
+	if a < 0 || b < 0 {
+		return (a < 0) != (b < 0)
+	}
+	d := time.Duration(a-b) * time.Millisecond
+	return d > DiffTtlTolerance || d < -DiffTtlTolerance
+}
+
+// keyLen 元素个数, string 为字节数
+func keyLen(ctx context.Context, client *trace_redis.RedisClient, key, typeInfo string) (int64, error) {
+	switch typeInfo {
+	case "string":
+		return client.StrLen(ctx, key)
+	case "hash":
+		return client.HLen(ctx, key)
+	case "list":
+		return client.LLen(ctx, key)
+	case "set":
+		return client.SCard(ctx, key)
+	case "zset":
+		return client.ZCard(ctx, key)
+	case "stream":
+		return client.XLen(ctx, key)
+	}
+	return 0, nil
+}
+
+// diffValue 按元素对比, 元素过多时只对比长度, 其他类型对比 DUMP
+func diffValue(ctx context.Context, source, target *trace_redis.RedisClient, key, typeInfo string) ([]protos.DiffField, error) {
+	sl, err := keyLen(ctx, source, key, typeInfo)
+	if err != nil {
+		return nil, err
+	}
+	tl, err := keyLen(ctx, target, key, typeInfo)
+	if err != nil {
+		return nil, err
+	}
+	if typeInfo != "string" && (sl > DiffMaxElements || tl > DiffMaxElements) {
+		if sl != tl {
+			return []protos.DiffField{{Field: "length", Source: strconv.FormatInt(sl, 10), Target: strconv.FormatInt(tl, 10)}}, nil
+		}
+		return nil, nil
+	}
+
+	dump := false
+	switch typeInfo {
+	case "string", "hash", "list", "set", "zset", "stream":
+	default:
+		dump = true
+	}
+	si, err := readItem(ctx, source, key, dump)
+	if err != nil {
+		return nil, err
+	}
+	ti, err := readItem(ctx, target, key, dump)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []protos.DiffField
+	add := func(f protos.DiffField) bool {
+		out = append(out, f)
+		return len(out) < DiffMaxFields
+	}
+	switch typeInfo {
+	case "string":
+		if si.Str != ti.Str {
+			add(protos.DiffField{Source: si.Str, Target: ti.Str})
+		}
+	case "hash":
+		diffMap(si.Hash, ti.Hash, add)
+	case "set":
+		diffMap(setMap(si.List), setMap(ti.List), add)
+	case "zset":
+		diffMap(zsetMap(si.Zset), zsetMap(ti.Zset), add)
+	case "list":
+		for i := 0; i < len(si.List) || i < len(ti.List); i++ {
+			f := protos.DiffField{Field: strconv.Itoa(i)}
+			switch {
+			case i >= len(ti.List):
+				f.Source, f.Missing = si.List[i], "target"
+			case i >= len(si.List):
+				f.Target, f.Missing = ti.List[i], "source"
+			case si.List[i] == ti.List[i]:
+				continue
+			default:
+				f.Source, f.Target = si.List[i], ti.List[i]
+			}
+			if !add(f) {
+				break
+			}
+		}
+	case "stream":
+		diffMap(streamMap(si.Stream), streamMap(ti.Stream), add)
+	default:
+		if si.Dump != ti.Dump {
+			add(protos.DiffField{Field: "dump"})
+		}
+	}
+	return out, nil
+}
+
+// diffMap 按 field 排序对比, add 返回 false 时停止
+func diffMap(s, t map[string]string, add func(protos.DiffField) bool) {
+	fields := make([]string, 0, len(s)+len(t))
+	for k := range s {
+		fields = append(fields, k)
+	}
+	for k := range t {
+		if _, ok := s[k]; !ok {
+			fields = append(fields, k)
+		}
+	}
+	sort.Strings(fields)
+	for _, k := range fields {
+		sv, sok := s[k]
+		tv, tok := t[k]
+		f := protos.DiffField{Field: k, Source: sv, Target: tv}
+		switch {
+		case !tok:
+			f.Missing = "target"
+		case !sok:
+			f.Missing = "source"
+		case sv == tv:
+			continue
+		}
+		if !add(f) {
+			return
+		}
+	}
+}
+
+func setMap(list []string) map[string]string {
+	m := make(map[string]string, len(list))
+	for _, v := range list {
+		m[v] = ""
+	}
+	return m
+}
+
+func zsetMap(list []goredis.Z) map[string]string {
+	m := make(map[string]string, len(list))
+	for _, v := range list {
+		m[toString(v.Member)] = strconv.FormatFloat(v.Score, 'f', -1, 64)
+	}
+	return m
+}
+
+func streamMap(list []goredis.XMessage) map[string]string {
+	m := make(map[string]string, len(list))
+	for _, v := range list {
+		m[v.ID] = fmt.Sprintf("%v", v.Values)
+	}
+	return m
+}
+
+// diffJob 当前用户的 compare/migrate 任务, admin 可查看全部
+func diffJob(c *gin.Context, id int64) (*job, error) {
+	j := jobs.get(id)
+	if j == nil {
+		return nil, ErrJobNotFound
+	}
+	user := currentUserName(c)
+	info := j.snapshot()
+	if (info.Action != "compare" && info.Action != "migrate") || (info.User != user && !login.HasRole(user, login.RoleAdmin)) {
+		return nil, ErrJobNotFound
+	}
+	return j, nil
+}
+
+// DiffReport 分页返回差异, 任务运行中也可查看
+func DiffReport(c *gin.Context, req protos.DiffReportReq) (*protos.DiffReport, error) {
+	j, err := diffJob(c, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	size := req.Size
+	if size <= 0 || size > int(DataPageSize)*10 {
+		size = int(DataPageSize)
+	}
+	if req.Page < 0 {
+		req.Page = 0
+	}
+	j.mux.Lock()
+	res := &protos.DiffReport{Job: j.info, Page: req.Page}
+	var list []protos.DiffItem
+	for _, v := range j.diffs {
+		if req.Kind == "" || v.Kind == req.Kind {
+			list = append(list, v)
+		}
+	}
+	j.mux.Unlock()
+
+	res.Total = len(list)
+	from := req.Page * size
+	if from < len(list) {
+		to := from + size
+		if to > len(list) {
+			to = len(list)
+		}
+		res.Items = list[from:to]
+	}
+	return res, nil
+}
+
+// DiffSync 将选中的 key 从 source 同步到 target, 覆盖前保存快照
+func DiffSync(c *gin.Context, req protos.DiffSyncReq) ([]protos.DiffSyncItem, error) {
+	j, err := diffJob(c, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	info := j.snapshot()
+	if info.Action != "compare" {
+		return nil, ErrJobNotFound
+	}
+	keys := splitIds(req.Keys)
+	if len(keys) == 0 {
+		return nil, errors.New("keys 不能为空")
+	}
+	db, _ := strconv.Atoi(info.Db)
+	source := redis.LoadOthersDB(info.Client, db)
+	targetDb, _ := strconv.Atoi(info.TargetDb)
+	target := redis.LoadOthersDB(info.Target, targetDb)
+	if source == nil || target == nil {
+		return nil, errors.New("redis client create error")
+	}
+
+	// 只允许同步报告中的 key
+	j.mux.Lock()
+	found := make(map[string]bool, len(j.diffs))
+	for _, v := range j.diffs {
+		found[v.Key] = true
+	}
+	j.mux.Unlock()
+
+	user := currentUserName(c)
+	ctx := c.Request.Context()
+	out := make([]protos.DiffSyncItem, 0, len(keys))
+	for _, k := range keys {
+		res := protos.DiffSyncItem{Key: k}
+		if !found[k] {
+			res.Action, res.Error = "failed", "key 不在差异报告中"
+			out = append(out, res)
+			continue
+		}
+		err := login.CheckAccess(user, info.Client, info.Db, k, false)
+		if err == nil {
+			err = CheckWrite(c, info.Target, info.TargetDb, k)
+		}
+		if err != nil {
+			res.Action, res.Error = "failed", err.Error()
+			out = append(out, res)
+			continue
+		}
+		handle := protos.SearchKeyReq{Client: info.Target, Db: info.TargetDb, Type: "DIFF_SYNC", Key: k}
+		snapshot(c, handle, target.Client)
+
+		item, err := readItem(ctx, source.Client, k, true)
+		switch {
+		case err == ErrKeyNotFound:
+			_, err = target.Client.Del(ctx, k)
+			res.Action = "deleted"
+		case err == nil:
+			err = writeItem(ctx, target.Client, k, item, true)
+			// 版本不同时 RESTORE 可能失败, 改为按元素写入
+			if err != nil {
+				if full, e := readItem(ctx, source.Client, k, false); e == nil {
+					err = writeItem(ctx, target.Client, k, full, true)
+				}
+			}
+			res.Action = "replaced"
+		}
+		if err != nil {
+			res.Action, res.Error = "failed", err.Error()
+		}
+		Audit(c, handle, 0, protos.KeysInfo{Data: fmt.Sprintf("%s/%s -> %s/%s %s", info.Client, info.Db, info.Target, info.TargetDb, res.Action)}, err)
+		out = append(out, res)
+	}
+	return out, nil
+}
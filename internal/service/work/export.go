@@ -0,0 +1,285 @@
+package work
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fighthorse/redisAdmin/component/thirdpart/trace_redis"
+	"github.com/fighthorse/redisAdmin/internal/pkg/redis"
+	"github.com/fighthorse/redisAdmin/internal/service/login"
+	"github.com/fighthorse/redisAdmin/protos"
+	"github.com/gin-gonic/gin"
+
+	goredis "github.com/go-redis/redis"
+)
+
+var (
+	// ExportMaxKeys 按 pattern 导出时最多导出 key 数
+	ExportMaxKeys = 10000
+
+	ErrFormat      = errors.New("format 只支持 json/csv/resp")
+	ErrKeyNotFound = errors.New("当前key不存在")
+
+	errExportLimit = errors.New("export limit")
+	csvHeader      = []string{"key", "type", "ttl", "field", "value"}
+)
+
+// transferItem 导出/导入时 key 的完整数据, Dump 不为空时为 resp 格式
+type transferItem struct {
+	Key    string
+	Type   string
+	Ttl    int64 // 毫秒, 0 不过期
+	Dump   string
+	Str    string
+	Hash   map[string]string
+	List   []string // list / set
+	Zset   []goredis.Z
+	Stream []goredis.XMessage
+}
+
+// ExportKeys 导出 key 及 ttl, 返回文件名和内容
+func ExportKeys(c *gin.Context, req protos.ExportReq) (string, []byte, error) {
+	if req.Format == "" {
+		req.Format = "json"
+	}
+	if req.Format != "json" && req.Format != "csv" && req.Format != "resp" {
+		return "", nil, ErrFormat
+	}
+	db, _ := strconv.Atoi(req.Db)
+	client := redis.LoadOthersDB(req.Client, db)
+	if client == nil {
+		return "", nil, errors.New("redis client create error")
+	}
+	ctx := c.Request.Context()
+	keys, err := exportKeys(client.Client, req)
+	if err != nil {
+		return "", nil, err
+	}
+
+	user := currentUserName(c)
+	var buf bytes.Buffer
+	var w *csv.Writer
+	if req.Format == "csv" {
+		w = csv.NewWriter(&buf)
+		_ = w.Write(csvHeader)
+	}
+	for _, key := range keys {
+		if login.CheckAccess(user, req.Client, req.Db, key, false) != nil {
+			continue
+		}
+		// key 不存在、已过期或类型不支持时跳过
+		item, err := readItem(ctx, client.Client, key, req.Format == "resp")
+		if err != nil {
+			continue
+		}
+		switch req.Format {
+		case "json":
+			err = writeJSONItem(&buf, item)
+		case "csv":
+			err = writeCSVItem(w, item)
+		case "resp":
+			writeRESP(&buf, "RESTORE", item.Key, strconv.FormatInt(item.Ttl, 10), item.Dump)
+		}
+		if err != nil {
+			return "", nil, err
+		}
+	}
+	if w != nil {
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return "", nil, err
+		}
+	}
+	fileName := fmt.Sprintf("%s_%d_%s.%s", req.Client, db, time.Now().Format("20060102150405"), req.Format)
+	return fileName, buf.Bytes(), nil
+}
+
+// exportKeys 指定 keys 时直接使用, 否则按 pattern 扫描, 最多 ExportMaxKeys 个
+func exportKeys(client *trace_redis.RedisClient, req protos.ExportReq) ([]string, error) {
+	if req.Keys != "" {
+		return splitIds(req.Keys), nil
+	}
+	if req.Pattern == "" {
+		return nil, errors.New("keys 和 pattern 不能同时为空")
+	}
+	var mu sync.Mutex
+	var out []string
+	err := client.ForEachMaster(func(node *goredis.Client) error {
+		return scanEach(node, req.Pattern, 100, func(keys []string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			out = append(out, keys...)
+			if len(out) >= ExportMaxKeys {
+				return errExportLimit
+			}
+			return nil
+		})
+	})
+	if err != nil && err != errExportLimit {
+		return nil, err
+	}
+	if len(out) > ExportMaxKeys {
+		out = out[:ExportMaxKeys]
+	}
+	return out, nil
+}
+
+// readItem 读取 key 的完整数据, dump 为 true 时只读取 DUMP
+func readItem(ctx context.Context, client *trace_redis.RedisClient, key string, dump bool) (*transferItem, error) {
+	typeInfo, err := client.Type(key).Result()
+	if err != nil {
+		return nil, err
+	}
+	if typeInfo == "none" {
+		return nil, ErrKeyNotFound
+	}
+	item := &transferItem{Key: key, Type: typeInfo}
+	if pttl, _ := client.PTTL(ctx, key); pttl > 0 {
+		item.Ttl = int64(pttl / time.Millisecond)
+	}
+	if dump {
+		item.Dump, err = client.Dump(ctx, key)
+		return item, err
+	}
+
+	switch typeInfo {
+	case "string":
+		item.Str, err = client.Get(ctx, key)
+	case "hash":
+		item.Hash, err = client.HGetAll(ctx, key)
+	case "list":
+		item.List, err = client.LRange(key, 0, -1).Result()
+	case "set":
+		item.List, err = client.SMembers(ctx, key)
+	case "zset":
+		item.Zset, err = client.ZRangeWithScores(ctx, key, 0, -1)
+	case "stream":
+		item.Stream, err = client.XRange(key, "-", "+").Result()
+	default:
+		err = fmt.Errorf("%s 类型只支持 resp 格式", typeInfo)
+	}
+	return item, err
+}
+
+// encodeValue 按类型生成 json 导出的 value, 所有字符串经过 enc 处理
+func encodeValue(item *transferItem, enc func(string) string) interface{} {
+	switch item.Type {
+	case "string":
+		return enc(item.Str)
+	case "hash":
+		out := make(map[string]string, len(item.Hash))
+		for k, v := range item.Hash {
+			out[enc(k)] = enc(v)
+		}
+		return out
+	case "list", "set":
+		out := make([]string, 0, len(item.List))
+		for _, v := range item.List {
+			out = append(out, enc(v))
+		}
+		return out
+	case "zset":
+		out := make([]protos.ZSET, 0, len(item.Zset))
+		for _, v := range item.Zset {
+			out = append(out, protos.ZSET{Score: v.Score, Member: enc(toString(v.Member))})
+		}
+		return out
+	case "stream":
+		out := make([]protos.StreamEntry, 0, len(item.Stream))
+		for _, v := range item.Stream {
+			values := make(map[string]interface{}, len(v.Values))
+			for k, vv := range v.Values {
+				values[enc(k)] = enc(toString(vv))
+			}
+			out = append(out, protos.StreamEntry{Id: v.ID, Values: values})
+		}
+		return out
+	}
+	return nil
+}
+
+// writeJSONItem 每个 key 一行, 含非 utf8 数据时整体使用 base64
+func writeJSONItem(buf *bytes.Buffer, item *transferItem) error {
+	binary := false
+	value := encodeValue(item, func(s string) string {
+		if !utf8.ValidString(s) {
+			binary = true
+		}
+		return s
+	})
+	out := protos.ExportItem{
+		Key:   item.Key,
+		Type:  item.Type,
+		Ttl:   item.Ttl,
+		Value: value,
+	}
+	if binary {
+		out.Encoding = "base64"
+		out.Value = encodeValue(item, func(s string) string {
+			return base64.StdEncoding.EncodeToString([]byte(s))
+		})
+	}
+	b, err := json.Marshal(out)
+	if err != nil {
+		return err
+	}
+	buf.Write(b)
+	buf.WriteByte('\n')
+	return nil
+}
+
+// writeCSVItem 每个元素一行, 只支持 string/hash/list/set/zset
+func writeCSVItem(w *csv.Writer, item *transferItem) error {
+	ttl := strconv.FormatInt(item.Ttl, 10)
+	row := func(field, value string) error {
+		return w.Write([]string{item.Key, item.Type, ttl, field, value})
+	}
+	switch item.Type {
+	case "string":
+		return row("", item.Str)
+	case "hash":
+		for k, v := range item.Hash {
+			if err := row(k, v); err != nil {
+				return err
+			}
+		}
+	case "list":
+		for k, v := range item.List {
+			if err := row(strconv.Itoa(k), v); err != nil {
+				return err
+			}
+		}
+	case "set":
+		for _, v := range item.List {
+			if err := row(v, ""); err != nil {
+				return err
+			}
+		}
+	case "zset":
+		for _, v := range item.Zset {
+			if err := row(toString(v.Member), strconv.FormatFloat(v.Score, 'g', -1, 64)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeRESP 写入一条 RESP 命令, 可直接用于 redis-cli --pipe
+func writeRESP(buf *bytes.Buffer, args ...string) {
+	buf.WriteString("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, v := range args {
+		buf.WriteString("$" + strconv.Itoa(len(v)) + "\r\n")
+		buf.WriteString(v)
+		buf.WriteString("\r\n")
+	}
+}
@@ -7,8 +7,10 @@ import (
 	"strings"
 	"time"
 
+	"github.com/fighthorse/redisAdmin/component/self_errors"
 	"github.com/fighthorse/redisAdmin/component/thirdpart/trace_redis"
 	"github.com/fighthorse/redisAdmin/internal/pkg/redis"
+	"github.com/fighthorse/redisAdmin/internal/service/login"
 	"github.com/fighthorse/redisAdmin/protos"
 	"github.com/gin-gonic/gin"
 
@@ -17,6 +19,15 @@ import (
 
 var (
 	DataPageSize int64 = 20
+
+	// writeTypes 需要写权限的操作
+	writeTypes = map[string]bool{
+		"DEL": true, "SET": true, "HSET": true, "SADD": true, "SREM": true, "ZADD": true, "ZREM": true,
+		"XADD": true, "XDEL": true, "XTRIM": true, "XACK": true, "XCLAIM": true,
+		"SETBIT": true, "PFADD": true, "PFMERGE": true, "GEOADD": true,
+		"RENAME": true, "COPY": true, "MOVE": true, "EXPIRE": true, "PERSIST": true,
+		"LPUSH": true, "RPUSH": true, "LSET": true, "LREM": true, "LDEL": true, "LINSERT": true, "LTRIM": true, "LPOP": true, "RPOP": true,
+	}
 )
 
 func HandleKey(c *gin.Context, req protos.SearchKeyReq) (interface{}, error) {
@@ -30,6 +41,48 @@ func HandleKey(c *gin.Context, req protos.SearchKeyReq) (interface{}, error) {
 	return HandleByType(c, req, client.Client)
 }
 
+// CurrentUser 当前登录用户, 由 middleware.TokenRequired 设置
+func CurrentUser(c *gin.Context) *protos.Person {
+	data, _ := c.Get("user_info")
+	person, _ := data.(*protos.Person)
+	return person
+}
+
+// CheckWrite 校验当前登录用户对 key 的写权限
+func CheckWrite(c *gin.Context, instance, db, key string) error {
+	person := CurrentUser(c)
+	if person == nil {
+		return &self_errors.PermissionError{Reason: "需要登录"}
+	}
+	return login.CheckAccess(person.Name, instance, db, key, true)
+}
+
+// CheckRead 校验实例读权限, 用于请求中的第二个实例
+func CheckRead(c *gin.Context, instance string) error {
+	person := CurrentUser(c)
+	if person == nil {
+		return &self_errors.PermissionError{Reason: "需要登录"}
+	}
+	return login.CheckAccess(person.Name, instance, "", "", false)
+}
+
+// CheckReadKey 校验当前登录用户对 key 的读权限
+func CheckReadKey(c *gin.Context, instance, db, key string) error {
+	person := CurrentUser(c)
+	if person == nil {
+		return &self_errors.PermissionError{Reason: "需要登录"}
+	}
+	return login.CheckAccess(person.Name, instance, db, key, false)
+}
+
+// readFilter 过滤 scan 结果中没有读权限的 key
+func readFilter(c *gin.Context, instance, db string) func(key string) bool {
+	person := CurrentUser(c)
+	return func(key string) bool {
+		return person != nil && login.CheckAccess(person.Name, instance, db, key, false) == nil
+	}
+}
+
 func HandleErrMsg(title string, err error) string {
 	if err != nil {
 		return fmt.Sprintf("%s,[%s]", title, err.Error())
@@ -37,7 +90,25 @@ func HandleErrMsg(title string, err error) string {
 	return title
 }
 
+// HandleByType 写操作需校验权限并记录审计日志
 func HandleByType(c *gin.Context, req protos.SearchKeyReq, client *trace_redis.RedisClient) (interface{}, error) {
+	if !writeTypes[req.Type] {
+		return handleByType(c, req, client)
+	}
+	if err := CheckWrite(c, req.Client, req.Db, req.Key); err != nil {
+		Audit(c, req, 0, nil, err)
+		return nil, err
+	}
+	if trashTypes[req.Type] {
+		snapshot(c, req, client)
+	}
+	oldSize := keySize(c, client, req.Key)
+	out, err := handleByType(c, req, client)
+	Audit(c, req, oldSize, out, err)
+	return out, err
+}
+
+func handleByType(c *gin.Context, req protos.SearchKeyReq, client *trace_redis.RedisClient) (interface{}, error) {
 	ctx := c.Request.Context()
 	out := protos.KeysInfo{
 		Keys: req.Key,
@@ -122,6 +193,14 @@ func HandleByType(c *gin.Context, req protos.SearchKeyReq, client *trace_redis.R
 		out.Data = HandleErrMsg(fmt.Sprintf("删除集合数据:%d个", ssI), err)
 		out.Type = "msg"
 		return out, err
+	case "XRANGE", "XREVRANGE", "XINFO", "XPENDING", "XADD", "XDEL", "XTRIM", "XACK", "XCLAIM":
+		return HandleStream(c, req, client)
+	case "SETBIT", "GETBIT", "BITCOUNT", "PFADD", "PFCOUNT", "PFMERGE", "GEOADD", "GEOPOS", "GEOSEARCH":
+		return HandleSpecial(c, req, client)
+	case "RENAME", "COPY", "MOVE", "EXPIRE", "PERSIST", "OBJECT":
+		return HandleKeyOps(c, req, client)
+	case "LPUSH", "RPUSH", "LSET", "LREM", "LDEL", "LINSERT", "LTRIM", "LPOP", "RPOP":
+		return HandleList(c, req, client)
 	}
 	return nil, nil
 }
@@ -315,7 +394,18 @@ func GetKeyByType(c *gin.Context, req protos.SearchKeyReq, typeInfo, keys string
 			res.Total = 0
 		}
 
+	case "stream":
+		getStream(ctx, req, keys, client, &res)
+
 	case "bitmap":
+		getBitmap(ctx, req, keys, client, &res)
+
+	case "hyperloglog":
+		res.Length, _ = client.PFCount(ctx, keys)
+		res.Value = fmt.Sprintf("%d", res.Length)
+
+	case "geo":
+		getGeo(ctx, req, keys, client, &res)
 
 	default:
 		res.Value = "暂不支持查看类型"
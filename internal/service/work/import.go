@@ -0,0 +1,449 @@
+package work
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fighthorse/redisAdmin/component/thirdpart/trace_redis"
+	"github.com/fighthorse/redisAdmin/internal/pkg/redis"
+	"github.com/fighthorse/redisAdmin/internal/service/login"
+	"github.com/fighthorse/redisAdmin/protos"
+	"github.com/gin-gonic/gin"
+
+	goredis "github.com/go-redis/redis"
+)
+
+var (
+	// ImportMaxBytes 导入文件大小限制
+	ImportMaxBytes int64 = 64 << 20
+	// ImportItemsLimit 返回的明细条数
+	ImportItemsLimit = 1000
+)
+
+// ImportKeys 导入 ExportKeys 导出的数据, key 已存在时按 policy 处理, dry_run 只返回预览
+func ImportKeys(c *gin.Context, req protos.ImportReq) (*protos.ImportRes, error) {
+	if req.Format == "" {
+		req.Format = "json"
+	}
+	if req.Format != "json" && req.Format != "csv" && req.Format != "resp" {
+		return nil, ErrFormat
+	}
+	if req.Policy == "" {
+		req.Policy = "skip"
+	}
+	if req.Policy != "skip" && req.Policy != "replace" && req.Policy != "rename" {
+		return nil, errors.New("policy 只支持 skip/replace/rename")
+	}
+	if req.Suffix == "" {
+		req.Suffix = ":import"
+	}
+	data, err := importData(c, req)
+	if err != nil {
+		return nil, err
+	}
+	items, err := parseImport(req.Format, data)
+	if err != nil {
+		return nil, err
+	}
+	db, _ := strconv.Atoi(req.Db)
+	client := redis.LoadOthersDB(req.Client, db)
+	if client == nil {
+		return nil, errors.New("redis client create error")
+	}
+
+	ctx := c.Request.Context()
+	user := currentUserName(c)
+	res := &protos.ImportRes{Total: len(items)}
+	check := func(key string) error {
+		return login.CheckAccess(user, req.Client, req.Db, key, true)
+	}
+	exists := func(key string) (bool, error) {
+		n, err := client.Client.Exists(ctx, key)
+		return n > 0, err
+	}
+	for _, item := range items {
+		out := protos.ImportItem{Key: item.Key, Type: item.Type}
+		action, newKey, err := importAction(req.Policy, req.Suffix, item.Key, check, exists)
+		out.Action, out.NewKey = action, newKey
+		key := item.Key
+		if action == "rename" {
+			key = newKey
+		}
+		if err == nil && !req.DryRun && action != "skip" {
+			err = writeItem(ctx, client.Client, key, item, action == "replace")
+		}
+		if err != nil {
+			out.Action, out.Error = "fail", err.Error()
+		}
+
+		switch out.Action {
+		case "create":
+			res.Created++
+		case "replace":
+			res.Replaced++
+		case "rename":
+			res.Renamed++
+		case "skip":
+			res.Skipped++
+		case "fail":
+			res.Failed++
+		}
+		if len(res.Items) < ImportItemsLimit {
+			res.Items = append(res.Items, out)
+		}
+	}
+
+	if !req.DryRun {
+		summary := fmt.Sprintf("新增:%d 覆盖:%d 重命名:%d 跳过:%d 失败:%d", res.Created, res.Replaced, res.Renamed, res.Skipped, res.Failed)
+		handle := protos.SearchKeyReq{Client: req.Client, Db: req.Db, Type: "IMPORT"}
+		Audit(c, handle, 0, protos.KeysInfo{Data: summary}, nil)
+	}
+	return res, nil
+}
+
+// importAction 按 policy 决定 key 的处理方式: create/replace/rename/skip,
+// rename 的新 key 同样需要写权限, 新 key 已存在时跳过
+func importAction(policy, suffix, key string, check func(key string) error, exists func(key string) (bool, error)) (string, string, error) {
+	if err := check(key); err != nil {
+		return "", "", err
+	}
+	ok, err := exists(key)
+	switch {
+	case err != nil:
+		return "", "", err
+	case !ok:
+		return "create", "", nil
+	case policy == "replace":
+		return "replace", "", nil
+	case policy == "rename":
+		newKey := key + suffix
+		if err := check(newKey); err != nil {
+			return "", newKey, err
+		}
+		if ok, err = exists(newKey); err != nil {
+			return "", newKey, err
+		}
+		if ok {
+			return "skip", newKey, nil
+		}
+		return "rename", newKey, nil
+	}
+	return "skip", "", nil
+}
+
+// importData 优先读取上传的 file, 否则使用 data 字段
+func importData(c *gin.Context, req protos.ImportReq) ([]byte, error) {
+	fh, err := c.FormFile("file")
+	if err != nil {
+		if req.Data == "" {
+			return nil, errors.New("导入数据不能为空")
+		}
+		return []byte(req.Data), nil
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	b, err := ioutil.ReadAll(io.LimitReader(f, ImportMaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) > ImportMaxBytes {
+		return nil, fmt.Errorf("导入文件不能超过 %d 字节", ImportMaxBytes)
+	}
+	return b, nil
+}
+
+func parseImport(format string, data []byte) ([]*transferItem, error) {
+	switch format {
+	case "csv":
+		return parseCSV(data)
+	case "resp":
+		return parseRESP(data)
+	}
+	return parseJSON(data)
+}
+
+// parseJSON 解析 json lines
+func parseJSON(data []byte) ([]*transferItem, error) {
+	var out []*transferItem
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), int(ImportMaxBytes))
+	line := 0
+	for scanner.Scan() {
+		line++
+		b := bytes.TrimSpace(scanner.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+		var raw struct {
+			Key      string          `json:"key"`
+			Type     string          `json:"type"`
+			Ttl      int64           `json:"ttl"`
+			Encoding string          `json:"encoding"`
+			Value    json.RawMessage `json:"value"`
+		}
+		if err := json.Unmarshal(b, &raw); err != nil {
+			return nil, fmt.Errorf("第%d行: %s", line, err)
+		}
+		item, err := decodeValue(raw.Type, raw.Value, raw.Encoding == "base64")
+		if err != nil {
+			return nil, fmt.Errorf("第%d行: %s", line, err)
+		}
+		item.Key, item.Ttl = raw.Key, raw.Ttl
+		if item.Key == "" {
+			return nil, fmt.Errorf("第%d行: key 不能为空", line)
+		}
+		out = append(out, item)
+	}
+	return out, scanner.Err()
+}
+
+// decodeValue encodeValue 的逆过程
+func decodeValue(typeInfo string, value json.RawMessage, b64 bool) (*transferItem, error) {
+	var err error
+	dec := func(s string) string {
+		if !b64 || err != nil {
+			return s
+		}
+		var b []byte
+		b, err = base64.StdEncoding.DecodeString(s)
+		return string(b)
+	}
+
+	item := &transferItem{Type: typeInfo}
+	switch typeInfo {
+	case "string":
+		var v string
+		if e := json.Unmarshal(value, &v); e != nil {
+			return nil, e
+		}
+		item.Str = dec(v)
+	case "hash":
+		var v map[string]string
+		if e := json.Unmarshal(value, &v); e != nil {
+			return nil, e
+		}
+		item.Hash = make(map[string]string, len(v))
+		for k, vv := range v {
+			item.Hash[dec(k)] = dec(vv)
+		}
+	case "list", "set":
+		var v []string
+		if e := json.Unmarshal(value, &v); e != nil {
+			return nil, e
+		}
+		for _, vv := range v {
+			item.List = append(item.List, dec(vv))
+		}
+	case "zset":
+		var v []protos.ZSET
+		if e := json.Unmarshal(value, &v); e != nil {
+			return nil, e
+		}
+		for _, vv := range v {
+			item.Zset = append(item.Zset, goredis.Z{Score: vv.Score, Member: dec(toString(vv.Member))})
+		}
+	case "stream":
+		var v []protos.StreamEntry
+		if e := json.Unmarshal(value, &v); e != nil {
+			return nil, e
+		}
+		for _, vv := range v {
+			values := make(map[string]interface{}, len(vv.Values))
+			for k, val := range vv.Values {
+				values[dec(k)] = dec(toString(val))
+			}
+			item.Stream = append(item.Stream, goredis.XMessage{ID: vv.Id, Values: values})
+		}
+	default:
+		return nil, fmt.Errorf("不支持的类型: %s", typeInfo)
+	}
+	return item, err
+}
+
+// parseCSV 相同 key 的行合并为一个 key
+func parseCSV(data []byte) ([]*transferItem, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = len(csvHeader)
+	var out []*transferItem
+	index := map[string]*transferItem{}
+	for line := 1; ; line++ {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line == 1 && row[0] == csvHeader[0] && row[1] == csvHeader[1] {
+			continue
+		}
+		key, typeInfo, field, value := row[0], row[1], row[3], row[4]
+		ttl, _ := strconv.ParseInt(row[2], 10, 64)
+		item, ok := index[key]
+		if !ok {
+			item = &transferItem{Key: key, Type: typeInfo, Ttl: ttl}
+			index[key] = item
+			out = append(out, item)
+		}
+		switch typeInfo {
+		case "string":
+			item.Str = value
+		case "hash":
+			if item.Hash == nil {
+				item.Hash = map[string]string{}
+			}
+			item.Hash[field] = value
+		case "list":
+			item.List = append(item.List, value)
+		case "set":
+			item.List = append(item.List, field)
+		case "zset":
+			score, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("第%d行: score 不正确", line)
+			}
+			item.Zset = append(item.Zset, goredis.Z{Score: score, Member: field})
+		default:
+			return nil, fmt.Errorf("第%d行: 不支持的类型: %s", line, typeInfo)
+		}
+	}
+	return out, nil
+}
+
+// parseRESP 解析 RESTORE key ttl payload 命令
+func parseRESP(data []byte) ([]*transferItem, error) {
+	var out []*transferItem
+	r := bufio.NewReader(bytes.NewReader(data))
+	for {
+		args, err := readRESP(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(args) < 4 || strings.ToUpper(args[0]) != "RESTORE" {
+			return nil, errors.New("只支持 RESTORE key ttl payload 命令")
+		}
+		ttl, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: ttl 不正确", args[1])
+		}
+		out = append(out, &transferItem{Key: args[1], Type: "dump", Ttl: ttl, Dump: args[3]})
+	}
+	return out, nil
+}
+
+// readRESP 读取一个由 bulk string 组成的数组
+func readRESP(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		if err == io.EOF && strings.TrimSpace(line) == "" {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return readRESP(r)
+	}
+	if line[0] != '*' {
+		return nil, errors.New("resp 格式不正确")
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < 0 {
+		return nil, errors.New("resp 格式不正确")
+	}
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, io.ErrUnexpectedEOF
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" || line[0] != '$' {
+			return nil, errors.New("resp 格式不正确")
+		}
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 {
+			return nil, errors.New("resp 格式不正确")
+		}
+		b := make([]byte, size+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, io.ErrUnexpectedEOF
+		}
+		args = append(args, string(b[:size]))
+	}
+	return args, nil
+}
+
+// writeItem 写入 key, replace 时先删除原有数据
+func writeItem(ctx context.Context, client *trace_redis.RedisClient, key string, item *transferItem, replace bool) error {
+	ttl := time.Duration(item.Ttl) * time.Millisecond
+	if item.Dump != "" {
+		var err error
+		if replace {
+			_, err = client.RestoreReplace(ctx, key, ttl, item.Dump)
+		} else {
+			_, err = client.Restore(ctx, key, ttl, item.Dump)
+		}
+		return err
+	}
+
+	pipe := client.TxPipeline()
+	defer pipe.Close()
+	if replace {
+		pipe.Del(key)
+	}
+	switch item.Type {
+	case "string":
+		pipe.Set(key, item.Str, 0)
+	case "hash":
+		if len(item.Hash) > 0 {
+			fields := make(map[string]interface{}, len(item.Hash))
+			for k, v := range item.Hash {
+				fields[k] = v
+			}
+			pipe.HMSet(key, fields)
+		}
+	case "list", "set":
+		if len(item.List) > 0 {
+			vals := make([]interface{}, 0, len(item.List))
+			for _, v := range item.List {
+				vals = append(vals, v)
+			}
+			if item.Type == "list" {
+				pipe.RPush(key, vals...)
+			} else {
+				pipe.SAdd(key, vals...)
+			}
+		}
+	case "zset":
+		if len(item.Zset) > 0 {
+			pipe.ZAdd(key, item.Zset...)
+		}
+	case "stream":
+		for _, v := range item.Stream {
+			pipe.XAdd(&goredis.XAddArgs{Stream: key, ID: v.ID, Values: v.Values})
+		}
+	}
+	if ttl > 0 {
+		pipe.PExpire(key, ttl)
+	}
+	_, err := pipe.Exec()
+	return err
+}
@@ -0,0 +1,153 @@
+package work
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+
+	goredis "github.com/go-redis/redis"
+)
+
+var transferItems = []*transferItem{
+	{Key: "s", Type: "string", Ttl: 1500, Str: "hello, \"world\"\n"},
+	{Key: "h", Type: "hash", Hash: map[string]string{"f1": "v1", "f,2": "v 2"}},
+	{Key: "l", Type: "list", List: []string{"c", "a", "b", "a"}},
+	{Key: "set", Type: "set", List: []string{"x", "y"}},
+	{Key: "z", Type: "zset", Zset: []goredis.Z{{Score: 1.5, Member: "m1"}, {Score: -2, Member: "m2"}}},
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	items := append([]*transferItem{
+		{Key: "bin", Type: "string", Str: "\x00\xff\xfe"},
+		{Key: "bin:h", Type: "hash", Hash: map[string]string{"\xff": "ok"}},
+		{Key: "st", Type: "stream", Stream: []goredis.XMessage{{ID: "1-0", Values: map[string]interface{}{"f": "v"}}}},
+	}, transferItems...)
+	var buf bytes.Buffer
+	for _, item := range items {
+		if err := writeJSONItem(&buf, item); err != nil {
+			t.Fatalf("writeJSONItem(%s) error = %v", item.Key, err)
+		}
+	}
+	got, err := parseImport("json", buf.Bytes())
+	if err != nil {
+		t.Fatalf("parseImport(json) error = %v", err)
+	}
+	if !reflect.DeepEqual(got, items) {
+		t.Errorf("json round trip = %+v, want %+v", got, items)
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write(csvHeader)
+	for _, item := range transferItems {
+		if err := writeCSVItem(w, item); err != nil {
+			t.Fatalf("writeCSVItem(%s) error = %v", item.Key, err)
+		}
+	}
+	w.Flush()
+	got, err := parseImport("csv", buf.Bytes())
+	if err != nil {
+		t.Fatalf("parseImport(csv) error = %v", err)
+	}
+	if !reflect.DeepEqual(got, transferItems) {
+		t.Errorf("csv round trip = %+v, want %+v", got, transferItems)
+	}
+}
+
+func TestRESPRoundTrip(t *testing.T) {
+	items := []*transferItem{
+		{Key: "a", Type: "dump", Ttl: 0, Dump: "\x00\x03abc\t\x00\r\n\xff"},
+		{Key: "b c", Type: "dump", Ttl: 60000, Dump: "payload"},
+	}
+	var buf bytes.Buffer
+	for _, item := range items {
+		writeRESP(&buf, "RESTORE", item.Key, strconv.FormatInt(item.Ttl, 10), item.Dump)
+	}
+	got, err := parseImport("resp", buf.Bytes())
+	if err != nil {
+		t.Fatalf("parseImport(resp) error = %v", err)
+	}
+	if !reflect.DeepEqual(got, items) {
+		t.Errorf("resp round trip = %+v, want %+v", got, items)
+	}
+}
+
+func TestParseImportErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		data   string
+	}{
+		{name: "json invalid", format: "json", data: "{\"key\":\n"},
+		{name: "json empty key", format: "json", data: `{"key":"","type":"string","value":"v"}`},
+		{name: "json unknown type", format: "json", data: `{"key":"k","type":"bitmap","value":"v"}`},
+		{name: "json bad base64", format: "json", data: `{"key":"k","type":"string","encoding":"base64","value":"!!"}`},
+		{name: "csv columns", format: "csv", data: "k,string,0,v\n"},
+		{name: "csv bad score", format: "csv", data: "z,zset,0,m,abc\n"},
+		{name: "csv unknown type", format: "csv", data: "k,stream,0,f,v\n"},
+		{name: "resp not restore", format: "resp", data: "*2\r\n$3\r\nDEL\r\n$1\r\nk\r\n"},
+		{name: "resp bad ttl", format: "resp", data: "*4\r\n$7\r\nRESTORE\r\n$1\r\nk\r\n$1\r\nx\r\n$1\r\np\r\n"},
+		{name: "resp truncated", format: "resp", data: "*4\r\n$7\r\nRESTORE\r\n$1\r\nk\r\n$1\r\n0\r\n$10\r\np\r\n"},
+		{name: "resp not array", format: "resp", data: "+OK\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := parseImport(tt.format, []byte(tt.data)); err == nil {
+				t.Errorf("parseImport(%s, %q) = %+v, want error", tt.format, tt.data, got)
+			}
+		})
+	}
+}
+
+func TestImportAction(t *testing.T) {
+	denied := errors.New("denied")
+	existing := map[string]bool{"a": true, "b": true, "b:import": true}
+	exists := func(key string) (bool, error) {
+		if key == "broken" {
+			return false, errors.New("io")
+		}
+		return existing[key], nil
+	}
+	check := func(key string) error {
+		if key == "secret" || key == "a:denied" {
+			return denied
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name       string
+		policy     string
+		suffix     string
+		key        string
+		wantAction string
+		wantNewKey string
+		wantErr    bool
+	}{
+		{name: "create", policy: "skip", key: "new", wantAction: "create"},
+		{name: "create ignores policy", policy: "replace", key: "new", wantAction: "create"},
+		{name: "skip existing", policy: "skip", key: "a", wantAction: "skip"},
+		{name: "replace existing", policy: "replace", key: "a", wantAction: "replace"},
+		{name: "rename existing", policy: "rename", suffix: ":import", key: "a", wantAction: "rename", wantNewKey: "a:import"},
+		{name: "rename target exists", policy: "rename", suffix: ":import", key: "b", wantAction: "skip", wantNewKey: "b:import"},
+		{name: "rename target denied", policy: "rename", suffix: ":denied", key: "a", wantNewKey: "a:denied", wantErr: true},
+		{name: "key denied", policy: "replace", key: "secret", wantErr: true},
+		{name: "exists error", policy: "skip", key: "broken", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action, newKey, err := importAction(tt.policy, tt.suffix, tt.key, check, exists)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("importAction() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if action != tt.wantAction || newKey != tt.wantNewKey {
+				t.Errorf("importAction() = %q, %q, want %q, %q", action, newKey, tt.wantAction, tt.wantNewKey)
+			}
+		})
+	}
+}
@@ -0,0 +1,328 @@
+package work
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fighthorse/redisAdmin/component/log"
+	"github.com/fighthorse/redisAdmin/internal/pkg/redis"
+	"github.com/fighthorse/redisAdmin/internal/service/login"
+	"github.com/fighthorse/redisAdmin/protos"
+	"github.com/gin-gonic/gin"
+
+	goredis "github.com/go-redis/redis"
+)
+
+// 任务状态
+const (
+	JobRunning  = "running"
+	JobDone     = "done"
+	JobCanceled = "canceled"
+	JobFailed   = "failed"
+)
+
+var (
+	// JobDefaultBatch 每批处理 key 数
+	JobDefaultBatch int64 = 100
+	// JobMaxBatch 每批最多处理 key 数
+	JobMaxBatch int64 = 1000
+	// JobKeepFinished 保留已结束任务数
+	JobKeepFinished = 100
+
+	ErrJobNotFound = errors.New("任务不存在")
+
+	jobs = &jobStore{items: map[int64]*job{}}
+)
+
+type job struct {
+	mux    sync.Mutex
+	info   protos.JobInfo
+	ip     string
+	cancel context.CancelFunc
+	diffs  []protos.DiffItem // compare 任务的差异
+}
+
+type jobStore struct {
+	mux   sync.Mutex
+	seq   int64
+	items map[int64]*job
+}
+
+func (s *jobStore) add(j *job) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.seq++
+	j.info.Id = s.seq
+	s.items[j.info.Id] = j
+	s.gc()
+}
+
+// gc 只保留最近 JobKeepFinished 个已结束任务, 需持有锁
+func (s *jobStore) gc() {
+	var finished []int64
+	for id, j := range s.items {
+		if j.snapshot().Status != JobRunning {
+			finished = append(finished, id)
+		}
+	}
+	if len(finished) <= JobKeepFinished {
+		return
+	}
+	sort.Slice(finished, func(i, k int) bool { return finished[i] < finished[k] })
+	for _, id := range finished[:len(finished)-JobKeepFinished] {
+		delete(s.items, id)
+	}
+}
+
+func (s *jobStore) get(id int64) *job {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.items[id]
+}
+
+func (s *jobStore) list() []*job {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	out := make([]*job, 0, len(s.items))
+	for _, j := range s.items {
+		out = append(out, j)
+	}
+	sort.Slice(out, func(i, k int) bool { return out[i].info.Id > out[k].info.Id })
+	return out
+}
+
+func (j *job) snapshot() protos.JobInfo {
+	j.mux.Lock()
+	defer j.mux.Unlock()
+	return j.info
+}
+
+func (j *job) update(fn func(info *protos.JobInfo)) {
+	j.mux.Lock()
+	fn(&j.info)
+	j.mux.Unlock()
+}
+
+// CreateJob 按 pattern 批量删除(UNLINK)或设置过期, 后台执行
+func CreateJob(c *gin.Context, req protos.JobReq) (interface{}, error) {
+	if req.Pattern == "" {
+		return nil, errors.New("pattern 不能为空")
+	}
+	if req.Action != "delete" && req.Action != "expire" {
+		return nil, errors.New("action 只支持 delete/expire")
+	}
+	if req.Action == "expire" && req.Ttl <= 0 {
+		return nil, errors.New("ttl 必须大于0")
+	}
+	if req.Batch <= 0 {
+		req.Batch = JobDefaultBatch
+	}
+	if req.Batch > JobMaxBatch {
+		req.Batch = JobMaxBatch
+	}
+	if !req.DryRun {
+		if err := CheckWrite(c, req.Client, req.Db, req.Pattern); err != nil {
+			return nil, err
+		}
+	}
+	db, _ := strconv.Atoi(req.Db)
+	client := redis.LoadOthersDB(req.Client, db)
+	if client == nil {
+		return nil, errors.New("redis client create error")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	j := &job{
+		info: protos.JobInfo{
+			User:      currentUserName(c),
+			Client:    req.Client,
+			Db:        req.Db,
+			Action:    req.Action,
+			Pattern:   req.Pattern,
+			Ttl:       req.Ttl,
+			DryRun:    req.DryRun,
+			Status:    JobRunning,
+			CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
+		},
+		ip:     c.ClientIP(),
+		cancel: cancel,
+	}
+	jobs.add(j)
+
+	go func() {
+		defer cancel()
+		// 集群各节点并发执行, 共用计数按总速率限速
+		start := time.Now()
+		var done int64
+		err := client.Client.ForEachMaster(func(node *goredis.Client) error {
+			return runJob(ctx, j, req, node, start, &done)
+		})
+		j.finish(err)
+	}()
+	return j.snapshot(), nil
+}
+
+// runJob 单个节点上 SCAN + UNLINK/EXPIRE, start/done 为所有节点共用, 按 rate 限速
+func runJob(ctx context.Context, j *job, req protos.JobReq, node *goredis.Client, start time.Time, done *int64) error {
+	user := j.snapshot().User
+	return scanEach(node, req.Pattern, req.Batch, func(keys []string) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var allowed []string
+		for _, k := range keys {
+			// dry run 只统计用户可读的 key, 实际执行需要写权限
+			if login.CheckAccess(user, req.Client, req.Db, k, !req.DryRun) == nil {
+				allowed = append(allowed, k)
+			}
+		}
+		j.update(func(info *protos.JobInfo) {
+			info.Scanned += int64(len(keys))
+			info.Skipped += int64(len(keys) - len(allowed))
+		})
+		if req.DryRun || len(allowed) == 0 {
+			return nil
+		}
+
+		// node 为 ForEachMaster 返回的节点连接, keys 来自该节点的 SCAN, pipeline 直接发往该节点,
+		// 各命令独立执行, 不要求 key 在同一个 slot
+		pipe := node.Pipeline()
+		cmds := make([]goredis.Cmder, 0, len(allowed))
+		for _, k := range allowed {
+			if req.Action == "delete" {
+				cmds = append(cmds, pipe.Unlink(k))
+			} else {
+				cmds = append(cmds, pipe.Expire(k, time.Duration(req.Ttl)*time.Second))
+			}
+		}
+		_, err := pipe.Exec()
+		_ = pipe.Close()
+		if err != nil && err != goredis.Nil {
+			return err
+		}
+		var n int64
+		for _, cmd := range cmds {
+			switch v := cmd.(type) {
+			case *goredis.IntCmd:
+				n += v.Val()
+			case *goredis.BoolCmd:
+				if v.Val() {
+					n++
+				}
+			}
+		}
+		j.update(func(info *protos.JobInfo) { info.Processed += n })
+
+		return diffWait(ctx, start, atomic.AddInt64(done, int64(len(allowed))), req.Rate)
+	})
+}
+
+// finish 记录任务结果, 非 dry run 时写审计日志
+func (j *job) finish(err error) {
+	j.update(func(info *protos.JobInfo) {
+		info.FinishedAt = time.Now().Format("2006-01-02 15:04:05")
+		switch {
+		case err == nil:
+			info.Status = JobDone
+		case err == context.Canceled:
+			info.Status = JobCanceled
+		default:
+			info.Status = JobFailed
+			info.Error = err.Error()
+		}
+	})
+	info := j.snapshot()
+	// compare 只读, 不需要审计
+	if info.DryRun || info.Action == "compare" {
+		return
+	}
+	fields := log.Fields{
+		"user":      info.User,
+		"ip":        j.ip,
+		"instance":  info.Client,
+		"db":        info.Db,
+		"command":   "JOB_" + info.Action,
+		"key":       info.Pattern,
+		"processed": info.Processed,
+		"result":    info.Status,
+	}
+	if info.Target != "" {
+		fields["target"] = info.Target
+		fields["target_db"] = info.TargetDb
+	}
+	if info.Error != "" {
+		fields["error"] = info.Error
+	}
+	log.AuditLog(context.Background(), fields)
+}
+
+// audit 后台任务中对单个 key 的修改, 没有请求上下文, 使用任务的用户和 ip
+func (j *job) audit(instance, db, command, key, result string, err error) {
+	fields := log.Fields{
+		"user":     j.snapshot().User,
+		"ip":       j.ip,
+		"instance": instance,
+		"db":       db,
+		"command":  command,
+		"key":      key,
+		"old_size": 0,
+		"result":   result,
+	}
+	if err != nil {
+		fields["result"] = "error"
+		fields["error"] = err.Error()
+	}
+	log.AuditLog(context.Background(), fields)
+}
+
+// JobStatus id 为 0 时返回当前用户的任务列表, admin 可查看全部
+func JobStatus(c *gin.Context, req protos.JobReq) (interface{}, error) {
+	user := currentUserName(c)
+	isAdmin := login.HasRole(user, login.RoleAdmin)
+	if req.Id > 0 {
+		j := jobs.get(req.Id)
+		if j == nil {
+			return nil, ErrJobNotFound
+		}
+		info := j.snapshot()
+		if !isAdmin && info.User != user {
+			return nil, ErrJobNotFound
+		}
+		return info, nil
+	}
+	out := make([]protos.JobInfo, 0)
+	for _, j := range jobs.list() {
+		info := j.snapshot()
+		if !isAdmin && info.User != user {
+			continue
+		}
+		if req.Client != "" && info.Client != req.Client {
+			continue
+		}
+		out = append(out, info)
+	}
+	return out, nil
+}
+
+// CancelJob 取消运行中的任务
+func CancelJob(c *gin.Context, req protos.JobReq) (interface{}, error) {
+	j := jobs.get(req.Id)
+	if j == nil {
+		return nil, ErrJobNotFound
+	}
+	user := currentUserName(c)
+	info := j.snapshot()
+	if info.User != user && !login.HasRole(user, login.RoleAdmin) {
+		return nil, ErrJobNotFound
+	}
+	if info.Status != JobRunning {
+		return nil, errors.New("任务已结束")
+	}
+	j.cancel()
+	return j.snapshot(), nil
+}
@@ -0,0 +1,211 @@
+package work
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fighthorse/redisAdmin/component/thirdpart/trace_redis"
+	"github.com/fighthorse/redisAdmin/internal/pkg/redis"
+	"github.com/fighthorse/redisAdmin/protos"
+	"github.com/gin-gonic/gin"
+
+	goredis "github.com/go-redis/redis"
+)
+
+var ErrDestExists = errors.New("目标key已存在, 需要覆盖时请勾选 replace")
+
+// HandleKeyOps 重命名/复制/移动/过期时间/OBJECT, 返回操作后的 key 信息
+func HandleKeyOps(c *gin.Context, req protos.SearchKeyReq, client *trace_redis.RedisClient) (interface{}, error) {
+	ctx := c.Request.Context()
+	dest := req.Dest
+	if dest == "" {
+		dest = req.Key
+	}
+	if req.Db == "" {
+		req.Db = "0"
+	}
+	destDb := req.DestDb
+	if destDb == "" {
+		destDb = req.Db
+	}
+
+	switch req.Type {
+	case "RENAME":
+		if dest == req.Key {
+			return nil, errors.New("新key不能与原key相同")
+		}
+		if err := CheckWrite(c, req.Client, req.Db, dest); err != nil {
+			return nil, err
+		}
+		if req.Replace {
+			snapshot(c, protos.SearchKeyReq{Client: req.Client, Db: req.Db, Type: req.Type, Key: dest}, client)
+			if _, err := client.Rename(ctx, req.Key, dest); err != nil {
+				return nil, err
+			}
+		} else {
+			ok, err := client.RenameNX(ctx, req.Key, dest)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return nil, ErrDestExists
+			}
+		}
+		return refreshKey(c, req, dest, client, fmt.Sprintf("已重命名为 %s", dest))
+
+	case "COPY":
+		if dest == req.Key && destDb == req.Db {
+			return nil, errors.New("目标与原key相同")
+		}
+		if err := CheckWrite(c, req.Client, destDb, dest); err != nil {
+			return nil, err
+		}
+		if err := copyKey(c, req, client, dest, destDb); err != nil {
+			return nil, err
+		}
+		return refreshKey(c, req, req.Key, client, fmt.Sprintf("已复制到 db%s %s", destDb, dest))
+
+	case "MOVE":
+		if destDb == req.Db {
+			return nil, errors.New("目标db与当前db相同")
+		}
+		if client.IsCluster() {
+			return nil, trace_redis.ErrClusterSelect
+		}
+		if err := CheckWrite(c, req.Client, destDb, req.Key); err != nil {
+			return nil, err
+		}
+		db, err := strconv.Atoi(destDb)
+		if err != nil || db < 0 {
+			return nil, errors.New("dest_db 不正确")
+		}
+		// MOVE 不能覆盖, replace 时先复制再删除
+		if req.Replace {
+			if err := copyKey(c, req, client, req.Key, destDb); err != nil {
+				return nil, err
+			}
+			_, err = client.Del(ctx, req.Key)
+		} else {
+			var ok bool
+			ok, err = client.Move(ctx, req.Key, db)
+			if err == nil && !ok {
+				err = ErrDestExists
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		return protos.KeysInfo{Keys: req.Key, Type: "msg", Data: fmt.Sprintf("已移动到 db%s", destDb)}, nil
+
+	case "EXPIRE":
+		if req.Ttl <= 0 {
+			return nil, errors.New("ttl 必须大于0, 取消过期请使用 PERSIST")
+		}
+		ok, err := client.Expire(ctx, req.Key, time.Duration(req.Ttl)*time.Second)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrKeyNotFound
+		}
+		return refreshKey(c, req, req.Key, client, fmt.Sprintf("过期时间:%d秒", req.Ttl))
+
+	case "PERSIST":
+		ok, err := client.Persist(ctx, req.Key)
+		if err != nil {
+			return nil, err
+		}
+		msg := "已取消过期时间"
+		if !ok {
+			msg = "key 没有过期时间"
+		}
+		return refreshKey(c, req, req.Key, client, msg)
+
+	case "OBJECT":
+		out, err := refreshKey(c, req, req.Key, client, "")
+		if err != nil {
+			return nil, err
+		}
+		obj := &protos.KeyObject{}
+		obj.Encoding, _ = client.ObjectEncoding(ctx, req.Key)
+		obj.Refcount, _ = client.ObjectRefCount(ctx, req.Key)
+		if idle, err := client.ObjectIdleTime(ctx, req.Key); err == nil {
+			obj.Idletime = int64(idle / time.Second)
+		}
+		// 非 LFU 策略时返回错误
+		if freq, err := client.DoCmd(ctx, "object", "freq", req.Key); err == nil {
+			obj.Freq = toInt64(freq)
+		}
+		out.Object = obj
+		return out, nil
+	}
+	return nil, nil
+}
+
+// copyKey COPY 需要 6.2+, 不支持时使用 DUMP/RESTORE
+func copyKey(c *gin.Context, req protos.SearchKeyReq, client *trace_redis.RedisClient, dest, destDb string) error {
+	ctx := c.Request.Context()
+	dst := client
+	if destDb != req.Db {
+		db, err := strconv.Atoi(destDb)
+		if err != nil || db < 0 {
+			return errors.New("dest_db 不正确")
+		}
+		inst := redis.LoadOthersDB(req.Client, db)
+		if inst == nil {
+			return errors.New("redis client create error")
+		}
+		dst = inst.Client
+	}
+	if req.Replace {
+		snapshot(c, protos.SearchKeyReq{Client: req.Client, Db: destDb, Type: req.Type, Key: dest}, dst)
+	}
+
+	args := []interface{}{"copy", req.Key, dest}
+	if destDb != req.Db {
+		args = append(args, "db", destDb)
+	}
+	if req.Replace {
+		args = append(args, "replace")
+	}
+	res, err := client.DoCmd(ctx, args...)
+	if err == nil {
+		if toInt64(res) == 0 {
+			return ErrDestExists
+		}
+		return nil
+	}
+	if !strings.Contains(strings.ToLower(err.Error()), "unknown command") {
+		return err
+	}
+
+	item, err := readItem(ctx, client, req.Key, true)
+	if err != nil {
+		return err
+	}
+	err = writeItem(ctx, dst, dest, item, req.Replace)
+	if err != nil && strings.Contains(err.Error(), "BUSYKEY") {
+		return ErrDestExists
+	}
+	return err
+}
+
+// refreshKey 返回 key 的最新数据, msg 放在 Data 中供页面提示
+func refreshKey(c *gin.Context, req protos.SearchKeyReq, key string, client *trace_redis.RedisClient, msg string) (protos.KeysInfo, error) {
+	typeInfo, err := client.Type(key).Result()
+	if err != nil && err != goredis.Nil {
+		return protos.KeysInfo{}, err
+	}
+	if typeInfo == "none" || typeInfo == "" {
+		return protos.KeysInfo{}, ErrKeyNotFound
+	}
+	req.Page = 0
+	typeInfo = detectType(c.Request.Context(), req, key, typeInfo, client)
+	out := GetKeyByType(c, req, typeInfo, key, client)
+	out.Notify = KeyspaceEnabled(req.Client, client)
+	out.Data = msg
+	return out, nil
+}
@@ -0,0 +1,293 @@
+package work
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fighthorse/redisAdmin/component/conf"
+	"github.com/fighthorse/redisAdmin/component/self_errors"
+	"github.com/fighthorse/redisAdmin/component/thirdpart/trace_redis"
+	"github.com/fighthorse/redisAdmin/internal/pkg/redis"
+	"github.com/fighthorse/redisAdmin/internal/service/login"
+	"github.com/fighthorse/redisAdmin/protos"
+	"github.com/gin-gonic/gin"
+
+	goredis "github.com/go-redis/redis"
+)
+
+// 各实例 notify-keyspace-events 是否已开启, 缓存一分钟
+var (
+	notifyCache = map[string]notifyState{}
+	notifyMux   sync.Mutex
+)
+
+// 当前 keyspace 订阅会话数, 以及每个用户的 keyspace/pubsub 会话数
+var (
+	keyspaceSessions int32
+	userStreams      = map[string]int{}
+	userStreamsMux   sync.Mutex
+)
+
+// acquireStream 占用一个订阅会话, 超过总数或用户上限时返回错误, 结束时调用返回的 release
+func acquireStream(sessions *int32, max int32, user string) (func(), error) {
+	limit := conf.GConfig.Console.UserStreams
+	if limit <= 0 {
+		limit = 3
+	}
+	if atomic.AddInt32(sessions, 1) > max {
+		atomic.AddInt32(sessions, -1)
+		return nil, fmt.Errorf("订阅会话数已达上限 %d", max)
+	}
+	userStreamsMux.Lock()
+	if userStreams[user] >= limit {
+		userStreamsMux.Unlock()
+		atomic.AddInt32(sessions, -1)
+		return nil, fmt.Errorf("每个用户最多同时订阅 %d 个", limit)
+	}
+	userStreams[user]++
+	userStreamsMux.Unlock()
+	return func() {
+		userStreamsMux.Lock()
+		if userStreams[user]--; userStreams[user] <= 0 {
+			delete(userStreams, user)
+		}
+		userStreamsMux.Unlock()
+		atomic.AddInt32(sessions, -1)
+	}, nil
+}
+
+type notifyState struct {
+	enabled bool
+	at      time.Time
+}
+
+func keyspaceDuration() time.Duration {
+	d := time.Duration(conf.GConfig.Console.KeyspaceDuration * float64(time.Second))
+	if d <= 0 {
+		d = 30 * time.Minute
+	}
+	return d
+}
+
+func keyspaceMaxSessions() int32 {
+	n := int32(conf.GConfig.Console.KeyspaceSessions)
+	if n <= 0 {
+		n = 20
+	}
+	return n
+}
+
+// notifyFlags 返回开启所需的 notify-keyspace-events, 已满足时返回空
+func notifyFlags(current string, keyevent bool) string {
+	need := "K"
+	if keyevent {
+		need = "E"
+	}
+	flags := current
+	if !strings.Contains(flags, need) {
+		flags += need
+	}
+	// A 为 g$lshzxetd 的别名, 只有 K/E 时不会产生任何事件
+	if strings.Trim(flags, "KE") == "" {
+		flags += "A"
+	}
+	if flags == current {
+		return ""
+	}
+	return flags
+}
+
+// KeyspaceEnabled 实例所有 master 是否已开启 keyspace 通知
+func KeyspaceEnabled(name string, client *trace_redis.RedisClient) bool {
+	notifyMux.Lock()
+	st, ok := notifyCache[name]
+	notifyMux.Unlock()
+	if ok && time.Since(st.at) < time.Minute {
+		return st.enabled
+	}
+	var mu sync.Mutex
+	enabled := true
+	_ = client.ForEachMaster(func(node *goredis.Client) error {
+		current, err := nodeNotifyFlags(node)
+		mu.Lock()
+		if err != nil || notifyFlags(current, false) != "" {
+			enabled = false
+		}
+		mu.Unlock()
+		return nil
+	})
+	notifyMux.Lock()
+	notifyCache[name] = notifyState{enabled: enabled, at: time.Now()}
+	notifyMux.Unlock()
+	return enabled
+}
+
+func nodeNotifyFlags(node *goredis.Client) (string, error) {
+	res, err := node.ConfigGet("notify-keyspace-events").Result()
+	if err != nil {
+		return "", err
+	}
+	if len(res) < 2 {
+		return "", errors.New("notify-keyspace-events 不可用")
+	}
+	return toString(res[1]), nil
+}
+
+// KeyspaceStream SSE 推送 key 变化事件, 通知未开启时需 enable 且为 admin
+func KeyspaceStream(c *gin.Context, req protos.KeyspaceReq) error {
+	person := CurrentUser(c)
+	if person == nil {
+		return &self_errors.PermissionError{Reason: "需要登录"}
+	}
+	release, err := acquireStream(&keyspaceSessions, keyspaceMaxSessions(), person.Name)
+	if err != nil {
+		return err
+	}
+	defer release()
+	db, _ := strconv.Atoi(req.Db)
+	client := redis.LoadOthersDB(req.Client, db)
+	if client == nil {
+		return errors.New("redis client create error")
+	}
+	if req.Db == "" {
+		req.Db = "0"
+	}
+	key := req.Key
+	if key == "" {
+		key = "*"
+	}
+	var events []string
+	for _, v := range strings.Split(req.Events, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			events = append(events, strings.ToLower(v))
+		}
+	}
+	keyevent := len(events) > 0
+
+	var mu sync.Mutex
+	var nodes []*goredis.Client
+	var changed []string
+	var missing error
+	_ = client.Client.ForEachMaster(func(node *goredis.Client) error {
+		mu.Lock()
+		nodes = append(nodes, node)
+		mu.Unlock()
+		current, err := nodeNotifyFlags(node)
+		if err != nil {
+			mu.Lock()
+			missing = err
+			mu.Unlock()
+			return nil
+		}
+		flags := notifyFlags(current, keyevent)
+		if flags == "" {
+			return nil
+		}
+		if !req.Enable || !login.HasRole(person.Name, login.RoleAdmin) {
+			mu.Lock()
+			missing = fmt.Errorf("%s 未开启 keyspace 通知(notify-keyspace-events=%q), 需 admin 确认开启", node.Options().Addr, current)
+			mu.Unlock()
+			return nil
+		}
+		err = node.ConfigSet("notify-keyspace-events", flags).Err()
+		mu.Lock()
+		if err != nil {
+			missing = err
+		} else {
+			changed = append(changed, fmt.Sprintf("%s: %q -> %q", node.Options().Addr, current, flags))
+		}
+		mu.Unlock()
+		return nil
+	})
+	if len(changed) > 0 {
+		notifyMux.Lock()
+		delete(notifyCache, req.Client)
+		notifyMux.Unlock()
+		handle := protos.SearchKeyReq{
+			Client: req.Client,
+			Type:   "CONFIG_SET",
+			Key:    "notify-keyspace-events",
+		}
+		Audit(c, handle, 0, protos.KeysInfo{Data: strings.Join(changed, "; ")}, missing)
+	}
+	if missing != nil {
+		return missing
+	}
+
+	// keyevent 频道的消息为 key, keyspace 频道名带 key, 消息为事件
+	var patterns []string
+	if keyevent {
+		for _, e := range events {
+			patterns = append(patterns, fmt.Sprintf("__keyevent@%s__:%s", req.Db, e))
+		}
+	} else {
+		patterns = []string{fmt.Sprintf("__keyspace@%s__:%s", req.Db, key)}
+	}
+	out := make(chan protos.KeyEvent, 256)
+	var subs []*goredis.PubSub
+	defer func() {
+		for _, s := range subs {
+			_ = s.Close()
+		}
+	}()
+	for _, node := range nodes {
+		sub := node.PSubscribe(patterns...)
+		if _, err := sub.Receive(); err != nil {
+			_ = sub.Close()
+			return err
+		}
+		subs = append(subs, sub)
+		go func(addr string, ch <-chan *goredis.Message) {
+			for msg := range ch {
+				ev := protos.KeyEvent{Node: addr, Db: req.Db, Time: time.Now().UnixNano() / int64(time.Millisecond)}
+				if keyevent {
+					ev.Key = msg.Payload
+					ev.Event = msg.Channel[strings.IndexByte(msg.Channel, ':')+1:]
+					if !login.MatchPattern(key, ev.Key) {
+						continue
+					}
+				} else {
+					ev.Key = msg.Channel[strings.IndexByte(msg.Channel, ':')+1:]
+					ev.Event = msg.Payload
+				}
+				// 不推送无权限的 key
+				if login.CheckAccess(person.Name, req.Client, req.Db, ev.Key, false) != nil {
+					continue
+				}
+				select {
+				case out <- ev:
+				default:
+				}
+			}
+		}(node.Options().Addr, sub.Channel())
+	}
+
+	timeout := time.NewTimer(keyspaceDuration())
+	defer timeout.Stop()
+	heartbeat := time.NewTicker(15 * time.Second)
+	defer heartbeat.Stop()
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-timeout.C:
+			c.SSEvent("closed", "超过最长时间")
+			return false
+		case <-heartbeat.C:
+			c.SSEvent("ping", time.Now().Unix())
+			return true
+		case ev := <-out:
+			c.SSEvent("key", ev)
+			return true
+		}
+	})
+	return nil
+}
@@ -0,0 +1,246 @@
+package work
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fighthorse/redisAdmin/component/self_errors"
+	"github.com/fighthorse/redisAdmin/component/thirdpart/trace_redis"
+	"github.com/fighthorse/redisAdmin/protos"
+	"github.com/gin-gonic/gin"
+
+	goredis "github.com/go-redis/redis"
+)
+
+// 按下标修改前先确认该位置仍是页面看到的值, 返回 {状态, 当前值}
+// 状态 1 成功, 0 值不一致, -1 下标越界
+const (
+	listCheck = `
+local cur = redis.call('LINDEX', KEYS[1], ARGV[1])
+if cur == false then return {-1, ''} end
+if cur ~= ARGV[2] then return {0, cur} end
+`
+	listSetScript = listCheck + `
+redis.call('LSET', KEYS[1], ARGV[1], ARGV[3])
+return {1, cur}
+`
+	// 先替换成唯一占位值再 LREM, 只删除该下标
+	listDelScript = listCheck + `
+redis.call('LSET', KEYS[1], ARGV[1], ARGV[3])
+redis.call('LREM', KEYS[1], 1, ARGV[3])
+return {1, cur}
+`
+	// 占位值作为 LINSERT 参照, 插入后还原原值
+	listInsertScript = listCheck + `
+redis.call('LSET', KEYS[1], ARGV[1], ARGV[5])
+redis.call('LINSERT', KEYS[1], ARGV[4], ARGV[5], ARGV[3])
+local idx = tonumber(ARGV[1])
+if ARGV[4] == 'BEFORE' then idx = idx + 1 end
+redis.call('LSET', KEYS[1], idx, cur)
+return {1, cur}
+`
+)
+
+var ErrListIndex = errors.New("index 不能小于0")
+
+// HandleList list 增删改, 按下标的操作会检查并发修改
+func HandleList(c *gin.Context, req protos.SearchKeyReq, client *trace_redis.RedisClient) (interface{}, error) {
+	ctx := c.Request.Context()
+	var msg string
+
+	switch req.Type {
+	case "LPUSH", "RPUSH":
+		var n int64
+		var err error
+		if req.Type == "LPUSH" {
+			n, err = client.LPush(ctx, req.Key, req.Value)
+		} else {
+			n, err = client.RPush(ctx, req.Key, req.Value)
+		}
+		if err != nil {
+			return nil, err
+		}
+		msg = fmt.Sprintf("已添加, 当前长度:%d", n)
+
+	case "LSET", "LDEL":
+		if req.Index < 0 {
+			return nil, ErrListIndex
+		}
+		script, args := listSetScript, []interface{}{req.Index, req.Old, req.Value}
+		if req.Type == "LDEL" {
+			script, args = listDelScript, []interface{}{req.Index, req.Old, listTomb()}
+		}
+		if err := evalList(c, req, client, script, args...); err != nil {
+			return nil, err
+		}
+		msg = fmt.Sprintf("第%d项已修改", req.Index)
+		if req.Type == "LDEL" {
+			msg = fmt.Sprintf("第%d项已删除", req.Index)
+		}
+
+	case "LINSERT":
+		where := strings.ToUpper(req.Where)
+		if where == "" {
+			where = "BEFORE"
+		}
+		if where != "BEFORE" && where != "AFTER" {
+			return nil, errors.New("where 只支持 before, after")
+		}
+		// 指定参照值时使用原生 LINSERT, 否则按下标插入
+		if req.Pivot != "" {
+			n, err := client.LInsert(ctx, req.Key, where, req.Pivot, req.Value)
+			if err != nil {
+				return nil, err
+			}
+			if n == 0 {
+				return nil, ErrKeyNotFound
+			}
+			if n < 0 {
+				return nil, errors.New("参照值不存在")
+			}
+			msg = fmt.Sprintf("已插入, 当前长度:%d", n)
+			break
+		}
+		if req.Index < 0 {
+			return nil, ErrListIndex
+		}
+		if err := evalList(c, req, client, listInsertScript, req.Index, req.Old, req.Value, where, listTomb()); err != nil {
+			return nil, err
+		}
+		msg = fmt.Sprintf("已插入到第%d项%s", req.Index, map[string]string{"BEFORE": "之前", "AFTER": "之后"}[where])
+
+	case "LREM":
+		n, err := client.LRem(ctx, req.Key, req.Count, req.Value)
+		if err != nil {
+			return nil, err
+		}
+		msg = fmt.Sprintf("删除%d项", n)
+
+	case "LTRIM":
+		start, err := strconv.ParseInt(req.Start, 10, 64)
+		if err != nil {
+			return nil, errors.New("start 不正确")
+		}
+		stop, err := strconv.ParseInt(req.End, 10, 64)
+		if err != nil {
+			return nil, errors.New("end 不正确")
+		}
+		if _, err := client.LTrim(ctx, req.Key, start, stop); err != nil {
+			return nil, err
+		}
+		msg = fmt.Sprintf("已保留 %d ~ %d", start, stop)
+
+	case "LPOP", "RPOP":
+		var v string
+		var err error
+		if req.Type == "LPOP" {
+			v, err = client.LPop(ctx, req.Key)
+		} else {
+			v, err = client.RPop(ctx, req.Key)
+		}
+		if err == goredis.Nil {
+			return nil, ErrKeyNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		msg = fmt.Sprintf("弹出: %s", v)
+	}
+	return listView(c, req, client, msg), nil
+}
+
+// evalList 执行按下标修改的脚本, 值不一致时返回 ConflictError 及最新数据
+func evalList(c *gin.Context, req protos.SearchKeyReq, client *trace_redis.RedisClient, script string, args ...interface{}) error {
+	res, err := client.Eval(c.Request.Context(), script, []string{req.Key}, args...)
+	if err != nil {
+		return err
+	}
+	ret, ok := res.([]interface{})
+	if !ok || len(ret) < 2 {
+		return errors.New("脚本返回格式不正确")
+	}
+	state := toInt64(ret[0])
+	if state == 1 {
+		return nil
+	}
+
+	drift := listDrift(c, req, client, toString(ret[1]))
+	var reason string
+	switch {
+	case state < 0:
+		length, _ := client.LLen(c.Request.Context(), req.Key)
+		reason = fmt.Sprintf("列表已被修改: 第%d项已不存在(当前长度%d)", req.Index, length)
+		drift.Current = ""
+	case drift.NewIndex >= 0:
+		reason = fmt.Sprintf("列表已被修改: 第%d项当前为 %q, 原值现位于第%d项(偏移%+d), 请刷新后重试",
+			req.Index, drift.Current, drift.NewIndex, drift.Offset)
+	default:
+		reason = fmt.Sprintf("列表已被修改: 第%d项当前为 %q, 附近未找到原值, 请刷新后重试", req.Index, drift.Current)
+	}
+	out := listView(c, req, client, reason)
+	out.Drift = drift
+	return &self_errors.ConflictError{Reason: reason, Data: out}
+}
+
+// listDrift 在下标前后一页内查找原值的新位置, 取距离最近的一项
+func listDrift(c *gin.Context, req protos.SearchKeyReq, client *trace_redis.RedisClient, current string) *protos.ListDrift {
+	drift := &protos.ListDrift{
+		Index:    req.Index,
+		Expected: req.Old,
+		Current:  current,
+		NewIndex: -1,
+	}
+	lo := req.Index - DataPageSize
+	if lo < 0 {
+		lo = 0
+	}
+	values, err := client.LRange(req.Key, lo, req.Index+DataPageSize).Result()
+	if err != nil {
+		return drift
+	}
+	best := int64(-1)
+	for k, v := range values {
+		if v != req.Old {
+			continue
+		}
+		idx := lo + int64(k)
+		if best < 0 || abs64(idx-req.Index) < abs64(best-req.Index) {
+			best = idx
+		}
+	}
+	if best >= 0 {
+		drift.NewIndex = best
+		drift.Offset = best - req.Index
+	}
+	return drift
+}
+
+// listView 返回修改后当前页的数据, 页码超出时退到最后一页
+func listView(c *gin.Context, req protos.SearchKeyReq, client *trace_redis.RedisClient, msg string) protos.KeysInfo {
+	length, _ := client.LLen(c.Request.Context(), req.Key)
+	if length == 0 {
+		return protos.KeysInfo{Keys: req.Key, Type: "msg", Data: msg + ", 列表已为空"}
+	}
+	if req.Page < 0 || int64(req.Page)*DataPageSize >= length {
+		req.Page = int((length - 1) / DataPageSize)
+	}
+	out := GetKeyByType(c, req, "list", req.Key, client)
+	out.Notify = KeyspaceEnabled(req.Client, client)
+	out.Data = msg
+	return out
+}
+
+// listTomb 删除/插入时使用的唯一占位值
+func listTomb() string {
+	return fmt.Sprintf("__redisadmin_tomb_%d__", time.Now().UnixNano())
+}
+
+func abs64(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
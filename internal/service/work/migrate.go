@@ -0,0 +1,496 @@
+package work
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fighthorse/redisAdmin/component/conf"
+	"github.com/fighthorse/redisAdmin/component/thirdpart/trace_redis"
+	"github.com/fighthorse/redisAdmin/internal/service/login"
+	"github.com/fighthorse/redisAdmin/protos"
+	"github.com/gin-gonic/gin"
+
+	goredis "github.com/go-redis/redis"
+)
+
+var (
+	// MigrateFlushInterval 断点写入文件的最小间隔
+	MigrateFlushInterval = 5 * time.Second
+	// MigrateTimeout MIGRATE 命令的超时, 毫秒
+	MigrateTimeout = 5000
+
+	checkpointName = regexp.MustCompile(`^migrate_\d+$`)
+	checkpoints    = &checkpointStore{items: map[string]*protos.MigrateCheckpoint{}, running: map[string]bool{}}
+)
+
+// checkpointStore 迁移断点, 配置 checkpoint_dir 时同时写入文件, 重启后可恢复
+type checkpointStore struct {
+	mux     sync.Mutex
+	items   map[string]*protos.MigrateCheckpoint
+	running map[string]bool
+}
+
+func (s *checkpointStore) save(cp protos.MigrateCheckpoint) error {
+	cp.UpdatedAt = time.Now().Format("2006-01-02 15:04:05")
+	s.mux.Lock()
+	s.items[cp.Name] = &cp
+	s.mux.Unlock()
+
+	dir := conf.GConfig.Migrate.CheckpointDir
+	if dir == "" {
+		return nil
+	}
+	b, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	fileName := filepath.Join(dir, cp.Name+".json")
+	tmp := fileName + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, fileName)
+}
+
+func (s *checkpointStore) load(name string) (*protos.MigrateCheckpoint, error) {
+	if !checkpointName.MatchString(name) {
+		return nil, errors.New("断点名称错误")
+	}
+	s.mux.Lock()
+	cp, ok := s.items[name]
+	s.mux.Unlock()
+	if ok {
+		v := *cp
+		return &v, nil
+	}
+	dir := conf.GConfig.Migrate.CheckpointDir
+	if dir == "" {
+		return nil, errors.New("断点不存在")
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, name+".json"))
+	if err != nil {
+		return nil, errors.New("断点不存在")
+	}
+	v := &protos.MigrateCheckpoint{}
+	if err := json.Unmarshal(b, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// list 内存中的断点及目录中的文件, 新的在前
+func (s *checkpointStore) list() []protos.MigrateCheckpoint {
+	all := map[string]protos.MigrateCheckpoint{}
+	if dir := conf.GConfig.Migrate.CheckpointDir; dir != "" {
+		files, _ := filepath.Glob(filepath.Join(dir, "migrate_*.json"))
+		for _, f := range files {
+			name := strings.TrimSuffix(filepath.Base(f), ".json")
+			if cp, err := s.load(name); err == nil {
+				all[name] = *cp
+			}
+		}
+	}
+	s.mux.Lock()
+	for name, cp := range s.items {
+		all[name] = *cp
+	}
+	s.mux.Unlock()
+	out := make([]protos.MigrateCheckpoint, 0, len(all))
+	for _, v := range all {
+		out = append(out, v)
+	}
+	sort.Slice(out, func(i, k int) bool { return out[i].Name > out[k].Name })
+	return out
+}
+
+// acquire 同一断点只能有一个任务在执行
+func (s *checkpointStore) acquire(name string) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.running[name] {
+		return false
+	}
+	s.running[name] = true
+	return true
+}
+
+func (s *checkpointStore) release(name string) {
+	s.mux.Lock()
+	delete(s.running, name)
+	s.mux.Unlock()
+}
+
+// CreateMigrate 按 pattern 复制 key 到目标实例, 后台执行, 状态通过 /jobs/status 查询
+func CreateMigrate(c *gin.Context, req protos.MigrateReq) (interface{}, error) {
+	if req.Pattern == "" {
+		return nil, errors.New("pattern 不能为空")
+	}
+	if req.Target == "" {
+		return nil, errors.New("target 不能为空")
+	}
+	if req.Policy == "" {
+		req.Policy = "skip"
+	}
+	if req.Policy != "skip" && req.Policy != "replace" {
+		return nil, errors.New("policy 只支持 skip/replace")
+	}
+	switch req.Method {
+	case "":
+		req.Method = "auto"
+	case "auto", "dump", "migrate":
+	default:
+		return nil, errors.New("method 只支持 auto/dump/migrate")
+	}
+	if req.Verify != "" && req.Verify != "basic" && req.Verify != "value" {
+		return nil, errors.New("verify 只支持 basic/value")
+	}
+	if req.Db == "" {
+		req.Db = "0"
+	}
+	if req.TargetDb == "" {
+		req.TargetDb = req.Db
+	}
+	if req.Client == req.Target && req.Db == req.TargetDb {
+		return nil, errors.New("source 和 target 相同")
+	}
+	if req.Batch <= 0 {
+		req.Batch = JobDefaultBatch
+	}
+	if req.Batch > JobMaxBatch {
+		req.Batch = JobMaxBatch
+	}
+	if err := CheckWrite(c, req.Target, req.TargetDb, req.Pattern); err != nil {
+		return nil, err
+	}
+	req.Token = ""
+	cp := &protos.MigrateCheckpoint{
+		Name:     fmt.Sprintf("migrate_%d", time.Now().UnixNano()),
+		User:     currentUserName(c),
+		Req:      req,
+		Cursors:  map[string]uint64{},
+		Finished: map[string]bool{},
+	}
+	return startMigrate(c, cp)
+}
+
+// ResumeMigrate 从断点继续, 已完成复制的只执行校验
+func ResumeMigrate(c *gin.Context, req protos.MigrateResumeReq) (interface{}, error) {
+	cp, err := checkpoints.load(req.Checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	user := currentUserName(c)
+	if cp.User != user && !login.HasRole(user, login.RoleAdmin) {
+		return nil, errors.New("断点不存在")
+	}
+	if cp.Done && cp.Req.Verify == "" {
+		return nil, errors.New("迁移已完成")
+	}
+	if err := CheckWrite(c, cp.Req.Target, cp.Req.TargetDb, cp.Req.Pattern); err != nil {
+		return nil, err
+	}
+	if cp.Cursors == nil {
+		cp.Cursors = map[string]uint64{}
+	}
+	if cp.Finished == nil {
+		cp.Finished = map[string]bool{}
+	}
+	return startMigrate(c, cp)
+}
+
+// ListMigrate 断点列表, 非 admin 只能看到自己的
+func ListMigrate(c *gin.Context) []protos.MigrateCheckpoint {
+	user := currentUserName(c)
+	isAdmin := login.HasRole(user, login.RoleAdmin)
+	out := make([]protos.MigrateCheckpoint, 0)
+	for _, v := range checkpoints.list() {
+		if isAdmin || v.User == user {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func startMigrate(c *gin.Context, cp *protos.MigrateCheckpoint) (interface{}, error) {
+	req := cp.Req
+	db, _ := strconv.Atoi(req.Db)
+	source, err := trace_redis.MgrClient(req.Client, db)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", req.Client, err)
+	}
+	targetDb, _ := strconv.Atoi(req.TargetDb)
+	target, err := trace_redis.MgrClient(req.Target, targetDb)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", req.Target, err)
+	}
+	if !checkpoints.acquire(cp.Name) {
+		return nil, errors.New("该断点的任务正在执行")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	j := &job{
+		info: protos.JobInfo{
+			User:       cp.User,
+			Client:     req.Client,
+			Db:         req.Db,
+			Target:     req.Target,
+			TargetDb:   req.TargetDb,
+			Action:     "migrate",
+			Pattern:    req.Pattern,
+			Checkpoint: cp.Name,
+			Phase:      "copy",
+			Status:     JobRunning,
+			Scanned:    cp.Scanned,
+			Processed:  cp.Copied,
+			Skipped:    cp.Skipped,
+			Failed:     cp.Failed,
+			CreatedAt:  time.Now().Format("2006-01-02 15:04:05"),
+		},
+		ip:     c.ClientIP(),
+		cancel: cancel,
+	}
+	jobs.add(j)
+	if err := checkpoints.save(*cp); err != nil {
+		j.update(func(info *protos.JobInfo) { info.Error = "保存断点失败: " + err.Error() })
+	}
+
+	go func() {
+		defer cancel()
+		defer checkpoints.release(cp.Name)
+		m := &migrator{j: j, cp: cp, source: source, target: target}
+		j.finish(m.run(ctx))
+	}()
+	return j.snapshot(), nil
+}
+
+type migrator struct {
+	j      *job
+	source *trace_redis.RedisClient
+	target *trace_redis.RedisClient
+
+	mux     sync.Mutex // 保护 cp
+	cp      *protos.MigrateCheckpoint
+	flushed time.Time
+
+	// MIGRATE 参数, host 为空时使用 DUMP/RESTORE
+	host, port, auth string
+	noMigrate        int32
+}
+
+func (m *migrator) run(ctx context.Context) error {
+	req := m.cp.Req
+	if req.Method != "dump" {
+		cfg, err := trace_redis.Mgr().Config(req.Target)
+		if err == nil && (cfg.Mode == "" || cfg.Mode == trace_redis.ModeSingle) {
+			m.host, m.port, _ = net.SplitHostPort(cfg.Addr)
+			m.auth = cfg.Passwd
+		}
+		if m.host == "" && req.Method == "migrate" {
+			return errors.New("target 不是单机模式, 不能使用 MIGRATE")
+		}
+	}
+
+	if !m.cp.Done {
+		if err := m.copyAll(ctx); err != nil {
+			m.checkpoint(true)
+			return err
+		}
+		m.mux.Lock()
+		m.cp.Done = true
+		m.mux.Unlock()
+		m.checkpoint(true)
+	}
+	if req.Verify == "" {
+		return nil
+	}
+	m.j.update(func(info *protos.JobInfo) { info.Phase = "verify" })
+	return m.verify(ctx)
+}
+
+// copyAll 各 master 从断点游标继续 SCAN
+func (m *migrator) copyAll(ctx context.Context) error {
+	req := m.cp.Req
+	user := m.j.snapshot().User
+	start := time.Now()
+	var done int64
+	return m.source.ForEachMaster(func(node *goredis.Client) error {
+		addr := node.Options().Addr
+		m.mux.Lock()
+		finished, cursor := m.cp.Finished[addr], m.cp.Cursors[addr]
+		m.mux.Unlock()
+		if finished {
+			return nil
+		}
+		for {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			keys, next, err := node.Scan(cursor, req.Pattern, req.Batch).Result()
+			if err != nil {
+				return err
+			}
+			var copied, skipped, failed int64
+			for _, k := range keys {
+				if login.CheckAccess(user, req.Client, req.Db, k, false) != nil ||
+					login.CheckAccess(user, req.Target, req.TargetDb, k, true) != nil {
+					skipped++
+					continue
+				}
+				ok, err := m.copyKey(ctx, node, k)
+				if ok || err != nil {
+					m.j.audit(req.Target, req.TargetDb, "MIGRATE_COPY", k, fmt.Sprintf("%s/%s -> %s/%s", req.Client, req.Db, req.Target, req.TargetDb), err)
+				}
+				switch {
+				case err != nil:
+					failed++
+					m.j.addDiff(&protos.DiffItem{Key: k, Kind: "failed", Fields: []protos.DiffField{{Field: "error", Source: err.Error()}}})
+				case ok:
+					copied++
+				default:
+					skipped++
+				}
+				if err := diffWait(ctx, start, atomic.AddInt64(&done, 1), req.Rate); err != nil {
+					return err
+				}
+			}
+			// 整批完成后才推进游标, 恢复时最多重复一批
+			m.j.update(func(info *protos.JobInfo) {
+				info.Scanned += int64(len(keys))
+				info.Processed += copied
+				info.Skipped += skipped
+				info.Failed += failed
+			})
+			m.mux.Lock()
+			m.cp.Cursors[addr] = next
+			if next == 0 {
+				m.cp.Finished[addr] = true
+			}
+			m.mux.Unlock()
+			m.checkpoint(false)
+			if next == 0 {
+				return nil
+			}
+			cursor = next
+		}
+	})
+}
+
+// copyKey 返回 false 表示跳过(已存在或已被删除)
+func (m *migrator) copyKey(ctx context.Context, node *goredis.Client, key string) (bool, error) {
+	replace := m.cp.Req.Policy == "replace"
+	if m.host != "" && atomic.LoadInt32(&m.noMigrate) == 0 {
+		args := []interface{}{"migrate", m.host, m.port, key, m.cp.Req.TargetDb, MigrateTimeout, "copy"}
+		if replace {
+			args = append(args, "replace")
+		}
+		if m.auth != "" {
+			args = append(args, "auth", m.auth)
+		}
+		res, err := node.Do(args...).Result()
+		switch {
+		case err == nil:
+			return res != "NOKEY", nil
+		case strings.Contains(err.Error(), "BUSYKEY"):
+			return false, nil
+		}
+		if m.cp.Req.Method == "migrate" {
+			return false, err
+		}
+		// source 无法连接 target 等错误, 之后改用 DUMP/RESTORE
+		atomic.StoreInt32(&m.noMigrate, 1)
+	}
+
+	if !replace {
+		if n, err := m.target.Exists(ctx, key); err != nil {
+			return false, err
+		} else if n > 0 {
+			return false, nil
+		}
+	}
+	item, err := readItem(ctx, m.source, key, true)
+	if err == ErrKeyNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	err = writeItem(ctx, m.target, key, item, replace)
+	if err != nil && strings.Contains(err.Error(), "BUSYKEY") {
+		return false, nil
+	}
+	// 版本不同时 RESTORE 失败, 改为按元素写入
+	if err != nil && strings.Contains(err.Error(), "payload") {
+		full, e := readItem(ctx, m.source, key, false)
+		if e != nil {
+			return false, e
+		}
+		err = writeItem(ctx, m.target, key, full, replace)
+	}
+	return err == nil, err
+}
+
+// verify 对比 source 中 pattern 匹配的 key, 不一致的记录到报告
+func (m *migrator) verify(ctx context.Context) error {
+	req := m.cp.Req
+	user := m.j.snapshot().User
+	return m.source.ForEachMaster(func(node *goredis.Client) error {
+		return scanEach(node, req.Pattern, req.Batch, func(keys []string) error {
+			for _, k := range keys {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				if login.CheckAccess(user, req.Client, req.Db, k, false) != nil ||
+					login.CheckAccess(user, req.Target, req.TargetDb, k, false) != nil {
+					continue
+				}
+				item, err := diffKey(ctx, m.source, m.target, k, req.Verify == "value")
+				if err != nil {
+					return err
+				}
+				m.j.addDiff(item)
+			}
+			return nil
+		})
+	})
+}
+
+// checkpoint 同步计数并保存, force 为 false 时按 MigrateFlushInterval 限频
+func (m *migrator) checkpoint(force bool) {
+	m.mux.Lock()
+	if !force && time.Since(m.flushed) < MigrateFlushInterval {
+		m.mux.Unlock()
+		return
+	}
+	m.flushed = time.Now()
+	info := m.j.snapshot()
+	m.cp.Scanned, m.cp.Copied, m.cp.Skipped, m.cp.Failed = info.Scanned, info.Processed, info.Skipped, info.Failed
+	cp := *m.cp
+	cp.Cursors = make(map[string]uint64, len(m.cp.Cursors))
+	for k, v := range m.cp.Cursors {
+		cp.Cursors[k] = v
+	}
+	cp.Finished = make(map[string]bool, len(m.cp.Finished))
+	for k, v := range m.cp.Finished {
+		cp.Finished[k] = v
+	}
+	m.mux.Unlock()
+	if err := checkpoints.save(cp); err != nil {
+		m.j.update(func(info *protos.JobInfo) { info.Error = "保存断点失败: " + err.Error() })
+	}
+}
@@ -0,0 +1,336 @@
+package work
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fighthorse/redisAdmin/component/conf"
+	"github.com/fighthorse/redisAdmin/internal/pkg/redis"
+	"github.com/fighthorse/redisAdmin/internal/pkg/websocket"
+	"github.com/fighthorse/redisAdmin/internal/service/login"
+	"github.com/fighthorse/redisAdmin/protos"
+	"github.com/gin-gonic/gin"
+
+	goredis "github.com/go-redis/redis"
+)
+
+// 当前 MONITOR 会话数
+var monitorSessions int32
+
+func monitorLimits() (time.Duration, int32, int) {
+	cfg := conf.GConfig.Console
+	duration, sessions, rate := time.Duration(cfg.MonitorDuration*float64(time.Second)), int32(cfg.MonitorSessions), cfg.MonitorRate
+	if duration <= 0 {
+		duration = 5 * time.Minute
+	}
+	if sessions <= 0 {
+		sessions = 5
+	}
+	if rate <= 0 {
+		rate = 1000
+	}
+	return duration, sessions, rate
+}
+
+// monitorFilter 可在会话中被客户端消息替换
+type monitorFilter struct {
+	key      string
+	commands map[string]bool
+	addr     string
+}
+
+func newMonitorFilter(req protos.MonitorReq) *monitorFilter {
+	f := &monitorFilter{key: req.Key, addr: req.Addr}
+	for _, v := range strings.Split(req.Command, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			if f.commands == nil {
+				f.commands = map[string]bool{}
+			}
+			f.commands[strings.ToLower(v)] = true
+		}
+	}
+	return f
+}
+
+func (f *monitorFilter) match(line *protos.MonitorLine, keys []string) bool {
+	if f.commands != nil && !f.commands[strings.ToLower(line.Command)] {
+		return false
+	}
+	if f.addr != "" && !strings.Contains(line.Addr, f.addr) {
+		return false
+	}
+	if f.key == "" || f.key == "*" {
+		return true
+	}
+	for _, k := range keys {
+		if login.MatchPattern(f.key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// MonitorStream 升级为 websocket 并推送 MONITOR 输出, 仅升级前的错误会返回
+func MonitorStream(c *gin.Context, req protos.MonitorReq) error {
+	duration, maxSessions, rate := monitorLimits()
+	if atomic.AddInt32(&monitorSessions, 1) > maxSessions {
+		atomic.AddInt32(&monitorSessions, -1)
+		return fmt.Errorf("MONITOR 会话数已达上限 %d", maxSessions)
+	}
+	defer atomic.AddInt32(&monitorSessions, -1)
+
+	client := redis.LoadOthersDB(req.Client, 0)
+	if client == nil {
+		return errors.New("redis client create error")
+	}
+	var mu sync.Mutex
+	var nodes []*goredis.Client
+	_ = client.Client.ForEachMaster(func(node *goredis.Client) error {
+		if req.Node != "" && node.Options().Addr != req.Node {
+			return nil
+		}
+		mu.Lock()
+		nodes = append(nodes, node)
+		mu.Unlock()
+		return nil
+	})
+	if len(nodes) == 0 {
+		return fmt.Errorf("节点 %s 不存在", req.Node)
+	}
+
+	ws, err := websocket.Upgrade(c.Writer, c.Request)
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+	defer cancel()
+	var filter atomic.Value
+	filter.Store(newMonitorFilter(req))
+
+	lines := make(chan *protos.MonitorLine, 1024)
+	var dropped, total int64
+	var reason atomic.Value
+	stop := func(msg string) {
+		reason.Store(msg)
+		cancel()
+	}
+
+	for _, node := range nodes {
+		conn, err := monitorConn(node)
+		if err != nil {
+			_ = writeMonitorMsg(ws, protos.MonitorMsg{Type: "error", Message: node.Options().Addr + ": " + err.Error()})
+			continue
+		}
+		go func() {
+			<-ctx.Done()
+			_ = conn.Close()
+		}()
+		go func(addr string, conn net.Conn) {
+			br := bufio.NewReader(conn)
+			// 每个命令只查询一次 COMMAND INFO, 查询本身也会出现在 MONITOR 中
+			infos := map[string]*goredis.CommandInfo{}
+			for {
+				text, err := br.ReadString('\n')
+				if err != nil {
+					if ctx.Err() == nil {
+						stop(addr + ": " + err.Error())
+					}
+					return
+				}
+				line, err := parseMonitorLine(addr, strings.TrimRight(text, "\r\n"))
+				if err != nil || line == nil {
+					continue
+				}
+				var keys []string
+				f := filter.Load().(*monitorFilter)
+				if f.key != "" && f.key != "*" {
+					name := strings.ToLower(line.Command)
+					info, ok := infos[name]
+					if !ok {
+						info = commandInfo(req.Client, client.Client, name, "")
+						infos[name] = info
+					}
+					// 容器命令本身没有 key, 子命令信息已随父命令缓存, 不会再次查询
+					if info != nil && info.FirstKeyPos == 0 && len(line.Args) > 0 {
+						info = commandInfo(req.Client, client.Client, name, line.Args[0])
+					}
+					keys = commandKeys(info, append([]string{line.Command}, line.Args...))
+				}
+				if !f.match(line, keys) {
+					continue
+				}
+				select {
+				case lines <- line:
+				default:
+					atomic.AddInt64(&dropped, 1)
+				}
+			}
+		}(node.Options().Addr, conn)
+	}
+
+	// 客户端可发送 json 修改过滤条件, 断开时结束会话
+	go func() {
+		for {
+			_, msg, err := ws.ReadMessage()
+			if err != nil {
+				stop("客户端断开")
+				return
+			}
+			var update protos.MonitorReq
+			if err := json.Unmarshal(msg, &update); err != nil {
+				_ = writeMonitorMsg(ws, protos.MonitorMsg{Type: "error", Message: "过滤条件格式错误"})
+				continue
+			}
+			filter.Store(newMonitorFilter(update))
+		}
+	}()
+
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+	sent := 0
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-tick.C:
+			sent = 0
+		case line := <-lines:
+			if sent >= rate {
+				atomic.AddInt64(&dropped, 1)
+				continue
+			}
+			sent++
+			total++
+			if err := writeMonitorMsg(ws, protos.MonitorMsg{Type: "line", Line: line}); err != nil {
+				stop(err.Error())
+				break loop
+			}
+		}
+	}
+
+	msg, _ := reason.Load().(string)
+	if msg == "" {
+		msg = fmt.Sprintf("超过最长时间 %s", duration)
+	}
+	_ = writeMonitorMsg(ws, protos.MonitorMsg{Type: "closed", Message: msg, Dropped: atomic.LoadInt64(&dropped)})
+	_ = ws.WriteClose(websocket.CloseNormal, "")
+
+	handle := protos.SearchKeyReq{
+		Client: req.Client,
+		Type:   "MONITOR",
+		Key:    req.Node,
+	}
+	out := protos.KeysInfo{Data: fmt.Sprintf("推送:%d行, 丢弃:%d行, %s", total, atomic.LoadInt64(&dropped), msg)}
+	Audit(c, handle, 0, out, nil)
+	return nil
+}
+
+func writeMonitorMsg(ws *websocket.Conn, msg protos.MonitorMsg) error {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return ws.WriteText(b)
+}
+
+// monitorConn MONITOR 会独占连接, 不能使用连接池
+func monitorConn(node *goredis.Client) (net.Conn, error) {
+	opt := node.Options()
+	conn, err := opt.Dialer()
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(conn)
+	var cmds [][]string
+	if opt.Password != "" {
+		cmds = append(cmds, []string{"AUTH", opt.Password})
+	}
+	cmds = append(cmds, []string{"MONITOR"})
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	for _, args := range cmds {
+		var b strings.Builder
+		fmt.Fprintf(&b, "*%d\r\n", len(args))
+		for _, a := range args {
+			fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(a), a)
+		}
+		if _, err := conn.Write([]byte(b.String())); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		reply, err := br.ReadString('\n')
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		if strings.HasPrefix(reply, "-") {
+			_ = conn.Close()
+			return nil, errors.New(strings.TrimSpace(reply[1:]))
+		}
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return &bufferedConn{Conn: conn, br: br}, nil
+}
+
+// bufferedConn 握手时已读入缓冲的数据不能丢
+type bufferedConn struct {
+	net.Conn
+	br *bufio.Reader
+}
+
+func (b *bufferedConn) Read(p []byte) (int, error) {
+	return b.br.Read(p)
+}
+
+// parseMonitorLine 格式: 1339518083.107412 [0 127.0.0.1:60866] "keys" "*"
+func parseMonitorLine(node, text string) (*protos.MonitorLine, error) {
+	if !strings.HasPrefix(text, "+") {
+		return nil, errors.New("invalid monitor line")
+	}
+	text = text[1:]
+	sp := strings.IndexByte(text, ' ')
+	if sp < 0 {
+		return nil, nil
+	}
+	ts, err := strconv.ParseFloat(text[:sp], 64)
+	if err != nil {
+		// 如 +OK
+		return nil, nil
+	}
+	rest := text[sp+1:]
+	if !strings.HasPrefix(rest, "[") {
+		return nil, errors.New("invalid monitor line")
+	}
+	end := strings.IndexByte(rest, ']')
+	if end < 0 {
+		return nil, errors.New("invalid monitor line")
+	}
+	line := &protos.MonitorLine{Node: node, Time: ts}
+	meta := strings.Fields(rest[1:end])
+	if len(meta) > 0 {
+		line.Db, _ = strconv.Atoi(meta[0])
+	}
+	if len(meta) > 1 {
+		line.Addr = meta[1]
+	}
+	args, err := SplitArgs(rest[end+1:])
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, errors.New("invalid monitor line")
+	}
+	line.Command, line.Args = args[0], args[1:]
+	return line, nil
+}
@@ -0,0 +1,88 @@
+package work
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fighthorse/redisAdmin/protos"
+)
+
+func TestParseMonitorLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    *protos.MonitorLine
+		wantErr bool
+	}{
+		{name: "ok reply", text: "+OK", want: nil},
+		{name: "not status", text: "-ERR no", wantErr: true},
+		{
+			name: "simple",
+			text: `+1339518083.107412 [0 127.0.0.1:60866] "keys" "*"`,
+			want: &protos.MonitorLine{Node: "n1", Time: 1339518083.107412, Db: 0, Addr: "127.0.0.1:60866", Command: "keys", Args: []string{"*"}},
+		},
+		{
+			name: "lua",
+			text: `+1339518083.107412 [3 lua] "get" "k"`,
+			want: &protos.MonitorLine{Node: "n1", Time: 1339518083.107412, Db: 3, Addr: "lua", Command: "get", Args: []string{"k"}},
+		},
+		{
+			name: "escaped args",
+			text: `+1700000000.000001 [1 unix:/tmp/redis.sock] "set" "a \"b\"" "\x00\xff\n"`,
+			want: &protos.MonitorLine{Node: "n1", Time: 1700000000.000001, Db: 1, Addr: "unix:/tmp/redis.sock", Command: "set", Args: []string{`a "b"`, "\x00\xff\n"}},
+		},
+		{
+			name: "no args",
+			text: `+1700000000.5 [0 10.0.0.1:5000] "ping"`,
+			want: &protos.MonitorLine{Node: "n1", Time: 1700000000.5, Addr: "10.0.0.1:5000", Command: "ping", Args: []string{}},
+		},
+		{name: "missing meta", text: `+1700000000.5 "ping"`, wantErr: true},
+		{name: "unclosed meta", text: `+1700000000.5 [0 10.0.0.1:5000 "ping"`, wantErr: true},
+		{name: "missing command", text: `+1700000000.5 [0 10.0.0.1:5000]`, wantErr: true},
+		{name: "bad quotes", text: `+1700000000.5 [0 10.0.0.1:5000] "get`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMonitorLine("n1", tt.text)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseMonitorLine(%q) = %+v, want error", tt.text, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseMonitorLine(%q) error = %v", tt.text, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseMonitorLine(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMonitorFilter(t *testing.T) {
+	line := &protos.MonitorLine{Addr: "10.0.0.1:5000", Command: "GET", Args: []string{"user:1"}}
+	tests := []struct {
+		name string
+		req  protos.MonitorReq
+		keys []string
+		want bool
+	}{
+		{name: "no filter", want: true},
+		{name: "command match", req: protos.MonitorReq{Command: "set, get"}, want: true},
+		{name: "command miss", req: protos.MonitorReq{Command: "set"}, want: false},
+		{name: "addr match", req: protos.MonitorReq{Addr: "10.0.0.1"}, want: true},
+		{name: "addr miss", req: protos.MonitorReq{Addr: "10.0.0.2"}, want: false},
+		{name: "key wildcard", req: protos.MonitorReq{Key: "*"}, want: true},
+		{name: "key match", req: protos.MonitorReq{Key: "user:*"}, keys: []string{"user:1"}, want: true},
+		{name: "key miss", req: protos.MonitorReq{Key: "order:*"}, keys: []string{"user:1"}, want: false},
+		{name: "key filter without keys", req: protos.MonitorReq{Key: "user:*"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newMonitorFilter(tt.req).match(line, tt.keys); got != tt.want {
+				t.Errorf("match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
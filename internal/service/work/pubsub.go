@@ -0,0 +1,306 @@
+package work
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fighthorse/redisAdmin/component/conf"
+	"github.com/fighthorse/redisAdmin/component/self_errors"
+	"github.com/fighthorse/redisAdmin/internal/pkg/redis"
+	"github.com/fighthorse/redisAdmin/internal/pkg/websocket"
+	"github.com/fighthorse/redisAdmin/protos"
+	"github.com/gin-gonic/gin"
+
+	goredis "github.com/go-redis/redis"
+)
+
+// 当前 pubsub 订阅会话数
+var pubsubSessions int32
+
+func pubsubLimits() (time.Duration, int, int32) {
+	cfg := conf.GConfig.Console
+	duration, size, sessions := time.Duration(cfg.PubsubDuration*float64(time.Second)), cfg.PubsubBuffer, int32(cfg.PubsubSessions)
+	if duration <= 0 {
+		duration = 30 * time.Minute
+	}
+	if size <= 0 {
+		size = 1000
+	}
+	if sessions <= 0 {
+		sessions = 20
+	}
+	return duration, size, sessions
+}
+
+// PubsubChannels 汇总各 master 的 PUBSUB CHANNELS/NUMSUB/NUMPAT, 订阅只在连接的节点上可见
+func PubsubChannels(c *gin.Context, req protos.PubsubReq) (*protos.PubsubRes, error) {
+	client := redis.LoadOthersDB(req.Client, 0)
+	if client == nil {
+		return nil, errors.New("redis client create error")
+	}
+	pattern := req.Pattern
+	if pattern == "" {
+		pattern = "*"
+	}
+	res := &protos.PubsubRes{}
+	subs := map[string]int64{}
+	var mu sync.Mutex
+	_ = client.Client.ForEachMaster(func(node *goredis.Client) error {
+		addr := node.Options().Addr
+		channels, err := node.PubSubChannels(pattern).Result()
+		var num map[string]int64
+		if err == nil && len(channels) > 0 {
+			num, err = node.PubSubNumSub(channels...).Result()
+		}
+		var pat int64
+		if err == nil {
+			pat, err = node.PubSubNumPat().Result()
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			res.Errors = append(res.Errors, addr+": "+err.Error())
+			return nil
+		}
+		for _, ch := range channels {
+			subs[ch] += num[ch]
+		}
+		res.Patterns += pat
+		return nil
+	})
+	for name, n := range subs {
+		res.Channels = append(res.Channels, protos.PubsubChannel{Name: name, Subscribers: n})
+	}
+	sort.Slice(res.Channels, func(i, j int) bool {
+		if res.Channels[i].Subscribers != res.Channels[j].Subscribers {
+			return res.Channels[i].Subscribers > res.Channels[j].Subscribers
+		}
+		return res.Channels[i].Name < res.Channels[j].Name
+	})
+	return res, nil
+}
+
+// Publish 发布消息, 需要写权限
+func Publish(c *gin.Context, req protos.PublishReq) (interface{}, error) {
+	if req.Channel == "" {
+		return nil, errors.New("channel 不能为空")
+	}
+	if err := CheckWrite(c, req.Client, "", ""); err != nil {
+		return nil, err
+	}
+	client := redis.LoadOthersDB(req.Client, 0)
+	if client == nil {
+		return nil, errors.New("redis client create error")
+	}
+	n, err := client.Client.Publish(c.Request.Context(), req.Channel, req.Message)
+
+	handle := protos.SearchKeyReq{
+		Client: req.Client,
+		Type:   "PUBLISH",
+		Key:    req.Channel,
+	}
+	out := protos.KeysInfo{Data: fmt.Sprintf("接收:%d个", n)}
+	Audit(c, handle, int64(len(req.Message)), out, err)
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// PubsubStream 升级为 websocket 并推送订阅的消息, 客户端可发送 {"subscribe":[],"unsubscribe":[]} 修改订阅
+func PubsubStream(c *gin.Context, req protos.SubscribeReq) error {
+	patterns := splitPatterns(req.Pattern)
+	if len(patterns) == 0 {
+		return errors.New("pattern 不能为空")
+	}
+	if err := checkPatterns(patterns); err != nil {
+		return err
+	}
+	duration, size, maxSessions := pubsubLimits()
+	release, err := acquireStream(&pubsubSessions, maxSessions, currentUserName(c))
+	if err != nil {
+		return err
+	}
+	defer release()
+	client := redis.LoadOthersDB(req.Client, 0)
+	if client == nil {
+		return errors.New("redis client create error")
+	}
+	// 集群中 PUBLISH 会广播到所有节点, 订阅任意一个 master 即可
+	var node *goredis.Client
+	var mu sync.Mutex
+	_ = client.Client.ForEachMaster(func(n *goredis.Client) error {
+		mu.Lock()
+		if node == nil {
+			node = n
+		}
+		mu.Unlock()
+		return nil
+	})
+	if node == nil {
+		return errors.New("没有可用的节点")
+	}
+	sub := node.PSubscribe(patterns...)
+	defer sub.Close()
+	if _, err := sub.Receive(); err != nil {
+		return err
+	}
+
+	ws, err := websocket.Upgrade(c.Writer, c.Request)
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+	defer cancel()
+	var reason atomic.Value
+	stop := func(msg string) {
+		reason.Store(msg)
+		cancel()
+	}
+
+	// 缓冲满时丢弃最旧的消息
+	buf := make(chan protos.PubsubMsg, size)
+	var dropped int64
+	go func() {
+		for msg := range sub.Channel() {
+			m := protos.PubsubMsg{Type: "message", Channel: msg.Channel, Pattern: msg.Pattern, Payload: msg.Payload, Time: time.Now().UnixNano() / int64(time.Millisecond)}
+			for {
+				select {
+				case buf <- m:
+				default:
+					select {
+					case <-buf:
+						atomic.AddInt64(&dropped, 1)
+					default:
+					}
+					continue
+				}
+				break
+			}
+		}
+	}()
+
+	go func() {
+		for {
+			_, data, err := ws.ReadMessage()
+			if err != nil {
+				stop("客户端断开")
+				return
+			}
+			var update protos.SubscribeUpdate
+			if err := json.Unmarshal(data, &update); err != nil {
+				_ = writePubsubMsg(ws, protos.PubsubMsg{Type: "error", Payload: "订阅格式错误"})
+				continue
+			}
+			if err = checkPatterns(update.Subscribe); err == nil && len(update.Subscribe) > 0 {
+				err = sub.PSubscribe(update.Subscribe...)
+			}
+			if err == nil && len(update.Unsubscribe) > 0 {
+				err = sub.PUnsubscribe(update.Unsubscribe...)
+			}
+			if err != nil {
+				_ = writePubsubMsg(ws, protos.PubsubMsg{Type: "error", Payload: err.Error()})
+			}
+		}
+	}()
+
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case m := <-buf:
+			m.Dropped = atomic.SwapInt64(&dropped, 0)
+			if err := writePubsubMsg(ws, m); err != nil {
+				stop(err.Error())
+				break loop
+			}
+		}
+	}
+	msg, _ := reason.Load().(string)
+	if msg == "" {
+		msg = fmt.Sprintf("超过最长时间 %s", duration)
+	}
+	_ = writePubsubMsg(ws, protos.PubsubMsg{Type: "closed", Payload: msg, Dropped: atomic.LoadInt64(&dropped)})
+	_ = ws.WriteClose(websocket.CloseNormal, "")
+	return nil
+}
+
+// keyspace 通知会暴露所有 db 的 key 名, 需通过 /keyspace 按权限订阅
+const keyspaceChannelPrefix = "__key"
+
+func checkPatterns(patterns []string) error {
+	for _, p := range patterns {
+		if matchPrefix(p, keyspaceChannelPrefix) {
+			return &self_errors.PermissionError{Reason: fmt.Sprintf("不允许订阅 keyspace 通知频道 %s", p)}
+		}
+	}
+	return nil
+}
+
+// matchPrefix glob 模式是否可能匹配以 prefix 开头的频道, [] 字符集按匹配任意字符处理
+func matchPrefix(pattern, prefix string) bool {
+	for prefix != "" {
+		if pattern == "" {
+			return false
+		}
+		switch pattern[0] {
+		case '*':
+			return true
+		case '?':
+			pattern = pattern[1:]
+		case '[':
+			end := strings.IndexByte(pattern[1:], ']')
+			if end < 0 {
+				pattern = pattern[1:]
+				if prefix[0] != '[' {
+					return false
+				}
+			} else {
+				pattern = pattern[end+2:]
+			}
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			if pattern[0] != prefix[0] {
+				return false
+			}
+			pattern = pattern[1:]
+		default:
+			if pattern[0] != prefix[0] {
+				return false
+			}
+			pattern = pattern[1:]
+		}
+		prefix = prefix[1:]
+	}
+	return true
+}
+
+func splitPatterns(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func writePubsubMsg(ws *websocket.Conn, msg protos.PubsubMsg) error {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return ws.WriteText(b)
+}
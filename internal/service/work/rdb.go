@@ -0,0 +1,167 @@
+package work
+
+import (
+	"container/heap"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/fighthorse/redisAdmin/internal/pkg/rdb"
+	"github.com/fighthorse/redisAdmin/protos"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// RdbDefaultTop 默认返回 biggest key 个数
+	RdbDefaultTop = 50
+	// RdbMaxTop 最多返回 biggest key 个数
+	RdbMaxTop = 1000
+	// RdbMaxPrefix 最多返回前缀个数
+	RdbMaxPrefix = 500
+
+	// 过期时间分布
+	rdbExpiry = []struct {
+		name string
+		ttl  time.Duration
+	}{
+		{"1h", time.Hour},
+		{"1d", 24 * time.Hour},
+		{"7d", 7 * 24 * time.Hour},
+		{"30d", 30 * 24 * time.Hour},
+	}
+)
+
+// AnalyzeRdb 解析上传的 rdb 文件, 统计 biggest key/前缀/类型/过期时间分布, 大小为序列化字节数
+func AnalyzeRdb(c *gin.Context, req protos.RdbReq) (*protos.RdbReport, error) {
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return nil, errors.New("请上传 rdb 文件")
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	top := req.Top
+	if top <= 0 {
+		top = RdbDefaultTop
+	}
+	if top > RdbMaxTop {
+		top = RdbMaxTop
+	}
+	level := req.Level
+	if level <= 0 {
+		level = 1
+	} else {
+		level = level + 1
+	}
+	db := -1
+	if req.Db != "" {
+		db, _ = strconv.Atoi(req.Db)
+	}
+
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	res := &protos.RdbReport{Dbs: map[int]int64{}}
+	biggest := &rdbKeyHeap{}
+	prefix := map[string]*protos.RdbStat{}
+	types := map[string]*protos.RdbStat{}
+	expiry := map[string]*protos.RdbStat{}
+	add := func(m map[string]*protos.RdbStat, name string, e *rdb.Entry) {
+		s, ok := m[name]
+		if !ok {
+			s = &protos.RdbStat{Name: name}
+			m[name] = s
+		}
+		s.Keys++
+		s.Size += e.Size
+		s.Elements += e.Elements
+	}
+
+	err = rdb.Parse(f, fh.Size, func(e *rdb.Entry) error {
+		res.Dbs[e.Db]++
+		if db >= 0 && e.Db != db {
+			return nil
+		}
+		res.Keys++
+		res.Size += e.Size
+		// 与 ScanRedis 相同的前缀规则
+		add(prefix, keyPrefix(e.Key, level+1), e)
+		add(types, e.Type, e)
+		add(expiry, expiryBucket(e.Expire, now), e)
+
+		if biggest.Len() < top || e.Size > (*biggest)[0].Size {
+			heap.Push(biggest, protos.RdbKey{
+				Db:       e.Db,
+				Key:      e.Key,
+				Type:     e.Type,
+				Encoding: e.Encoding,
+				Size:     e.Size,
+				Elements: e.Elements,
+				Expire:   e.Expire,
+			})
+			if biggest.Len() > top {
+				heap.Pop(biggest)
+			}
+		}
+		return nil
+	})
+	// 文件不完整时返回已统计的部分
+	if err != nil {
+		if res.Keys == 0 {
+			return nil, err
+		}
+		res.Error = err.Error()
+	}
+
+	res.Biggest = []protos.RdbKey(*biggest)
+	sort.Slice(res.Biggest, func(i, k int) bool { return res.Biggest[i].Size > res.Biggest[k].Size })
+	res.Prefix = sortStats(prefix, RdbMaxPrefix)
+	res.Types = sortStats(types, 0)
+	res.Expiry = sortStats(expiry, 0)
+	return res, nil
+}
+
+func expiryBucket(expire, now int64) string {
+	if expire == 0 {
+		return "none"
+	}
+	ttl := time.Duration(expire-now) * time.Millisecond
+	if ttl <= 0 {
+		return "expired"
+	}
+	for _, v := range rdbExpiry {
+		if ttl <= v.ttl {
+			return "<" + v.name
+		}
+	}
+	return ">" + rdbExpiry[len(rdbExpiry)-1].name
+}
+
+// sortStats 按大小倒序, limit 为 0 时不限制
+func sortStats(m map[string]*protos.RdbStat, limit int) []protos.RdbStat {
+	out := make([]protos.RdbStat, 0, len(m))
+	for _, v := range m {
+		out = append(out, *v)
+	}
+	sort.Slice(out, func(i, k int) bool { return out[i].Size > out[k].Size })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+// rdbKeyHeap 按大小的最小堆, 保留 top N
+type rdbKeyHeap []protos.RdbKey
+
+func (h rdbKeyHeap) Len() int            { return len(h) }
+func (h rdbKeyHeap) Less(i, k int) bool  { return h[i].Size < h[k].Size }
+func (h rdbKeyHeap) Swap(i, k int)       { h[i], h[k] = h[k], h[i] }
+func (h *rdbKeyHeap) Push(x interface{}) { *h = append(*h, x.(protos.RdbKey)) }
+func (h *rdbKeyHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
@@ -4,11 +4,14 @@ import (
 	"errors"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/fighthorse/redisAdmin/component/thirdpart/trace_redis"
 	"github.com/fighthorse/redisAdmin/internal/pkg/redis"
 	"github.com/fighthorse/redisAdmin/protos"
 	"github.com/gin-gonic/gin"
+
+	goredis "github.com/go-redis/redis"
 )
 
 func HandleNowKey(c *gin.Context, req protos.SearchKeyReq) (interface{}, error) {
@@ -20,6 +23,9 @@ func HandleNowKey(c *gin.Context, req protos.SearchKeyReq) (interface{}, error)
 	}
 
 	if !strings.Contains(req.Key, "*") {
+		if err := CheckReadKey(c, req.Client, req.Db, req.Key); err != nil {
+			return nil, err
+		}
 		return GetNoneKey(c, req, req.Key, client.Client)
 	}
 	// 先scan 是否只有一个
@@ -27,8 +33,11 @@ func HandleNowKey(c *gin.Context, req protos.SearchKeyReq) (interface{}, error)
 	if !strings.Contains(req.Key, "*") {
 		key += "*"
 	}
-	keysPerFix, count := ScanRedis(client.Client, key, req.Level+1)
+	keysPerFix, count := ScanRedis(client.Client, key, req.Level+1, readFilter(c, req.Client, req.Db))
 	if count == 1 && !strings.Contains(keysPerFix[0], "*") {
+		if err := CheckReadKey(c, req.Client, req.Db, keysPerFix[0]); err != nil {
+			return nil, err
+		}
 		return GetNoneKey(c, req, keysPerFix[0], client.Client)
 	}
 
@@ -71,7 +80,9 @@ func GetNoneKey(c *gin.Context, req protos.SearchKeyReq, keys string, client *tr
 	if typeInfo == "none" {
 		return nil, errors.New("当前key不存在")
 	}
+	typeInfo = detectType(c.Request.Context(), req, keys, typeInfo, client)
 	res := GetKeyByType(c, req, typeInfo, keys, client)
+	res.Notify = KeyspaceEnabled(req.Client, client)
 	return res, nil
 }
 
@@ -92,7 +103,7 @@ func Search(c *gin.Context, data protos.SearchReq) (interface{}, error) {
 	} else {
 		level = level + 1
 	}
-	keysPerFix, count := ScanRedis(client.Client, match, level)
+	keysPerFix, count := ScanRedis(client.Client, match, level, readFilter(c, data.Client, data.Db))
 	out := make(map[string]int, len(keysPerFix))
 	for _, v := range keysPerFix {
 		out[v] += 1
@@ -111,41 +122,75 @@ func Search(c *gin.Context, data protos.SearchReq) (interface{}, error) {
 	return res, nil
 }
 
-func ScanRedis(client *trace_redis.RedisClient, match string, level int) ([]string, int) {
-	var cursor uint64
+// ScanRedis 扫描所有master节点, 按 level 合并key前缀, allow 不为空时只统计有读权限的 key
+func ScanRedis(client *trace_redis.RedisClient, match string, level int, allow func(key string) bool) ([]string, int) {
+	var mu sync.Mutex
 	var n int
 	var totalKeys []string
 	level += 1
+	_ = client.ForEachMaster(func(node *goredis.Client) error {
+		keys, count := scanNode(node, match, level, allow)
+		mu.Lock()
+		n += count
+		totalKeys = append(totalKeys, keys...)
+		mu.Unlock()
+		return nil
+	})
+	return totalKeys, n
+}
+
+func scanNode(node *goredis.Client, match string, level int, allow func(key string) bool) ([]string, int) {
+	var n int
+	var totalKeys []string
+	_ = scanEach(node, match, 30, func(keys []string) error {
+		for _, v := range keys {
+			if allow != nil && !allow(v) {
+				continue
+			}
+			n++
+			totalKeys = append(totalKeys, keyPrefix(v, level))
+		}
+		return nil
+	})
+	return totalKeys, n
+}
+
+// scanEach 按 count 分批 SCAN, fn 返回错误时停止
+func scanEach(node *goredis.Client, match string, count int64, fn func(keys []string) error) error {
+	var cursor uint64
 	for {
-		var keys []string
-		var err error
-		//*扫描所有key，每次20条
-		keys, cursor, err = client.Scan(cursor, match, 30).Result()
+		keys, next, err := node.Scan(cursor, match, count).Result()
 		if err != nil {
-			break
+			return err
 		}
-		n += len(keys)
-		for _, v := range keys {
-			vl := strings.Split(v, ":")
-			vll := len(vl)
-			str := ""
-			for kk, vv := range vl {
-				if kk <= level {
-					if str == "" {
-						str += vv
-					} else {
-						str += ":" + vv
-					}
-				}
-			}
-			if (vll - 1) > level {
-				str += ":*"
+		if len(keys) > 0 {
+			if err := fn(keys); err != nil {
+				return err
 			}
-			totalKeys = append(totalKeys, str)
 		}
-		if cursor == 0 {
-			break
+		if next == 0 {
+			return nil
 		}
+		cursor = next
 	}
-	return totalKeys, n
+}
+
+// keyPrefix 按 ":" 截取 level 层前缀, 更深的层级以 ":*" 结尾
+func keyPrefix(key string, level int) string {
+	vl := strings.Split(key, ":")
+	vll := len(vl)
+	str := ""
+	for kk, vv := range vl {
+		if kk <= level {
+			if str == "" {
+				str += vv
+			} else {
+				str += ":" + vv
+			}
+		}
+	}
+	if (vll - 1) > level {
+		str += ":*"
+	}
+	return str
 }
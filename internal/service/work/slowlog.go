@@ -0,0 +1,272 @@
+package work
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fighthorse/redisAdmin/internal/pkg/redis"
+	"github.com/fighthorse/redisAdmin/internal/service/login"
+	"github.com/fighthorse/redisAdmin/protos"
+	"github.com/gin-gonic/gin"
+
+	goredis "github.com/go-redis/redis"
+)
+
+var (
+	// SlowlogFetch 每次 SLOWLOG GET 的条数
+	SlowlogFetch = 128
+	// SlowlogMaxEntries 每个节点保留的记录数
+	SlowlogMaxEntries = 1024
+	// SlowlogDefaultLimit 默认返回的记录数
+	SlowlogDefaultLimit = 100
+
+	// 所有参数都是 key 的命令
+	slowlogMultiKey = map[string]bool{
+		"DEL": true, "UNLINK": true, "EXISTS": true, "TOUCH": true, "MGET": true,
+		"SDIFF": true, "SINTER": true, "SUNION": true, "PFCOUNT": true, "WATCH": true,
+	}
+	// 没有 key 的命令
+	slowlogNoKey = map[string]bool{
+		"PING": true, "INFO": true, "KEYS": true, "SCAN": true, "CONFIG": true, "CLIENT": true,
+		"FLUSHDB": true, "FLUSHALL": true, "SLOWLOG": true, "CLUSTER": true, "DBSIZE": true,
+		"EVAL": true, "EVALSHA": true, "SCRIPT": true, "MULTI": true, "EXEC": true, "SELECT": true,
+		"AUTH": true, "MONITOR": true, "PUBLISH": true, "SUBSCRIBE": true, "PSUBSCRIBE": true,
+	}
+
+	slowlogs = struct {
+		sync.Mutex
+		items map[string]map[string]*slowlogNode // instance -> node
+	}{items: map[string]map[string]*slowlogNode{}}
+)
+
+// slowlogNode 保留节点的慢日志, 服务端日志滚动后仍可查看
+type slowlogNode struct {
+	entries []protos.SlowlogEntry // 按时间倒序
+	seen    map[slowlogId]bool
+}
+
+// slowlogId 服务重启后 id 会重新计数, 与时间一起去重
+type slowlogId struct {
+	id   int64
+	time int64
+}
+
+func (n *slowlogNode) merge(list []protos.SlowlogEntry) {
+	var added []protos.SlowlogEntry
+	for _, v := range list {
+		id := slowlogId{v.Id, v.Time}
+		if n.seen[id] {
+			continue
+		}
+		n.seen[id] = true
+		added = append(added, v)
+	}
+	if len(added) == 0 {
+		return
+	}
+	n.entries = append(added, n.entries...)
+	sort.SliceStable(n.entries, func(i, k int) bool { return n.entries[i].Time > n.entries[k].Time })
+	for len(n.entries) > SlowlogMaxEntries {
+		last := n.entries[len(n.entries)-1]
+		delete(n.seen, slowlogId{last.Id, last.Time})
+		n.entries = n.entries[:len(n.entries)-1]
+	}
+}
+
+// Slowlog 获取各 master 节点的 SLOWLOG 并与已保留的记录合并, 按模板聚合
+func Slowlog(c *gin.Context, req protos.SlowlogReq) (*protos.SlowlogRes, error) {
+	client := redis.LoadOthersDB(req.Client, 0)
+	if client == nil {
+		return nil, errors.New("redis client create error")
+	}
+	res := &protos.SlowlogRes{}
+	var mu sync.Mutex
+	fetched := map[string][]protos.SlowlogEntry{}
+	_ = client.Client.ForEachMaster(func(node *goredis.Client) error {
+		addr := node.Options().Addr
+		reply, err := node.Do("slowlog", "get", SlowlogFetch).Result()
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			res.Errors = append(res.Errors, addr+": "+err.Error())
+			return nil
+		}
+		fetched[addr] = parseSlowlog(addr, reply)
+		return nil
+	})
+
+	slowlogs.Lock()
+	nodes := slowlogs.items[req.Client]
+	if nodes == nil {
+		nodes = map[string]*slowlogNode{}
+		slowlogs.items[req.Client] = nodes
+	}
+	var all []protos.SlowlogEntry
+	for addr, list := range fetched {
+		n, ok := nodes[addr]
+		if !ok {
+			n = &slowlogNode{seen: map[slowlogId]bool{}}
+			nodes[addr] = n
+		}
+		n.merge(list)
+	}
+	for _, n := range nodes {
+		all = append(all, n.entries...)
+	}
+	slowlogs.Unlock()
+
+	user := currentUserName(c)
+	stats := map[string]*protos.SlowlogStat{}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = SlowlogDefaultLimit
+	}
+	sort.SliceStable(all, func(i, k int) bool { return all[i].Time > all[k].Time })
+	for _, v := range all {
+		if !slowlogAllowed(user, req.Client, v.Args) {
+			continue
+		}
+		v.Template = slowlogTemplate(v.Args, req.Level)
+		st, ok := stats[v.Template]
+		if !ok {
+			st = &protos.SlowlogStat{Template: v.Template}
+			stats[v.Template] = st
+		}
+		st.Count++
+		st.Total += v.Duration
+		if v.Duration > st.Max {
+			st.Max = v.Duration
+		}
+		if v.Time > st.Last {
+			st.Last = v.Time
+		}
+		if len(res.Entries) < limit {
+			res.Entries = append(res.Entries, v)
+		}
+	}
+	for _, st := range stats {
+		st.Avg = st.Total / st.Count
+		res.Stats = append(res.Stats, *st)
+	}
+	sort.Slice(res.Stats, func(i, k int) bool { return res.Stats[i].Total > res.Stats[k].Total })
+	return res, nil
+}
+
+// SlowlogReset 清空各 master 节点的 SLOWLOG, clear 时同时清除已保留的记录
+func SlowlogReset(c *gin.Context, req protos.SlowlogReq) (interface{}, error) {
+	client := redis.LoadOthersDB(req.Client, 0)
+	if client == nil {
+		return nil, errors.New("redis client create error")
+	}
+	err := client.Client.ForEachMaster(func(node *goredis.Client) error {
+		return node.Do("slowlog", "reset").Err()
+	})
+	if err == nil && req.Clear {
+		slowlogs.Lock()
+		delete(slowlogs.items, req.Client)
+		slowlogs.Unlock()
+	}
+	handle := protos.SearchKeyReq{Client: req.Client, Type: "SLOWLOG_RESET"}
+	Audit(c, handle, 0, nil, err)
+	return nil, err
+}
+
+// parseSlowlog id, timestamp, duration, args, [client addr, client name]
+func parseSlowlog(node string, reply interface{}) []protos.SlowlogEntry {
+	list, _ := reply.([]interface{})
+	out := make([]protos.SlowlogEntry, 0, len(list))
+	for _, v := range list {
+		row, _ := v.([]interface{})
+		if len(row) < 4 {
+			continue
+		}
+		entry := protos.SlowlogEntry{
+			Id:       toInt64(row[0]),
+			Node:     node,
+			Time:     toInt64(row[1]),
+			Duration: toInt64(row[2]),
+		}
+		args, _ := row[3].([]interface{})
+		for _, arg := range args {
+			entry.Args = append(entry.Args, toString(arg))
+		}
+		if len(row) >= 6 {
+			entry.Addr = toString(row[4])
+			entry.ClientName = toString(row[5])
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
+// slowlogKeys 命令中的 key, 只识别第一个参数为 key 和全部参数为 key 的命令
+func slowlogKeys(args []string) []string {
+	if len(args) < 2 {
+		return nil
+	}
+	cmd := strings.ToUpper(args[0])
+	switch {
+	case slowlogNoKey[cmd]:
+		return nil
+	case slowlogMultiKey[cmd]:
+		return args[1:]
+	case cmd == "MSET" || cmd == "MSETNX":
+		var keys []string
+		for i := 1; i < len(args); i += 2 {
+			keys = append(keys, args[i])
+		}
+		return keys
+	}
+	return args[1:2]
+}
+
+// slowlogTemplate key 替换为 keyPrefix 前缀, 其余参数替换为 ?
+func slowlogTemplate(args []string, level int) string {
+	if len(args) == 0 {
+		return ""
+	}
+	cmd := strings.ToUpper(args[0])
+	keys := map[int]bool{}
+	switch {
+	case slowlogNoKey[cmd]:
+	case slowlogMultiKey[cmd]:
+		for i := 1; i < len(args); i++ {
+			keys[i] = true
+		}
+	case cmd == "MSET" || cmd == "MSETNX":
+		for i := 1; i < len(args); i += 2 {
+			keys[i] = true
+		}
+	default:
+		keys[1] = true
+	}
+
+	out := []string{cmd}
+	// 子命令保留, 如 CONFIG GET
+	start := 1
+	if slowlogNoKey[cmd] && len(args) > 1 && cmd != "EVAL" && cmd != "EVALSHA" {
+		out = append(out, strings.ToUpper(args[1]))
+		start = 2
+	}
+	for i := start; i < len(args); i++ {
+		if keys[i] {
+			out = append(out, keyPrefix(args[i], level))
+		} else if out[len(out)-1] != "?" && out[len(out)-1] != "..." {
+			out = append(out, "?")
+		} else if out[len(out)-1] == "?" {
+			out[len(out)-1] = "..."
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+func slowlogAllowed(user, instance string, args []string) bool {
+	for _, key := range slowlogKeys(args) {
+		if login.CheckAccess(user, instance, "", key, false) != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,90 @@
+package work
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fighthorse/redisAdmin/protos"
+)
+
+func TestSlowlogTemplate(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		level int
+		want  string
+	}{
+		{name: "empty", args: nil, want: ""},
+		{name: "no args", args: []string{"ping"}, want: "PING"},
+		{name: "key prefix level 0", args: []string{"GET", "user:1:name"}, level: 0, want: "GET user:*"},
+		{name: "key prefix level 1", args: []string{"get", "user:1:name"}, level: 1, want: "GET user:1:*"},
+		{name: "short key kept", args: []string{"SET", "a:b", "v"}, level: 1, want: "SET a:b ?"},
+		{name: "args collapsed", args: []string{"set", "k", "v", "EX", "10"}, want: "SET k ..."},
+		{name: "multi key", args: []string{"DEL", "a:1", "b:2"}, want: "DEL a:* b:*"},
+		{name: "mset", args: []string{"MSET", "a:1", "x", "b:2", "y"}, want: "MSET a:* ? b:* ?"},
+		{name: "subcommand kept", args: []string{"config", "get", "maxmemory"}, want: "CONFIG GET ?"},
+		{name: "eval has no subcommand", args: []string{"EVAL", "return 1", "0"}, want: "EVAL ..."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := slowlogTemplate(tt.args, tt.level); got != tt.want {
+				t.Errorf("slowlogTemplate(%q, %d) = %q, want %q", tt.args, tt.level, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlowlogKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{name: "no args", args: []string{"GET"}, want: nil},
+		{name: "no key command", args: []string{"CONFIG", "GET", "x"}, want: nil},
+		{name: "single key", args: []string{"HGET", "h", "f"}, want: []string{"h"}},
+		{name: "multi key", args: []string{"del", "a", "b"}, want: []string{"a", "b"}},
+		{name: "mset", args: []string{"MSET", "a", "1", "b", "2"}, want: []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := slowlogKeys(tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("slowlogKeys(%q) = %q, want %q", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSlowlog(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply interface{}
+		want  []protos.SlowlogEntry
+	}{
+		{name: "nil", reply: nil, want: []protos.SlowlogEntry{}},
+		{name: "short row skipped", reply: []interface{}{[]interface{}{int64(1), int64(2)}}, want: []protos.SlowlogEntry{}},
+		{
+			name:  "redis 3",
+			reply: []interface{}{[]interface{}{int64(7), int64(1700000000), int64(15000), []interface{}{"KEYS", "*"}}},
+			want:  []protos.SlowlogEntry{{Id: 7, Node: "n1", Time: 1700000000, Duration: 15000, Args: []string{"KEYS", "*"}}},
+		},
+		{
+			name: "redis 4 client info",
+			reply: []interface{}{
+				[]interface{}{int64(9), int64(1700000001), int64(20000), []interface{}{"GET", "k"}, "127.0.0.1:5000", "app"},
+				[]interface{}{int64(8), int64(1700000000), int64(10000), []interface{}{"DEL", "a", "b"}, "127.0.0.1:5001", ""},
+			},
+			want: []protos.SlowlogEntry{
+				{Id: 9, Node: "n1", Time: 1700000001, Duration: 20000, Args: []string{"GET", "k"}, Addr: "127.0.0.1:5000", ClientName: "app"},
+				{Id: 8, Node: "n1", Time: 1700000000, Duration: 10000, Args: []string{"DEL", "a", "b"}, Addr: "127.0.0.1:5001"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseSlowlog("n1", tt.reply); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSlowlog() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
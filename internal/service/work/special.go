@@ -0,0 +1,224 @@
+package work
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fighthorse/redisAdmin/component/thirdpart/trace_redis"
+	"github.com/fighthorse/redisAdmin/protos"
+	"github.com/gin-gonic/gin"
+
+	goredis "github.com/go-redis/redis"
+)
+
+var (
+	// BitmapPageBytes bitmap 每页字节数
+	BitmapPageBytes int64 = 64
+)
+
+// detectType bitmap/hyperloglog 存储为 string, geo 存储为 zset, 按页面选择的查看方式展示
+// 未指定时只识别带 HYLL 头的 hyperloglog, 其他数据无法可靠区分
+func detectType(ctx context.Context, req protos.SearchKeyReq, keys, typeInfo string, client *trace_redis.RedisClient) string {
+	switch {
+	case typeInfo == "string" && (req.Hint == "bitmap" || req.Hint == "hyperloglog"):
+		return req.Hint
+	case typeInfo == "zset" && req.Hint == "geo":
+		return req.Hint
+	}
+
+	if typeInfo == "string" {
+		head, _ := client.GetRange(ctx, keys, 0, 3)
+		if head == "HYLL" {
+			return "hyperloglog"
+		}
+	}
+	return typeInfo
+}
+
+// HandleSpecial bitmap/hyperloglog/geo 相关操作
+func HandleSpecial(c *gin.Context, req protos.SearchKeyReq, client *trace_redis.RedisClient) (interface{}, error) {
+	ctx := c.Request.Context()
+	out := protos.KeysInfo{
+		Keys: req.Key,
+		Type: "msg",
+	}
+	switch req.Type {
+	case "BITCOUNT":
+		return GetKeyByType(c, req, "bitmap", req.Key, client), nil
+	case "SETBIT":
+		ll := strings.Fields(req.Value)
+		if len(ll) != 2 {
+			return nil, errors.New("格式: offset bit")
+		}
+		offset, err1 := strconv.ParseInt(ll[0], 10, 64)
+		bit, err2 := strconv.Atoi(ll[1])
+		if err1 != nil || err2 != nil || offset < 0 || (bit != 0 && bit != 1) {
+			return nil, errors.New("offset/bit 不正确")
+		}
+		old, err := client.SetBit(ctx, req.Key, offset, bit)
+		out.Data = HandleErrMsg(fmt.Sprintf("设置位:%d 原值:%d", offset, old), err)
+		return out, err
+	case "GETBIT":
+		offset, err := strconv.ParseInt(strings.TrimSpace(req.Value), 10, 64)
+		if err != nil || offset < 0 {
+			return nil, errors.New("offset 不正确")
+		}
+		bit, err := client.GetBit(ctx, req.Key, offset)
+		out.Data = HandleErrMsg(fmt.Sprintf("位:%d 值:%d", offset, bit), err)
+		return out, err
+	case "PFCOUNT":
+		return GetKeyByType(c, req, "hyperloglog", req.Key, client), nil
+	case "PFADD":
+		var vals []interface{}
+		for _, v := range splitIds(req.Value) {
+			vals = append(vals, v)
+		}
+		if len(vals) == 0 {
+			return nil, errors.New("数据不正确")
+		}
+		n, err := client.PFAdd(ctx, req.Key, vals...)
+		out.Data = HandleErrMsg(fmt.Sprintf("基数变化:%d", n), err)
+		return out, err
+	case "PFMERGE":
+		src := splitIds(req.Value)
+		if len(src) == 0 {
+			return nil, errors.New("来源key不能为空")
+		}
+		// 合并结果会暴露来源 key 的基数
+		for _, v := range src {
+			if err := CheckReadKey(c, req.Client, req.Db, v); err != nil {
+				return nil, err
+			}
+		}
+		ss, err := client.PFMerge(ctx, req.Key, src...)
+		out.Data = HandleErrMsg(fmt.Sprintf("合并状态:%s", ss), err)
+		return out, err
+	case "GEOPOS":
+		return GetKeyByType(c, req, "geo", req.Key, client), nil
+	case "GEOADD":
+		var locations []*goredis.GeoLocation
+		for _, v := range strings.Split(req.Value, ",") {
+			ll := strings.Fields(v)
+			if len(ll) != 3 {
+				continue
+			}
+			lng, err1 := strconv.ParseFloat(ll[0], 64)
+			lat, err2 := strconv.ParseFloat(ll[1], 64)
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			locations = append(locations, &goredis.GeoLocation{Name: ll[2], Longitude: lng, Latitude: lat})
+		}
+		if len(locations) == 0 {
+			return nil, errors.New("格式: 经度 纬度 成员")
+		}
+		n, err := client.GeoAdd(ctx, req.Key, locations...)
+		out.Data = HandleErrMsg(fmt.Sprintf("添加位置:%d个", n), err)
+		return out, err
+	case "GEOSEARCH":
+		return geoSearch(ctx, req, client)
+	}
+	return nil, nil
+}
+
+// getBitmap 按 BitmapPageBytes 分页展示位
+func getBitmap(ctx context.Context, req protos.SearchKeyReq, keys string, client *trace_redis.RedisClient, res *protos.KeysInfo) {
+	size, _ := client.StrLen(ctx, keys)
+	start := int64(res.Page) * BitmapPageBytes
+	end := start + BitmapPageBytes - 1
+
+	info := &protos.BitmapInfo{
+		Size:   size * 8,
+		Offset: start * 8,
+	}
+	info.Count, _ = client.BitCount(ctx, keys)
+	info.PageCount, _ = client.BitCount(ctx, keys, start, end)
+	info.FirstSet, _ = client.BitPos(ctx, keys, 1)
+	info.FirstClear, _ = client.BitPos(ctx, keys, 0)
+
+	raw, _ := client.GetRange(ctx, keys, start, end)
+	var bits strings.Builder
+	for i := 0; i < len(raw); i++ {
+		bits.WriteString(fmt.Sprintf("%08b", raw[i]))
+	}
+	info.Bits = bits.String()
+
+	res.Bitmap = info
+	res.Length = info.Size
+	res.Value = fmt.Sprintf("%d", info.Count)
+	res.Total = int(size / BitmapPageBytes)
+}
+
+// getGeo 分页展示成员坐标
+func getGeo(ctx context.Context, req protos.SearchKeyReq, keys string, client *trace_redis.RedisClient, res *protos.KeysInfo) {
+	res.Length, _ = client.ZCard(ctx, keys)
+	start := int64(res.Page) * DataPageSize
+	members, _ := client.ZRange(keys, start, start+DataPageSize-1).Result()
+	if len(members) == 0 {
+		return
+	}
+	pos, _ := client.GeoPos(ctx, keys, members...)
+	for k, v := range members {
+		item := protos.GeoRes{Member: v}
+		if k < len(pos) && pos[k] != nil {
+			item.Longitude = pos[k].Longitude
+			item.Latitude = pos[k].Latitude
+		}
+		res.Geo = append(res.Geo, item)
+	}
+	res.Total = int(res.Length / DataPageSize)
+}
+
+// geoSearch value 格式: "经度 纬度 半径 [单位]" 或 "成员 半径 [单位]"
+func geoSearch(ctx context.Context, req protos.SearchKeyReq, client *trace_redis.RedisClient) (interface{}, error) {
+	ll := strings.Fields(req.Value)
+	var args []interface{}
+	var rest []string
+	if len(ll) >= 3 {
+		if _, err := strconv.ParseFloat(ll[2], 64); err == nil {
+			args = append(args, "FROMLONLAT", ll[0], ll[1])
+			rest = ll[2:]
+		}
+	}
+	if args == nil && len(ll) >= 2 {
+		args = append(args, "FROMMEMBER", ll[0])
+		rest = ll[1:]
+	}
+	if args == nil {
+		return nil, errors.New("格式: 经度 纬度 半径 [单位] 或 成员 半径 [单位]")
+	}
+	unit := "m"
+	if len(rest) > 1 {
+		unit = rest[1]
+	}
+	args = append(args, "BYRADIUS", rest[0], unit, "ASC", "COUNT", DataPageSize, "WITHCOORD", "WITHDIST")
+
+	reply, err := client.GeoSearch(ctx, req.Key, args...)
+	if err != nil {
+		return nil, err
+	}
+	res := protos.KeysInfo{
+		Keys: req.Key,
+		Type: "geo",
+	}
+	list, _ := reply.([]interface{})
+	for _, v := range list {
+		// member, dist, [lng, lat]
+		row, _ := v.([]interface{})
+		if len(row) < 3 {
+			continue
+		}
+		item := protos.GeoRes{Member: toString(row[0])}
+		item.Dist, _ = strconv.ParseFloat(toString(row[1]), 64)
+		if coord, ok := row[2].([]interface{}); ok && len(coord) == 2 {
+			item.Longitude, _ = strconv.ParseFloat(toString(coord[0]), 64)
+			item.Latitude, _ = strconv.ParseFloat(toString(coord[1]), 64)
+		}
+		res.Geo = append(res.Geo, item)
+	}
+	res.Length = int64(len(res.Geo))
+	return res, nil
+}
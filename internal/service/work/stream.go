@@ -0,0 +1,232 @@
+package work
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fighthorse/redisAdmin/component/thirdpart/trace_redis"
+	"github.com/fighthorse/redisAdmin/protos"
+	"github.com/gin-gonic/gin"
+
+	goredis "github.com/go-redis/redis"
+)
+
+// HandleStream stream 相关操作
+func HandleStream(c *gin.Context, req protos.SearchKeyReq, client *trace_redis.RedisClient) (interface{}, error) {
+	ctx := c.Request.Context()
+	out := protos.KeysInfo{
+		Keys: req.Key,
+		Type: "msg",
+	}
+	switch req.Type {
+	case "XRANGE", "XREVRANGE", "XINFO":
+		return GetKeyByType(c, req, "stream", req.Key, client), nil
+	case "XPENDING":
+		if req.Group == "" {
+			return nil, errors.New("消费组不能为空")
+		}
+		res := GetKeyByType(c, req, "stream", req.Key, client)
+		pending, err := client.XPendingExt(ctx, &goredis.XPendingExtArgs{
+			Stream:   req.Key,
+			Group:    req.Group,
+			Start:    streamId(req.Start, "-"),
+			End:      streamId(req.End, "+"),
+			Count:    DataPageSize,
+			Consumer: req.Consumer,
+		})
+		for _, v := range pending {
+			res.Pending = append(res.Pending, protos.StreamPending{
+				Id:         v.Id,
+				Consumer:   v.Consumer,
+				Idle:       int64(v.Idle / time.Millisecond),
+				RetryCount: v.RetryCount,
+			})
+		}
+		return res, err
+	case "XADD":
+		values := parseFieldValues(req.Value)
+		if len(values) == 0 {
+			return nil, errors.New("数据不正确")
+		}
+		id, err := client.XAdd(ctx, &goredis.XAddArgs{
+			Stream: req.Key,
+			ID:     streamId(req.Start, "*"),
+			Values: values,
+		})
+		out.Data = HandleErrMsg(fmt.Sprintf("添加消息:%s", id), err)
+		return out, err
+	case "XDEL":
+		n, err := client.XDel(ctx, req.Key, splitIds(req.Value)...)
+		out.Data = HandleErrMsg(fmt.Sprintf("删除消息:%d个", n), err)
+		return out, err
+	case "XTRIM":
+		maxLen, err := strconv.ParseInt(strings.TrimSpace(req.Value), 10, 64)
+		if err != nil || maxLen < 0 {
+			return nil, errors.New("MAXLEN 不正确")
+		}
+		n, err := client.XTrim(ctx, req.Key, maxLen)
+		out.Data = HandleErrMsg(fmt.Sprintf("裁剪消息:%d个", n), err)
+		return out, err
+	case "XACK":
+		if req.Group == "" {
+			return nil, errors.New("消费组不能为空")
+		}
+		n, err := client.XAck(ctx, req.Key, req.Group, splitIds(req.Value)...)
+		out.Data = HandleErrMsg(fmt.Sprintf("确认消息:%d个", n), err)
+		return out, err
+	case "XCLAIM":
+		if req.Group == "" || req.Consumer == "" {
+			return nil, errors.New("消费组/消费者不能为空")
+		}
+		msgs, err := client.XClaim(ctx, &goredis.XClaimArgs{
+			Stream:   req.Key,
+			Group:    req.Group,
+			Consumer: req.Consumer,
+			MinIdle:  time.Duration(req.Idle) * time.Millisecond,
+			Messages: splitIds(req.Value),
+		})
+		out.Data = HandleErrMsg(fmt.Sprintf("转移消息:%d个", len(msgs)), err)
+		return out, err
+	}
+	return nil, nil
+}
+
+// getStream 按ID游标分页读取 stream, XREVRANGE 时倒序
+func getStream(ctx context.Context, req protos.SearchKeyReq, keys string, client *trace_redis.RedisClient, res *protos.KeysInfo) {
+	res.Length, _ = client.XLen(ctx, keys)
+	res.Value = fmt.Sprintf("%d", res.Length)
+
+	var msgs []goredis.XMessage
+	if req.Type == "XREVRANGE" {
+		msgs, _ = client.XRevRangeN(ctx, keys, streamId(req.End, "+"), streamId(req.Start, "-"), DataPageSize+1)
+	} else {
+		msgs, _ = client.XRangeN(ctx, keys, streamId(req.Start, "-"), streamId(req.End, "+"), DataPageSize+1)
+	}
+	// 多取一条作为下一页游标
+	if int64(len(msgs)) > DataPageSize {
+		res.Next = msgs[DataPageSize].ID
+		msgs = msgs[:DataPageSize]
+	}
+	for _, v := range msgs {
+		res.Stream = append(res.Stream, protos.StreamEntry{
+			Id:     v.ID,
+			Values: v.Values,
+		})
+	}
+	res.Total = int(res.Length / DataPageSize)
+	res.StreamInfo = getStreamInfo(ctx, keys, client)
+}
+
+// getStreamInfo XINFO STREAM/GROUPS/CONSUMERS
+func getStreamInfo(ctx context.Context, keys string, client *trace_redis.RedisClient) *protos.StreamInfo {
+	reply, err := client.XInfo(ctx, "stream", keys)
+	if err != nil {
+		return nil
+	}
+	kv := flatToMap(reply)
+	info := &protos.StreamInfo{
+		Length:          toInt64(kv["length"]),
+		LastGeneratedId: toString(kv["last-generated-id"]),
+		FirstId:         entryId(kv["first-entry"]),
+		LastId:          entryId(kv["last-entry"]),
+	}
+
+	groups, _ := client.XInfo(ctx, "groups", keys)
+	list, _ := groups.([]interface{})
+	for _, g := range list {
+		gkv := flatToMap(g)
+		group := protos.StreamGroup{
+			Name:            toString(gkv["name"]),
+			Consumers:       toInt64(gkv["consumers"]),
+			Pending:         toInt64(gkv["pending"]),
+			LastDeliveredId: toString(gkv["last-delivered-id"]),
+		}
+		consumers, _ := client.XInfo(ctx, "consumers", keys, group.Name)
+		clist, _ := consumers.([]interface{})
+		for _, cc := range clist {
+			ckv := flatToMap(cc)
+			group.ConsumerList = append(group.ConsumerList, protos.StreamConsumer{
+				Name:    toString(ckv["name"]),
+				Pending: toInt64(ckv["pending"]),
+				Idle:    toInt64(ckv["idle"]),
+			})
+		}
+		info.Groups = append(info.Groups, group)
+	}
+	return info
+}
+
+// parseFieldValues "f1 v1,f2 v2" 格式解析
+func parseFieldValues(value string) map[string]interface{} {
+	out := make(map[string]interface{})
+	for _, vv := range strings.Split(value, ",") {
+		ll := strings.SplitN(strings.TrimSpace(vv), " ", 2)
+		if len(ll) != 2 || ll[0] == "" {
+			continue
+		}
+		out[ll[0]] = ll[1]
+	}
+	return out
+}
+
+func splitIds(value string) []string {
+	var out []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func streamId(id, def string) string {
+	if id == "" {
+		return def
+	}
+	return id
+}
+
+// flatToMap 将 [k1, v1, k2, v2] 形式的回复转成 map
+func flatToMap(v interface{}) map[string]interface{} {
+	list, _ := v.([]interface{})
+	out := make(map[string]interface{}, len(list)/2)
+	for i := 0; i+1 < len(list); i += 2 {
+		out[toString(list[i])] = list[i+1]
+	}
+	return out
+}
+
+func entryId(v interface{}) string {
+	entry, _ := v.([]interface{})
+	if len(entry) == 0 {
+		return ""
+	}
+	return toString(entry[0])
+}
+
+func toString(v interface{}) string {
+	switch vv := v.(type) {
+	case string:
+		return vv
+	case int64:
+		return strconv.FormatInt(vv, 10)
+	case nil:
+		return ""
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func toInt64(v interface{}) int64 {
+	switch vv := v.(type) {
+	case int64:
+		return vv
+	case string:
+		n, _ := strconv.ParseInt(vv, 10, 64)
+		return n
+	}
+	return 0
+}
@@ -0,0 +1,205 @@
+package work
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fighthorse/redisAdmin/component/conf"
+	"github.com/fighthorse/redisAdmin/component/thirdpart/trace_redis"
+	"github.com/fighthorse/redisAdmin/internal/pkg/redis"
+	"github.com/fighthorse/redisAdmin/internal/service/login"
+	"github.com/fighthorse/redisAdmin/protos"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// trashTypes 执行前需要保存快照的操作
+	trashTypes = map[string]bool{
+		"DEL": true, "SET": true, "HSET": true, "SREM": true, "ZREM": true,
+		"LSET": true, "LREM": true, "LDEL": true, "LTRIM": true, "LPOP": true, "RPOP": true,
+	}
+
+	trash = &trashStore{}
+)
+
+type trashEntry struct {
+	protos.TrashItem
+	dump    string
+	created time.Time
+}
+
+// trashStore 按条数/大小/保留时长限制的快照回收站
+type trashStore struct {
+	mux   sync.Mutex
+	seq   int64
+	size  int64
+	items []*trashEntry
+}
+
+func trashLimits() (int, int64, time.Duration) {
+	cfg := conf.GConfig.Trash
+	maxEntries, maxSize, retention := cfg.MaxEntries, cfg.MaxSize, time.Duration(cfg.Retention*float64(time.Second))
+	if maxEntries <= 0 {
+		maxEntries = 500
+	}
+	if maxSize <= 0 {
+		maxSize = 64 << 20
+	}
+	if retention <= 0 {
+		retention = 24 * time.Hour
+	}
+	return maxEntries, maxSize, retention
+}
+
+func (t *trashStore) add(entry *trashEntry) {
+	maxEntries, maxSize, _ := trashLimits()
+	if entry.Size > maxSize {
+		return
+	}
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	t.expire()
+	t.seq++
+	entry.Id = t.seq
+	t.items = append(t.items, entry)
+	t.size += entry.Size
+	for len(t.items) > maxEntries || t.size > maxSize {
+		t.size -= t.items[0].Size
+		t.items = t.items[1:]
+	}
+}
+
+// expire 删除超过保留时长的快照, 需持有锁
+func (t *trashStore) expire() {
+	_, _, retention := trashLimits()
+	n := 0
+	for n < len(t.items) && time.Since(t.items[n].created) > retention {
+		t.size -= t.items[n].Size
+		n++
+	}
+	t.items = t.items[n:]
+}
+
+func (t *trashStore) list() []*trashEntry {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	t.expire()
+	out := make([]*trashEntry, len(t.items))
+	copy(out, t.items)
+	return out
+}
+
+func (t *trashStore) get(id int64) *trashEntry {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	t.expire()
+	for _, v := range t.items {
+		if v.Id == id {
+			return v
+		}
+	}
+	return nil
+}
+
+func (t *trashStore) remove(id int64) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	for k, v := range t.items {
+		if v.Id == id {
+			t.size -= v.Size
+			t.items = append(t.items[:k], t.items[k+1:]...)
+			return
+		}
+	}
+}
+
+// snapshot 修改前保存 DUMP + PTTL, key 不存在时不保存
+func snapshot(c *gin.Context, req protos.SearchKeyReq, client *trace_redis.RedisClient) {
+	ctx := c.Request.Context()
+	dump, err := client.Dump(ctx, req.Key)
+	if err != nil {
+		return
+	}
+	var ttl int64
+	if pttl, _ := client.PTTL(ctx, req.Key); pttl > 0 {
+		ttl = int64(pttl / time.Millisecond)
+	}
+	now := time.Now()
+	trash.add(&trashEntry{
+		TrashItem: protos.TrashItem{
+			User:      currentUserName(c),
+			Instance:  req.Client,
+			Db:        req.Db,
+			Command:   req.Type,
+			Key:       req.Key,
+			Ttl:       ttl,
+			Size:      int64(len(dump)),
+			CreatedAt: now.Format("2006-01-02 15:04:05"),
+		},
+		dump:    dump,
+		created: now,
+	})
+}
+
+// ListTrash 当前用户的快照, admin 可查看全部, 最新的在前
+func ListTrash(c *gin.Context, req protos.TrashReq) ([]protos.TrashItem, error) {
+	user := currentUserName(c)
+	isAdmin := login.HasRole(user, login.RoleAdmin)
+	items := trash.list()
+	out := make([]protos.TrashItem, 0, len(items))
+	for i := len(items) - 1; i >= 0; i-- {
+		v := items[i]
+		if !isAdmin && v.User != user {
+			continue
+		}
+		if req.Client != "" && v.Instance != req.Client {
+			continue
+		}
+		if req.Db != "" && v.Db != req.Db {
+			continue
+		}
+		out = append(out, v.TrashItem)
+	}
+	return out, nil
+}
+
+// RestoreTrash 使用 RESTORE REPLACE 恢复快照
+func RestoreTrash(c *gin.Context, req protos.TrashReq) (interface{}, error) {
+	entry := trash.get(req.Id)
+	if entry == nil {
+		return nil, errors.New("快照不存在或已过期")
+	}
+	user := currentUserName(c)
+	if entry.User != user && !login.HasRole(user, login.RoleAdmin) {
+		return nil, errors.New("只能恢复自己的修改")
+	}
+	handle := protos.SearchKeyReq{
+		Client: entry.Instance,
+		Db:     entry.Db,
+		Type:   "RESTORE",
+		Key:    entry.Key,
+	}
+	if err := CheckWrite(c, handle.Client, handle.Db, handle.Key); err != nil {
+		return nil, err
+	}
+	db, _ := strconv.Atoi(entry.Db)
+	client := redis.LoadOthersDB(entry.Instance, db)
+	if client == nil {
+		return nil, errors.New("redis client create error")
+	}
+	oldSize := keySize(c, client.Client, entry.Key)
+	ss, err := client.Client.RestoreReplace(c.Request.Context(), entry.Key, time.Duration(entry.Ttl)*time.Millisecond, entry.dump)
+	out := protos.KeysInfo{
+		Keys: entry.Key,
+		Type: "msg",
+		Data: HandleErrMsg("恢复状态:"+ss, err),
+	}
+	Audit(c, handle, oldSize, out, err)
+	if err != nil {
+		return nil, err
+	}
+	trash.remove(entry.Id)
+	return out, nil
+}
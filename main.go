@@ -15,6 +15,7 @@ import (
 	"github.com/fighthorse/redisAdmin/controller"
 	"github.com/fighthorse/redisAdmin/internal/pkg/httpserver"
 	"github.com/fighthorse/redisAdmin/internal/pkg/redis"
+	"github.com/fighthorse/redisAdmin/internal/service/monitor"
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/viper"
@@ -61,6 +62,8 @@ func main() {
 	component.InitComponent()
 	// redis
 	redis.Init()
+	// INFO 采集
+	monitor.Init()
 	// http
 	httpserver.Init()
 	// start server
@@ -110,6 +113,8 @@ func InnerListener(listener net.Listener) {
 		mux.HandleFunc("/metrics", func(writer http.ResponseWriter, request *http.Request) {
 			promhttp.Handler().ServeHTTP(writer, request)
 		})
+		// exporter 多目标模式
+		mux.Handle("/scrape", monitor.ScrapeHandler())
 		err := http.Serve(listener, mux)
 		if err != nil {
 			panic(err)
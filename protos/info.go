@@ -0,0 +1,144 @@
+package protos
+
+// RedisInfo INFO all 解析结果, Raw 保留全部原始字段
+type RedisInfo struct {
+	Server       InfoServer                   `form:"server" json:"server" mapstructure:"server"`
+	Clients      InfoClients                  `form:"clients" json:"clients" mapstructure:"clients"`
+	Memory       InfoMemory                   `form:"memory" json:"memory" mapstructure:"memory"`
+	Persistence  InfoPersistence              `form:"persistence" json:"persistence" mapstructure:"persistence"`
+	Stats        InfoStats                    `form:"stats" json:"stats" mapstructure:"stats"`
+	Replication  InfoReplication              `form:"replication" json:"replication" mapstructure:"replication"`
+	Keyspace     map[string]InfoKeyspace      `form:"keyspace" json:"keyspace" mapstructure:"keyspace"`
+	Commandstats map[string]InfoCommand       `form:"commandstats" json:"commandstats" mapstructure:"commandstats"`
+	Latencystats map[string]InfoLatency       `form:"latencystats" json:"latencystats" mapstructure:"latencystats"`
+	Raw          map[string]map[string]string `form:"raw" json:"raw" mapstructure:"raw"`
+}
+
+type InfoServer struct {
+	RedisVersion    string `form:"redis_version" json:"redis_version" mapstructure:"redis_version"`
+	RedisMode       string `form:"redis_mode" json:"redis_mode" mapstructure:"redis_mode"`
+	Os              string `form:"os" json:"os" mapstructure:"os"`
+	ArchBits        int    `form:"arch_bits" json:"arch_bits" mapstructure:"arch_bits"`
+	ProcessId       int64  `form:"process_id" json:"process_id" mapstructure:"process_id"`
+	RunId           string `form:"run_id" json:"run_id" mapstructure:"run_id"`
+	TcpPort         int    `form:"tcp_port" json:"tcp_port" mapstructure:"tcp_port"`
+	UptimeInSeconds int64  `form:"uptime_in_seconds" json:"uptime_in_seconds" mapstructure:"uptime_in_seconds"`
+	Hz              int    `form:"hz" json:"hz" mapstructure:"hz"`
+	ConfigFile      string `form:"config_file" json:"config_file" mapstructure:"config_file"`
+}
+
+type InfoClients struct {
+	ConnectedClients int64 `form:"connected_clients" json:"connected_clients" mapstructure:"connected_clients"`
+	BlockedClients   int64 `form:"blocked_clients" json:"blocked_clients" mapstructure:"blocked_clients"`
+	TrackingClients  int64 `form:"tracking_clients" json:"tracking_clients" mapstructure:"tracking_clients"`
+	Maxclients       int64 `form:"maxclients" json:"maxclients" mapstructure:"maxclients"`
+}
+
+type InfoMemory struct {
+	UsedMemory            int64   `form:"used_memory" json:"used_memory" mapstructure:"used_memory"`
+	UsedMemoryRss         int64   `form:"used_memory_rss" json:"used_memory_rss" mapstructure:"used_memory_rss"`
+	UsedMemoryPeak        int64   `form:"used_memory_peak" json:"used_memory_peak" mapstructure:"used_memory_peak"`
+	UsedMemoryDataset     int64   `form:"used_memory_dataset" json:"used_memory_dataset" mapstructure:"used_memory_dataset"`
+	UsedMemoryLua         int64   `form:"used_memory_lua" json:"used_memory_lua" mapstructure:"used_memory_lua"`
+	Maxmemory             int64   `form:"maxmemory" json:"maxmemory" mapstructure:"maxmemory"`
+	MaxmemoryPolicy       string  `form:"maxmemory_policy" json:"maxmemory_policy" mapstructure:"maxmemory_policy"`
+	MemFragmentationRatio float64 `form:"mem_fragmentation_ratio" json:"mem_fragmentation_ratio" mapstructure:"mem_fragmentation_ratio"`
+	MemAllocator          string  `form:"mem_allocator" json:"mem_allocator" mapstructure:"mem_allocator"`
+}
+
+type InfoPersistence struct {
+	Loading                 int    `form:"loading" json:"loading" mapstructure:"loading"`
+	RdbChangesSinceLastSave int64  `form:"rdb_changes_since_last_save" json:"rdb_changes_since_last_save" mapstructure:"rdb_changes_since_last_save"`
+	RdbBgsaveInProgress     int    `form:"rdb_bgsave_in_progress" json:"rdb_bgsave_in_progress" mapstructure:"rdb_bgsave_in_progress"`
+	RdbLastSaveTime         int64  `form:"rdb_last_save_time" json:"rdb_last_save_time" mapstructure:"rdb_last_save_time"`
+	RdbLastBgsaveStatus     string `form:"rdb_last_bgsave_status" json:"rdb_last_bgsave_status" mapstructure:"rdb_last_bgsave_status"`
+	AofEnabled              int    `form:"aof_enabled" json:"aof_enabled" mapstructure:"aof_enabled"`
+	AofRewriteInProgress    int    `form:"aof_rewrite_in_progress" json:"aof_rewrite_in_progress" mapstructure:"aof_rewrite_in_progress"`
+	AofLastBgrewriteStatus  string `form:"aof_last_bgrewrite_status" json:"aof_last_bgrewrite_status" mapstructure:"aof_last_bgrewrite_status"`
+	AofLastWriteStatus      string `form:"aof_last_write_status" json:"aof_last_write_status" mapstructure:"aof_last_write_status"`
+}
+
+type InfoStats struct {
+	TotalConnectionsReceived int64   `form:"total_connections_received" json:"total_connections_received" mapstructure:"total_connections_received"`
+	TotalCommandsProcessed   int64   `form:"total_commands_processed" json:"total_commands_processed" mapstructure:"total_commands_processed"`
+	InstantaneousOpsPerSec   int64   `form:"instantaneous_ops_per_sec" json:"instantaneous_ops_per_sec" mapstructure:"instantaneous_ops_per_sec"`
+	TotalNetInputBytes       int64   `form:"total_net_input_bytes" json:"total_net_input_bytes" mapstructure:"total_net_input_bytes"`
+	TotalNetOutputBytes      int64   `form:"total_net_output_bytes" json:"total_net_output_bytes" mapstructure:"total_net_output_bytes"`
+	InstantaneousInputKbps   float64 `form:"instantaneous_input_kbps" json:"instantaneous_input_kbps" mapstructure:"instantaneous_input_kbps"`
+	InstantaneousOutputKbps  float64 `form:"instantaneous_output_kbps" json:"instantaneous_output_kbps" mapstructure:"instantaneous_output_kbps"`
+	RejectedConnections      int64   `form:"rejected_connections" json:"rejected_connections" mapstructure:"rejected_connections"`
+	ExpiredKeys              int64   `form:"expired_keys" json:"expired_keys" mapstructure:"expired_keys"`
+	EvictedKeys              int64   `form:"evicted_keys" json:"evicted_keys" mapstructure:"evicted_keys"`
+	KeyspaceHits             int64   `form:"keyspace_hits" json:"keyspace_hits" mapstructure:"keyspace_hits"`
+	KeyspaceMisses           int64   `form:"keyspace_misses" json:"keyspace_misses" mapstructure:"keyspace_misses"`
+	PubsubChannels           int64   `form:"pubsub_channels" json:"pubsub_channels" mapstructure:"pubsub_channels"`
+	PubsubPatterns           int64   `form:"pubsub_patterns" json:"pubsub_patterns" mapstructure:"pubsub_patterns"`
+	LatestForkUsec           int64   `form:"latest_fork_usec" json:"latest_fork_usec" mapstructure:"latest_fork_usec"`
+}
+
+type InfoReplication struct {
+	Role                   string      `form:"role" json:"role" mapstructure:"role"`
+	ConnectedSlaves        int         `form:"connected_slaves" json:"connected_slaves" mapstructure:"connected_slaves"`
+	MasterHost             string      `form:"master_host" json:"master_host" mapstructure:"master_host"`
+	MasterPort             int         `form:"master_port" json:"master_port" mapstructure:"master_port"`
+	MasterLinkStatus       string      `form:"master_link_status" json:"master_link_status" mapstructure:"master_link_status"`
+	MasterLastIoSecondsAgo int64       `form:"master_last_io_seconds_ago" json:"master_last_io_seconds_ago" mapstructure:"master_last_io_seconds_ago"`
+	MasterSyncInProgress   int         `form:"master_sync_in_progress" json:"master_sync_in_progress" mapstructure:"master_sync_in_progress"`
+	MasterReplOffset       int64       `form:"master_repl_offset" json:"master_repl_offset" mapstructure:"master_repl_offset"`
+	SlaveReplOffset        int64       `form:"slave_repl_offset" json:"slave_repl_offset" mapstructure:"slave_repl_offset"`
+	ReplBacklogSize        int64       `form:"repl_backlog_size" json:"repl_backlog_size" mapstructure:"repl_backlog_size"`
+	Slaves                 []InfoSlave `form:"slaves" json:"slaves" mapstructure:"slaves"`
+}
+
+type InfoSlave struct {
+	Ip     string `form:"ip" json:"ip" mapstructure:"ip"`
+	Port   int    `form:"port" json:"port" mapstructure:"port"`
+	State  string `form:"state" json:"state" mapstructure:"state"`
+	Offset int64  `form:"offset" json:"offset" mapstructure:"offset"`
+	Lag    int64  `form:"lag" json:"lag" mapstructure:"lag"`
+}
+
+type InfoKeyspace struct {
+	Keys    int64 `form:"keys" json:"keys" mapstructure:"keys"`
+	Expires int64 `form:"expires" json:"expires" mapstructure:"expires"`
+	AvgTtl  int64 `form:"avg_ttl" json:"avg_ttl" mapstructure:"avg_ttl"`
+}
+
+type InfoCommand struct {
+	Calls         int64   `form:"calls" json:"calls" mapstructure:"calls"`
+	Usec          int64   `form:"usec" json:"usec" mapstructure:"usec"`
+	UsecPerCall   float64 `form:"usec_per_call" json:"usec_per_call" mapstructure:"usec_per_call"`
+	RejectedCalls int64   `form:"rejected_calls" json:"rejected_calls" mapstructure:"rejected_calls"`
+	FailedCalls   int64   `form:"failed_calls" json:"failed_calls" mapstructure:"failed_calls"`
+}
+
+type InfoLatency struct {
+	P50  float64 `form:"p50" json:"p50" mapstructure:"p50"`
+	P99  float64 `form:"p99" json:"p99" mapstructure:"p99"`
+	P999 float64 `form:"p999" json:"p999" mapstructure:"p99.9"`
+}
+
+type InfoReq struct {
+	Client string `form:"client" json:"client" mapstructure:"client"`
+	Node   string `form:"node" json:"node" mapstructure:"node"`       // 集群节点地址, 为空时返回全部节点
+	Fields string `form:"fields" json:"fields" mapstructure:"fields"` // 历史数据字段, 逗号分隔
+	Since  int64  `form:"since" json:"since" mapstructure:"since"`    // 历史数据起始 unix 秒
+	Token  string `form:"token" json:"token" mapstructure:"token"`
+}
+
+type InfoSample struct {
+	Time  int64      `form:"time" json:"time" mapstructure:"time"` // unix 秒
+	Node  string     `form:"node" json:"node" mapstructure:"node"`
+	Error string     `form:"error" json:"error" mapstructure:"error"`
+	Info  *RedisInfo `form:"info" json:"info" mapstructure:"info"`
+}
+
+type InfoPoint struct {
+	Time   int64              `form:"time" json:"time" mapstructure:"time"`
+	Values map[string]float64 `form:"values" json:"values" mapstructure:"values"`
+}
+
+type InfoHistory struct {
+	Node   string      `form:"node" json:"node" mapstructure:"node"`
+	Points []InfoPoint `form:"points" json:"points" mapstructure:"points"`
+}
@@ -15,6 +15,7 @@ type Person struct {
 	Ip      string `form:"ip" json:"ip"`           //登录用户
 	Token   string `form:"token" json:"token"`     // token有效
 	Expires string `form:"expires" json:"expires"` // 到期时间
+	Role    string `form:"role" json:"role"`       // 角色
 }
 
 type TokenCheck struct {
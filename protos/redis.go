@@ -4,9 +4,15 @@ import "time"
 
 type AddCfgReq struct {
 	Name  string `form:"name" json:"name" mapstructure:"name"`
+	Mode  string `form:"mode" json:"mode" mapstructure:"mode"` // single / cluster / sentinel, cluster 时 addr 以逗号分隔
 	Addr  string `form:"addr" json:"addr" mapstructure:"addr"`
 	Pwd   string `form:"pwd" json:"pwd" mapstructure:"pwd"`
+	Db    int    `form:"db" json:"db" mapstructure:"db"`
 	Token string `form:"token" json:"token" mapstructure:"token"`
+
+	MasterName    string `form:"master_name" json:"master_name" mapstructure:"master_name"`          // sentinel 监控的 master 名称
+	SentinelAddrs string `form:"sentinel_addrs" json:"sentinel_addrs" mapstructure:"sentinel_addrs"` // sentinel 节点地址, 逗号分隔
+	SentinelPwd   string `form:"sentinel_pwd" json:"sentinel_pwd" mapstructure:"sentinel_pwd"`
 }
 
 type SearchReq struct {
@@ -27,6 +33,23 @@ type SearchKeyReq struct {
 	Token  string `form:"token" json:"token" mapstructure:"token"`
 	Level  int    `form:"level" json:"level" mapstructure:"level"`
 	Page   int    `form:"page" json:"page" mapstructure:"page"`
+
+	Start    string `form:"start" json:"start" mapstructure:"start"`          // stream 起始ID
+	End      string `form:"end" json:"end" mapstructure:"end"`                // stream 结束ID
+	Group    string `form:"group" json:"group" mapstructure:"group"`          // stream 消费组
+	Consumer string `form:"consumer" json:"consumer" mapstructure:"consumer"` // stream 消费者
+	Idle     int64  `form:"idle" json:"idle" mapstructure:"idle"`             // XCLAIM min-idle 毫秒
+	Hint     string `form:"hint" json:"hint" mapstructure:"hint"`             // 指定展示类型 bitmap/hyperloglog/geo
+
+	Dest    string `form:"dest" json:"dest" mapstructure:"dest"`          // RENAME/COPY 目标key, 为空时与 key 相同
+	DestDb  string `form:"dest_db" json:"dest_db" mapstructure:"dest_db"` // COPY/MOVE 目标db
+	Replace bool   `form:"replace" json:"replace" mapstructure:"replace"` // 目标key存在时覆盖
+
+	Index int64  `form:"index" json:"index" mapstructure:"index"` // list 下标
+	Old   string `form:"old" json:"old" mapstructure:"old"`       // list 下标处页面看到的值, 不一致时视为并发修改
+	Pivot string `form:"pivot" json:"pivot" mapstructure:"pivot"` // LINSERT 参照值, 为空时按 index 插入
+	Where string `form:"where" json:"where" mapstructure:"where"` // LINSERT before, after
+	Count int64  `form:"count" json:"count" mapstructure:"count"` // LREM count
 }
 
 type KeysInfo struct {
@@ -44,6 +67,36 @@ type KeysInfo struct {
 	Total int `form:"total" json:"total" mapstructure:"total"`
 
 	Length int64 `form:"length" json:"length" mapstructure:"length"`
+
+	Stream     []StreamEntry   `form:"stream" json:"stream" mapstructure:"stream"`
+	StreamInfo *StreamInfo     `form:"stream_info" json:"stream_info" mapstructure:"stream_info"`
+	Pending    []StreamPending `form:"pending" json:"pending" mapstructure:"pending"`
+	Next       string          `form:"next" json:"next" mapstructure:"next"` // stream 下一页起始ID
+
+	Notify bool `form:"notify" json:"notify" mapstructure:"notify"` // 实例已开启 keyspace 通知, 页面可订阅自动刷新
+
+	Bitmap *BitmapInfo `form:"bitmap" json:"bitmap" mapstructure:"bitmap"`
+	Geo    []GeoRes    `form:"geo" json:"geo" mapstructure:"geo"`
+
+	Object *KeyObject `form:"object" json:"object,omitempty" mapstructure:"object"`
+	Drift  *ListDrift `form:"drift" json:"drift,omitempty" mapstructure:"drift"`
+}
+
+// ListDrift list 下标处的值与页面不一致, NewIndex 为原值当前所在下标, 找不到时为 -1
+type ListDrift struct {
+	Index    int64  `form:"index" json:"index" mapstructure:"index"`
+	Expected string `form:"expected" json:"expected" mapstructure:"expected"`
+	Current  string `form:"current" json:"current" mapstructure:"current"`
+	NewIndex int64  `form:"new_index" json:"new_index" mapstructure:"new_index"`
+	Offset   int64  `form:"offset" json:"offset" mapstructure:"offset"`
+}
+
+// KeyObject OBJECT 命令信息, freq 只在 LFU 策略下有值
+type KeyObject struct {
+	Encoding string `form:"encoding" json:"encoding" mapstructure:"encoding"`
+	Refcount int64  `form:"refcount" json:"refcount" mapstructure:"refcount"`
+	Idletime int64  `form:"idletime" json:"idletime" mapstructure:"idletime"` // 秒
+	Freq     int64  `form:"freq" json:"freq" mapstructure:"freq"`
 }
 
 type ListRes struct {
@@ -55,3 +108,600 @@ type ZSET struct {
 	Score  float64     `form:"score" json:"score" mapstructure:"score"`
 	Member interface{} `form:"member" json:"member" mapstructure:"member"`
 }
+
+type BitmapInfo struct {
+	Size       int64  `form:"size" json:"size" mapstructure:"size"`                      // 总位数
+	Count      int64  `form:"count" json:"count" mapstructure:"count"`                   // BITCOUNT
+	PageCount  int64  `form:"page_count" json:"page_count" mapstructure:"page_count"`    // 当前页 BITCOUNT
+	FirstSet   int64  `form:"first_set" json:"first_set" mapstructure:"first_set"`       // BITPOS 1
+	FirstClear int64  `form:"first_clear" json:"first_clear" mapstructure:"first_clear"` // BITPOS 0
+	Offset     int64  `form:"offset" json:"offset" mapstructure:"offset"`                // 当前页起始位
+	Bits       string `form:"bits" json:"bits" mapstructure:"bits"`                      // 当前页 0/1
+}
+
+type GeoRes struct {
+	Member    string  `form:"member" json:"member" mapstructure:"member"`
+	Longitude float64 `form:"longitude" json:"longitude" mapstructure:"longitude"`
+	Latitude  float64 `form:"latitude" json:"latitude" mapstructure:"latitude"`
+	Dist      float64 `form:"dist" json:"dist" mapstructure:"dist"`
+}
+
+type StreamEntry struct {
+	Id     string                 `form:"id" json:"id" mapstructure:"id"`
+	Values map[string]interface{} `form:"values" json:"values" mapstructure:"values"`
+}
+
+type StreamInfo struct {
+	Length          int64         `form:"length" json:"length" mapstructure:"length"`
+	LastGeneratedId string        `form:"last_generated_id" json:"last_generated_id" mapstructure:"last_generated_id"`
+	FirstId         string        `form:"first_id" json:"first_id" mapstructure:"first_id"`
+	LastId          string        `form:"last_id" json:"last_id" mapstructure:"last_id"`
+	Groups          []StreamGroup `form:"groups" json:"groups" mapstructure:"groups"`
+}
+
+type StreamGroup struct {
+	Name            string           `form:"name" json:"name" mapstructure:"name"`
+	Consumers       int64            `form:"consumers" json:"consumers" mapstructure:"consumers"`
+	Pending         int64            `form:"pending" json:"pending" mapstructure:"pending"`
+	LastDeliveredId string           `form:"last_delivered_id" json:"last_delivered_id" mapstructure:"last_delivered_id"`
+	ConsumerList    []StreamConsumer `form:"consumer_list" json:"consumer_list" mapstructure:"consumer_list"`
+}
+
+type StreamConsumer struct {
+	Name    string `form:"name" json:"name" mapstructure:"name"`
+	Pending int64  `form:"pending" json:"pending" mapstructure:"pending"`
+	Idle    int64  `form:"idle" json:"idle" mapstructure:"idle"` // 毫秒
+}
+
+type StreamPending struct {
+	Id         string `form:"id" json:"id" mapstructure:"id"`
+	Consumer   string `form:"consumer" json:"consumer" mapstructure:"consumer"`
+	Idle       int64  `form:"idle" json:"idle" mapstructure:"idle"` // 毫秒
+	RetryCount int64  `form:"retry_count" json:"retry_count" mapstructure:"retry_count"`
+}
+
+type AuditReq struct {
+	User   string `form:"user" json:"user" mapstructure:"user"`
+	Client string `form:"client" json:"client" mapstructure:"client"`
+	Key    string `form:"key" json:"key" mapstructure:"key"`       // key 通配符
+	Start  string `form:"start" json:"start" mapstructure:"start"` // 2006-01-02 15:04:05
+	End    string `form:"end" json:"end" mapstructure:"end"`
+	Limit  int    `form:"limit" json:"limit" mapstructure:"limit"`
+	Token  string `form:"token" json:"token" mapstructure:"token"`
+}
+
+type AuditLog struct {
+	Time     string `form:"time" json:"time" mapstructure:"time"`
+	TraceId  string `form:"trace_id" json:"trace_id" mapstructure:"trace_id"`
+	User     string `form:"user" json:"user" mapstructure:"user"`
+	Ip       string `form:"ip" json:"ip" mapstructure:"ip"`
+	Instance string `form:"instance" json:"instance" mapstructure:"instance"`
+	Db       string `form:"db" json:"db" mapstructure:"db"`
+	Command  string `form:"command" json:"command" mapstructure:"command"`
+	Key      string `form:"key" json:"key" mapstructure:"key"`
+	OldSize  int64  `form:"old_size" json:"old_size" mapstructure:"old_size"` // 修改前 MEMORY USAGE 字节数
+	Result   string `form:"result" json:"result" mapstructure:"result"`
+	Error    string `form:"error" json:"error" mapstructure:"error"`
+}
+
+type TrashReq struct {
+	Client string `form:"client" json:"client" mapstructure:"client"`
+	Db     string `form:"db" json:"db" mapstructure:"db"`
+	Id     int64  `form:"id" json:"id" mapstructure:"id"`
+	Token  string `form:"token" json:"token" mapstructure:"token"`
+}
+
+type TrashItem struct {
+	Id        int64  `form:"id" json:"id" mapstructure:"id"`
+	User      string `form:"user" json:"user" mapstructure:"user"`
+	Instance  string `form:"instance" json:"instance" mapstructure:"instance"`
+	Db        string `form:"db" json:"db" mapstructure:"db"`
+	Command   string `form:"command" json:"command" mapstructure:"command"`
+	Key       string `form:"key" json:"key" mapstructure:"key"`
+	Ttl       int64  `form:"ttl" json:"ttl" mapstructure:"ttl"` // 毫秒, 0 不过期
+	Size      int64  `form:"size" json:"size" mapstructure:"size"`
+	CreatedAt string `form:"created_at" json:"created_at" mapstructure:"created_at"`
+}
+
+type JobReq struct {
+	Client  string `form:"client" json:"client" mapstructure:"client"`
+	Db      string `form:"db" json:"db" mapstructure:"db"`
+	Id      int64  `form:"id" json:"id" mapstructure:"id"`
+	Action  string `form:"action" json:"action" mapstructure:"action"`    // delete, expire
+	Pattern string `form:"pattern" json:"pattern" mapstructure:"pattern"` // SCAN match
+	Ttl     int64  `form:"ttl" json:"ttl" mapstructure:"ttl"`             // expire 秒数
+	Batch   int64  `form:"batch" json:"batch" mapstructure:"batch"`       // 每批 key 数
+	Rate    int64  `form:"rate" json:"rate" mapstructure:"rate"`          // 每秒最多处理 key 数, 0 不限制
+	DryRun  bool   `form:"dry_run" json:"dry_run" mapstructure:"dry_run"` // 只统计匹配数量
+	Token   string `form:"token" json:"token" mapstructure:"token"`
+}
+
+type JobInfo struct {
+	Id         int64  `form:"id" json:"id" mapstructure:"id"`
+	User       string `form:"user" json:"user" mapstructure:"user"`
+	Client     string `form:"client" json:"client" mapstructure:"client"`
+	Db         string `form:"db" json:"db" mapstructure:"db"`
+	Action     string `form:"action" json:"action" mapstructure:"action"`
+	Pattern    string `form:"pattern" json:"pattern" mapstructure:"pattern"`
+	Ttl        int64  `form:"ttl" json:"ttl" mapstructure:"ttl"`
+	Target     string `form:"target" json:"target,omitempty" mapstructure:"target"`             // compare 目标实例
+	TargetDb   string `form:"target_db" json:"target_db,omitempty" mapstructure:"target_db"`    // compare 目标db
+	Diffs      int64  `form:"diffs" json:"diffs" mapstructure:"diffs"`                          // compare 差异 key 数, migrate 校验不一致数
+	Failed     int64  `form:"failed" json:"failed,omitempty" mapstructure:"failed"`             // migrate 失败数
+	Checkpoint string `form:"checkpoint" json:"checkpoint,omitempty" mapstructure:"checkpoint"` // migrate 断点名称, 用于恢复
+	Phase      string `form:"phase" json:"phase,omitempty" mapstructure:"phase"`                // migrate 阶段 copy, verify
+	DryRun     bool   `form:"dry_run" json:"dry_run" mapstructure:"dry_run"`
+	Status     string `form:"status" json:"status" mapstructure:"status"` // running, done, canceled, failed
+	Scanned    int64  `form:"scanned" json:"scanned" mapstructure:"scanned"`
+	Processed  int64  `form:"processed" json:"processed" mapstructure:"processed"` // 删除/设置过期成功数
+	Skipped    int64  `form:"skipped" json:"skipped" mapstructure:"skipped"`       // 无权限跳过数
+	Error      string `form:"error" json:"error" mapstructure:"error"`
+	CreatedAt  string `form:"created_at" json:"created_at" mapstructure:"created_at"`
+	FinishedAt string `form:"finished_at" json:"finished_at" mapstructure:"finished_at"`
+}
+
+type ExportReq struct {
+	Client  string `form:"client" json:"client" mapstructure:"client"`
+	Db      string `form:"db" json:"db" mapstructure:"db"`
+	Keys    string `form:"keys" json:"keys" mapstructure:"keys"`          // 逗号分隔的 key
+	Pattern string `form:"pattern" json:"pattern" mapstructure:"pattern"` // SCAN match, keys 为空时使用
+	Format  string `form:"format" json:"format" mapstructure:"format"`    // json, csv, resp
+	Token   string `form:"token" json:"token" mapstructure:"token"`
+}
+
+// ExportItem json lines 导出格式, encoding 为 base64 时所有字符串均为 base64
+type ExportItem struct {
+	Key      string      `form:"key" json:"key" mapstructure:"key"`
+	Type     string      `form:"type" json:"type" mapstructure:"type"`
+	Ttl      int64       `form:"ttl" json:"ttl" mapstructure:"ttl"` // 毫秒, 0 不过期
+	Encoding string      `form:"encoding" json:"encoding,omitempty" mapstructure:"encoding"`
+	Value    interface{} `form:"value" json:"value" mapstructure:"value"`
+}
+
+type ImportReq struct {
+	Client string `form:"client" json:"client" mapstructure:"client"`
+	Db     string `form:"db" json:"db" mapstructure:"db"`
+	Format string `form:"format" json:"format" mapstructure:"format"` // json, csv, resp
+	Policy string `form:"policy" json:"policy" mapstructure:"policy"` // skip, replace, rename
+	Suffix string `form:"suffix" json:"suffix" mapstructure:"suffix"` // rename 时追加的后缀
+	DryRun bool   `form:"dry_run" json:"dry_run" mapstructure:"dry_run"`
+	Data   string `form:"data" json:"data" mapstructure:"data"` // 未上传文件时使用
+	Token  string `form:"token" json:"token" mapstructure:"token"`
+}
+
+type ImportRes struct {
+	Total    int          `form:"total" json:"total" mapstructure:"total"`
+	Created  int          `form:"created" json:"created" mapstructure:"created"`
+	Replaced int          `form:"replaced" json:"replaced" mapstructure:"replaced"`
+	Renamed  int          `form:"renamed" json:"renamed" mapstructure:"renamed"`
+	Skipped  int          `form:"skipped" json:"skipped" mapstructure:"skipped"`
+	Failed   int          `form:"failed" json:"failed" mapstructure:"failed"`
+	Items    []ImportItem `form:"items" json:"items" mapstructure:"items"`
+}
+
+type ImportItem struct {
+	Key    string `form:"key" json:"key" mapstructure:"key"`
+	Type   string `form:"type" json:"type" mapstructure:"type"`
+	Action string `form:"action" json:"action" mapstructure:"action"` // create, replace, rename, skip, fail
+	NewKey string `form:"new_key" json:"new_key" mapstructure:"new_key"`
+	Error  string `form:"error" json:"error" mapstructure:"error"`
+}
+
+type RdbReq struct {
+	Top   int    `form:"top" json:"top" mapstructure:"top"`       // biggest key 个数
+	Level int    `form:"level" json:"level" mapstructure:"level"` // 前缀层级, 同 search
+	Db    string `form:"db" json:"db" mapstructure:"db"`          // 为空时统计所有 db
+	Token string `form:"token" json:"token" mapstructure:"token"`
+}
+
+type RdbReport struct {
+	Keys    int64         `form:"keys" json:"keys" mapstructure:"keys"`
+	Size    int64         `form:"size" json:"size" mapstructure:"size"` // 序列化字节数
+	Biggest []RdbKey      `form:"biggest" json:"biggest" mapstructure:"biggest"`
+	Prefix  []RdbStat     `form:"prefix" json:"prefix" mapstructure:"prefix"`
+	Types   []RdbStat     `form:"types" json:"types" mapstructure:"types"`
+	Expiry  []RdbStat     `form:"expiry" json:"expiry" mapstructure:"expiry"`
+	Dbs     map[int]int64 `form:"dbs" json:"dbs" mapstructure:"dbs"`
+	Error   string        `form:"error" json:"error" mapstructure:"error"` // 解析中断时的错误, 已统计部分仍返回
+}
+
+type RdbKey struct {
+	Db       int    `form:"db" json:"db" mapstructure:"db"`
+	Key      string `form:"key" json:"key" mapstructure:"key"`
+	Type     string `form:"type" json:"type" mapstructure:"type"`
+	Encoding string `form:"encoding" json:"encoding" mapstructure:"encoding"`
+	Size     int64  `form:"size" json:"size" mapstructure:"size"`
+	Elements int64  `form:"elements" json:"elements" mapstructure:"elements"`
+	Expire   int64  `form:"expire" json:"expire" mapstructure:"expire"` // unix 毫秒, 0 不过期
+}
+
+type RdbStat struct {
+	Name     string `form:"name" json:"name" mapstructure:"name"`
+	Keys     int64  `form:"keys" json:"keys" mapstructure:"keys"`
+	Size     int64  `form:"size" json:"size" mapstructure:"size"`
+	Elements int64  `form:"elements" json:"elements" mapstructure:"elements"`
+}
+
+type BigKeyReq struct {
+	Client  string `form:"client" json:"client" mapstructure:"client"`
+	Db      string `form:"db" json:"db" mapstructure:"db"`
+	Pattern string `form:"pattern" json:"pattern" mapstructure:"pattern"` // SCAN match, 默认 *
+	Top     int    `form:"top" json:"top" mapstructure:"top"`             // 每个类型/前缀返回个数
+	Level   int    `form:"level" json:"level" mapstructure:"level"`       // 前缀层级, 同 search
+	Rate    int64  `form:"rate" json:"rate" mapstructure:"rate"`          // 每秒最多扫描 key 数, 0 不限制
+	Hot     bool   `form:"hot" json:"hot" mapstructure:"hot"`             // LFU 策略下统计 OBJECT FREQ
+	Token   string `form:"token" json:"token" mapstructure:"token"`
+}
+
+type BigKeyInfo struct {
+	Client     string              `form:"client" json:"client" mapstructure:"client"`
+	Db         string              `form:"db" json:"db" mapstructure:"db"`
+	Pattern    string              `form:"pattern" json:"pattern" mapstructure:"pattern"`
+	User       string              `form:"user" json:"user" mapstructure:"user"`
+	Status     string              `form:"status" json:"status" mapstructure:"status"` // running, done, canceled, failed
+	Scanned    int64               `form:"scanned" json:"scanned" mapstructure:"scanned"`
+	Size       int64               `form:"size" json:"size" mapstructure:"size"` // MEMORY USAGE 合计
+	Error      string              `form:"error" json:"error" mapstructure:"error"`
+	Lfu        bool                `form:"lfu" json:"lfu" mapstructure:"lfu"` // 是否统计了热点 key
+	CreatedAt  string              `form:"created_at" json:"created_at" mapstructure:"created_at"`
+	FinishedAt string              `form:"finished_at" json:"finished_at" mapstructure:"finished_at"`
+	Types      []BigKeyStat        `form:"types" json:"types" mapstructure:"types"`
+	Prefix     []BigKeyStat        `form:"prefix" json:"prefix" mapstructure:"prefix"`
+	TopByType  map[string][]BigKey `form:"top_by_type" json:"top_by_type" mapstructure:"top_by_type"`
+	HotKeys    []BigKey            `form:"hot_keys" json:"hot_keys" mapstructure:"hot_keys"`
+}
+
+type BigKey struct {
+	Key      string `form:"key" json:"key" mapstructure:"key"`
+	Type     string `form:"type" json:"type" mapstructure:"type"`
+	Size     int64  `form:"size" json:"size" mapstructure:"size"`             // MEMORY USAGE
+	Elements int64  `form:"elements" json:"elements" mapstructure:"elements"` // STRLEN/HLEN/LLEN/SCARD/ZCARD/XLEN
+	Freq     int64  `form:"freq" json:"freq" mapstructure:"freq"`             // OBJECT FREQ
+}
+
+type BigKeyStat struct {
+	Name     string   `form:"name" json:"name" mapstructure:"name"`
+	Keys     int64    `form:"keys" json:"keys" mapstructure:"keys"`
+	Size     int64    `form:"size" json:"size" mapstructure:"size"`
+	Elements int64    `form:"elements" json:"elements" mapstructure:"elements"`
+	Top      []BigKey `form:"top" json:"top" mapstructure:"top"` // 前缀下最大的 key
+}
+
+type SlowlogReq struct {
+	Client string `form:"client" json:"client" mapstructure:"client"`
+	Level  int    `form:"level" json:"level" mapstructure:"level"` // key 前缀层级, 0 时只保留第一段
+	Limit  int    `form:"limit" json:"limit" mapstructure:"limit"` // 返回最近的记录数
+	Clear  bool   `form:"clear" json:"clear" mapstructure:"clear"` // reset 时同时清除已保留的记录
+	Token  string `form:"token" json:"token" mapstructure:"token"`
+}
+
+type SlowlogEntry struct {
+	Id         int64    `form:"id" json:"id" mapstructure:"id"`
+	Node       string   `form:"node" json:"node" mapstructure:"node"`
+	Time       int64    `form:"time" json:"time" mapstructure:"time"`             // unix 秒
+	Duration   int64    `form:"duration" json:"duration" mapstructure:"duration"` // 微秒
+	Args       []string `form:"args" json:"args" mapstructure:"args"`
+	Addr       string   `form:"addr" json:"addr" mapstructure:"addr"`
+	ClientName string   `form:"client_name" json:"client_name" mapstructure:"client_name"`
+	Template   string   `form:"template" json:"template" mapstructure:"template"`
+}
+
+type SlowlogStat struct {
+	Template string `form:"template" json:"template" mapstructure:"template"`
+	Count    int64  `form:"count" json:"count" mapstructure:"count"`
+	Total    int64  `form:"total" json:"total" mapstructure:"total"` // 微秒
+	Max      int64  `form:"max" json:"max" mapstructure:"max"`
+	Avg      int64  `form:"avg" json:"avg" mapstructure:"avg"`
+	Last     int64  `form:"last" json:"last" mapstructure:"last"` // 最近一次 unix 秒
+}
+
+type SlowlogRes struct {
+	Entries []SlowlogEntry `form:"entries" json:"entries" mapstructure:"entries"`
+	Stats   []SlowlogStat  `form:"stats" json:"stats" mapstructure:"stats"`
+	Errors  []string       `form:"errors" json:"errors" mapstructure:"errors"` // 获取失败的节点
+}
+
+type ClientListReq struct {
+	Client string `form:"client" json:"client" mapstructure:"client"`
+	Group  string `form:"group" json:"group" mapstructure:"group"`    // ip, name, user, cmd, 为空时不分组
+	Sort   string `form:"sort" json:"sort" mapstructure:"sort"`       // age, idle, memory, db, id
+	Asc    bool   `form:"asc" json:"asc" mapstructure:"asc"`          // 默认倒序
+	Filter string `form:"filter" json:"filter" mapstructure:"filter"` // addr/name/user/cmd 包含
+	Flags  string `form:"flags" json:"flags" mapstructure:"flags"`    // 包含全部 flag, 如 S, x
+	Limit  int    `form:"limit" json:"limit" mapstructure:"limit"`
+	Token  string `form:"token" json:"token" mapstructure:"token"`
+}
+
+type ClientInfo struct {
+	Node   string `form:"node" json:"node" mapstructure:"node"`
+	Id     int64  `form:"id" json:"id" mapstructure:"id"`
+	Addr   string `form:"addr" json:"addr" mapstructure:"addr"`
+	Ip     string `form:"ip" json:"ip" mapstructure:"ip"`
+	Name   string `form:"name" json:"name" mapstructure:"name"`
+	Age    int64  `form:"age" json:"age" mapstructure:"age"`    // 秒
+	Idle   int64  `form:"idle" json:"idle" mapstructure:"idle"` // 秒
+	Db     int    `form:"db" json:"db" mapstructure:"db"`
+	Cmd    string `form:"cmd" json:"cmd" mapstructure:"cmd"`
+	Flags  string `form:"flags" json:"flags" mapstructure:"flags"`
+	User   string `form:"user" json:"user" mapstructure:"user"`
+	Memory int64  `form:"memory" json:"memory" mapstructure:"memory"` // tot-mem, 低版本为 qbuf + omem
+}
+
+type ClientGroup struct {
+	Name    string `form:"name" json:"name" mapstructure:"name"`
+	Count   int64  `form:"count" json:"count" mapstructure:"count"`
+	Memory  int64  `form:"memory" json:"memory" mapstructure:"memory"`
+	MaxAge  int64  `form:"max_age" json:"max_age" mapstructure:"max_age"`
+	MaxIdle int64  `form:"max_idle" json:"max_idle" mapstructure:"max_idle"`
+}
+
+type ClientListRes struct {
+	Total   int           `form:"total" json:"total" mapstructure:"total"`
+	Clients []ClientInfo  `form:"clients" json:"clients" mapstructure:"clients"`
+	Groups  []ClientGroup `form:"groups" json:"groups" mapstructure:"groups"`
+	Errors  []string      `form:"errors" json:"errors" mapstructure:"errors"`
+}
+
+type ClientKillReq struct {
+	Client string `form:"client" json:"client" mapstructure:"client"`
+	Node   string `form:"node" json:"node" mapstructure:"node"` // 按 id/addr 断开时必填, 按 user 断开时为空表示所有 master
+	Id     int64  `form:"id" json:"id" mapstructure:"id"`
+	Addr   string `form:"addr" json:"addr" mapstructure:"addr"`
+	User   string `form:"user" json:"user" mapstructure:"user"`
+	Token  string `form:"token" json:"token" mapstructure:"token"`
+}
+
+type ConsoleReq struct {
+	Client  string `form:"client" json:"client" mapstructure:"client"`
+	Db      string `form:"db" json:"db" mapstructure:"db"`
+	Command string `form:"command" json:"command" mapstructure:"command"` // redis-cli 格式, 支持引号
+	Token   string `form:"token" json:"token" mapstructure:"token"`
+}
+
+// ReplyNode type 为 string, integer, array, nil, error; array 的 value 为元素个数
+type ReplyNode struct {
+	Type     string      `form:"type" json:"type" mapstructure:"type"`
+	Value    string      `form:"value" json:"value" mapstructure:"value"`
+	Children []ReplyNode `form:"children" json:"children,omitempty" mapstructure:"children"`
+}
+
+type ConsoleRes struct {
+	Command  string    `form:"command" json:"command" mapstructure:"command"`
+	Reply    ReplyNode `form:"reply" json:"reply" mapstructure:"reply"`
+	Duration int64     `form:"duration" json:"duration" mapstructure:"duration"` // 毫秒
+}
+
+// MonitorReq 过滤条件可在连接后通过 websocket 发送 json 修改
+type MonitorReq struct {
+	Client  string `form:"client" json:"client" mapstructure:"client"`
+	Node    string `form:"node" json:"node" mapstructure:"node"`          // 为空时监听所有 master
+	Key     string `form:"key" json:"key" mapstructure:"key"`             // key 通配符
+	Command string `form:"command" json:"command" mapstructure:"command"` // 命令, 逗号分隔
+	Addr    string `form:"addr" json:"addr" mapstructure:"addr"`          // 客户端地址包含
+	Token   string `form:"token" json:"token" mapstructure:"token"`
+}
+
+type MonitorLine struct {
+	Node    string   `form:"node" json:"node" mapstructure:"node"`
+	Time    float64  `form:"time" json:"time" mapstructure:"time"` // unix 秒, 带微秒
+	Db      int      `form:"db" json:"db" mapstructure:"db"`
+	Addr    string   `form:"addr" json:"addr" mapstructure:"addr"` // 客户端地址, lua 脚本为 lua
+	Command string   `form:"command" json:"command" mapstructure:"command"`
+	Args    []string `form:"args" json:"args" mapstructure:"args"`
+}
+
+// MonitorMsg type 为 line, error, closed
+type MonitorMsg struct {
+	Type    string       `form:"type" json:"type" mapstructure:"type"`
+	Line    *MonitorLine `form:"line" json:"line,omitempty" mapstructure:"line"`
+	Message string       `form:"message" json:"message,omitempty" mapstructure:"message"`
+	Dropped int64        `form:"dropped" json:"dropped,omitempty" mapstructure:"dropped"` // 超出速率丢弃的行数
+}
+
+type KeyspaceReq struct {
+	Client string `form:"client" json:"client" mapstructure:"client"`
+	Db     string `form:"db" json:"db" mapstructure:"db"`
+	Key    string `form:"key" json:"key" mapstructure:"key"`          // key 通配符, 为空时为 *
+	Events string `form:"events" json:"events" mapstructure:"events"` // 逗号分隔, 不为空时订阅 keyevent 频道
+	Enable bool   `form:"enable" json:"enable" mapstructure:"enable"` // 未开启通知时由 admin 确认开启
+	Token  string `form:"token" json:"token" mapstructure:"token"`
+}
+
+type KeyEvent struct {
+	Node  string `form:"node" json:"node" mapstructure:"node"`
+	Db    string `form:"db" json:"db" mapstructure:"db"`
+	Key   string `form:"key" json:"key" mapstructure:"key"`
+	Event string `form:"event" json:"event" mapstructure:"event"` // set, del, expired, hset ...
+	Time  int64  `form:"time" json:"time" mapstructure:"time"`    // 毫秒
+}
+
+type PubsubReq struct {
+	Client  string `form:"client" json:"client" mapstructure:"client"`
+	Pattern string `form:"pattern" json:"pattern" mapstructure:"pattern"` // PUBSUB CHANNELS 的 pattern, 为空时为 *
+	Token   string `form:"token" json:"token" mapstructure:"token"`
+}
+
+type PubsubChannel struct {
+	Name        string `form:"name" json:"name" mapstructure:"name"`
+	Subscribers int64  `form:"subscribers" json:"subscribers" mapstructure:"subscribers"`
+}
+
+type PubsubRes struct {
+	Channels []PubsubChannel `form:"channels" json:"channels" mapstructure:"channels"`
+	Patterns int64           `form:"patterns" json:"patterns" mapstructure:"patterns"` // NUMPAT 合计
+	Errors   []string        `form:"errors" json:"errors" mapstructure:"errors"`
+}
+
+type PublishReq struct {
+	Client  string `form:"client" json:"client" mapstructure:"client"`
+	Channel string `form:"channel" json:"channel" mapstructure:"channel"`
+	Message string `form:"message" json:"message" mapstructure:"message"`
+	Token   string `form:"token" json:"token" mapstructure:"token"`
+}
+
+type SubscribeReq struct {
+	Client  string `form:"client" json:"client" mapstructure:"client"`
+	Pattern string `form:"pattern" json:"pattern" mapstructure:"pattern"` // 逗号分隔
+	Token   string `form:"token" json:"token" mapstructure:"token"`
+}
+
+// SubscribeUpdate 订阅中通过 websocket 修改 pattern
+type SubscribeUpdate struct {
+	Subscribe   []string `json:"subscribe"`
+	Unsubscribe []string `json:"unsubscribe"`
+}
+
+// PubsubMsg type 为 message, error, closed
+type PubsubMsg struct {
+	Type    string `form:"type" json:"type" mapstructure:"type"`
+	Channel string `form:"channel" json:"channel,omitempty" mapstructure:"channel"`
+	Pattern string `form:"pattern" json:"pattern,omitempty" mapstructure:"pattern"`
+	Payload string `form:"payload" json:"payload" mapstructure:"payload"`
+	Time    int64  `form:"time" json:"time,omitempty" mapstructure:"time"`          // 毫秒
+	Dropped int64  `form:"dropped" json:"dropped,omitempty" mapstructure:"dropped"` // 缓冲已满丢弃的消息数
+}
+
+type ConfigReq struct {
+	Client   string `form:"client" json:"client" mapstructure:"client"`
+	Node     string `form:"node" json:"node" mapstructure:"node"`             // 为空时读取第一个 master, 修改所有 master
+	Filter   string `form:"filter" json:"filter" mapstructure:"filter"`       // 参数名包含
+	Baseline string `form:"baseline" json:"baseline" mapstructure:"baseline"` // 与基线对比
+	Compare  string `form:"compare" json:"compare" mapstructure:"compare"`    // 与其他实例对比
+	OnlyDiff bool   `form:"only_diff" json:"only_diff" mapstructure:"only_diff"`
+	Token    string `form:"token" json:"token" mapstructure:"token"`
+}
+
+type ConfigParam struct {
+	Name    string `form:"name" json:"name" mapstructure:"name"`
+	Value   string `form:"value" json:"value" mapstructure:"value"`
+	Compare string `form:"compare" json:"compare,omitempty" mapstructure:"compare"` // 基线或对比实例的值
+	Diff    bool   `form:"diff" json:"diff" mapstructure:"diff"`
+}
+
+type ConfigGroup struct {
+	Name   string        `form:"name" json:"name" mapstructure:"name"` // memory, persistence, replication ...
+	Params []ConfigParam `form:"params" json:"params" mapstructure:"params"`
+}
+
+type ConfigRes struct {
+	Node   string        `form:"node" json:"node" mapstructure:"node"`
+	Diffs  int           `form:"diffs" json:"diffs" mapstructure:"diffs"`
+	Groups []ConfigGroup `form:"groups" json:"groups" mapstructure:"groups"`
+}
+
+type ConfigSetReq struct {
+	Client string `form:"client" json:"client" mapstructure:"client"`
+	Node   string `form:"node" json:"node" mapstructure:"node"`
+	Name   string `form:"name" json:"name" mapstructure:"name"`
+	Value  string `form:"value" json:"value" mapstructure:"value"`
+	Token  string `form:"token" json:"token" mapstructure:"token"`
+}
+
+type BaselineReq struct {
+	Client string `form:"client" json:"client" mapstructure:"client"`
+	Node   string `form:"node" json:"node" mapstructure:"node"`
+	Name   string `form:"name" json:"name" mapstructure:"name"`
+	Token  string `form:"token" json:"token" mapstructure:"token"`
+}
+
+type ConfigBaseline struct {
+	Name      string            `form:"name" json:"name" mapstructure:"name"`
+	Client    string            `form:"client" json:"client" mapstructure:"client"` // 来源实例
+	Node      string            `form:"node" json:"node" mapstructure:"node"`
+	Params    map[string]string `form:"params" json:"params,omitempty" mapstructure:"params"`
+	CreatedBy string            `form:"created_by" json:"created_by" mapstructure:"created_by"`
+	CreatedAt string            `form:"created_at" json:"created_at" mapstructure:"created_at"`
+}
+
+type DiffReq struct {
+	Client   string `form:"client" json:"client" mapstructure:"client"`
+	Db       string `form:"db" json:"db" mapstructure:"db"`
+	Target   string `form:"target" json:"target" mapstructure:"target"`
+	TargetDb string `form:"target_db" json:"target_db" mapstructure:"target_db"`
+	Pattern  string `form:"pattern" json:"pattern" mapstructure:"pattern"`    // SCAN match
+	Batch    int64  `form:"batch" json:"batch" mapstructure:"batch"`          // 每批 key 数
+	Rate     int64  `form:"rate" json:"rate" mapstructure:"rate"`             // 每秒最多对比 key 数, 0 不限制
+	NoValue  bool   `form:"no_value" json:"no_value" mapstructure:"no_value"` // 只对比是否存在/类型/TTL
+	Token    string `form:"token" json:"token" mapstructure:"token"`
+}
+
+// DiffItem kind 为 only_source, only_target, type, ttl, value, migrate 失败时为 failed
+type DiffItem struct {
+	Key        string      `form:"key" json:"key" mapstructure:"key"`
+	Kind       string      `form:"kind" json:"kind" mapstructure:"kind"`
+	SourceType string      `form:"source_type" json:"source_type" mapstructure:"source_type"`
+	TargetType string      `form:"target_type" json:"target_type" mapstructure:"target_type"`
+	SourceTtl  int64       `form:"source_ttl" json:"source_ttl" mapstructure:"source_ttl"` // 毫秒, -1 不过期
+	TargetTtl  int64       `form:"target_ttl" json:"target_ttl" mapstructure:"target_ttl"`
+	Fields     []DiffField `form:"fields" json:"fields,omitempty" mapstructure:"fields"` // 不同的元素, 最多 DiffMaxFields 个
+}
+
+// DiffField list 的 field 为下标, string 为空, set 只有 member
+type DiffField struct {
+	Field   string `form:"field" json:"field" mapstructure:"field"`
+	Source  string `form:"source" json:"source" mapstructure:"source"`
+	Target  string `form:"target" json:"target" mapstructure:"target"`
+	Missing string `form:"missing" json:"missing,omitempty" mapstructure:"missing"` // source, target
+}
+
+type DiffReportReq struct {
+	Id    int64  `form:"id" json:"id" mapstructure:"id"`
+	Kind  string `form:"kind" json:"kind" mapstructure:"kind"` // 为空时返回全部
+	Page  int    `form:"page" json:"page" mapstructure:"page"`
+	Size  int    `form:"size" json:"size" mapstructure:"size"`
+	Token string `form:"token" json:"token" mapstructure:"token"`
+}
+
+type DiffReport struct {
+	Job   JobInfo    `form:"job" json:"job" mapstructure:"job"`
+	Total int        `form:"total" json:"total" mapstructure:"total"`
+	Page  int        `form:"page" json:"page" mapstructure:"page"`
+	Items []DiffItem `form:"items" json:"items" mapstructure:"items"`
+}
+
+// DiffSyncReq 将 source 的 key 单向同步到 target, source 不存在时删除 target 的 key
+type DiffSyncReq struct {
+	Id    int64  `form:"id" json:"id" mapstructure:"id"`
+	Keys  string `form:"keys" json:"keys" mapstructure:"keys"` // 逗号分隔
+	Token string `form:"token" json:"token" mapstructure:"token"`
+}
+
+type DiffSyncItem struct {
+	Key    string `form:"key" json:"key" mapstructure:"key"`
+	Action string `form:"action" json:"action" mapstructure:"action"` // replaced, deleted, failed
+	Error  string `form:"error" json:"error,omitempty" mapstructure:"error"`
+}
+
+type MigrateReq struct {
+	Client   string `form:"client" json:"client" mapstructure:"client"`
+	Db       string `form:"db" json:"db" mapstructure:"db"`
+	Target   string `form:"target" json:"target" mapstructure:"target"`
+	TargetDb string `form:"target_db" json:"target_db" mapstructure:"target_db"`
+	Pattern  string `form:"pattern" json:"pattern" mapstructure:"pattern"` // SCAN match
+	Policy   string `form:"policy" json:"policy" mapstructure:"policy"`    // skip, replace
+	Method   string `form:"method" json:"method" mapstructure:"method"`    // auto, dump, migrate; auto 在 target 为单机时使用 MIGRATE
+	Batch    int64  `form:"batch" json:"batch" mapstructure:"batch"`       // 每批 key 数
+	Rate     int64  `form:"rate" json:"rate" mapstructure:"rate"`          // 每秒最多迁移 key 数, 0 不限制
+	Verify   string `form:"verify" json:"verify" mapstructure:"verify"`    // 为空不校验, basic 校验类型/TTL, value 校验元素
+	Token    string `form:"token" json:"token" mapstructure:"token"`
+}
+
+type MigrateResumeReq struct {
+	Checkpoint string `form:"checkpoint" json:"checkpoint" mapstructure:"checkpoint"`
+	Token      string `form:"token" json:"token" mapstructure:"token"`
+}
+
+// MigrateCheckpoint 每个 source 节点的 SCAN 游标, 恢复时从游标继续
+type MigrateCheckpoint struct {
+	Name      string            `json:"name"`
+	User      string            `json:"user"`
+	Req       MigrateReq        `json:"req"`
+	Cursors   map[string]uint64 `json:"cursors"`
+	Finished  map[string]bool   `json:"finished"` // 已扫描完成的节点
+	Scanned   int64             `json:"scanned"`
+	Copied    int64             `json:"copied"`
+	Skipped   int64             `json:"skipped"`
+	Failed    int64             `json:"failed"`
+	Done      bool              `json:"done"` // 复制阶段已完成
+	UpdatedAt string            `json:"updated_at"`
+}